@@ -0,0 +1,474 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"math"
+	"strings"
+)
+
+// tbcdDigits are the characters of the telephony bcd encoding, indexed
+// by nibble. 0xF is the filler that pads numbers of odd length
+const tbcdDigits = "0123456789*#abc"
+
+/* nibble methods */
+
+// ReadNibble returns the nibble located at the specified nibble
+// offset without modifying the internal offset value. even offsets
+// refer to the high nibble of a byte and odd ones to the low nibble
+func (b *Buffer) ReadNibble(off int64) byte {
+
+	if off > (b.cap*2 - 1) {
+
+		panic(BufferOverreadError.at(off/2, 1, b.cap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderreadError.at(off/2, 1, b.cap))
+
+	}
+
+	return (b.buf[off/2] >> uint(4*(1-off%2))) & 0x0F
+
+}
+
+// WriteNibble sets the nibble located at the specified nibble offset
+// to the low four bits of v without modifying the internal offset
+// value
+func (b *Buffer) WriteNibble(off int64, v byte) {
+
+	if off > (b.cap*2 - 1) {
+
+		panic(BufferOverwriteError.at(off/2, 1, b.cap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderwriteError.at(off/2, 1, b.cap))
+
+	}
+
+	shift := uint(4 * (1 - off%2))
+	b.buf[off/2] = (b.buf[off/2] &^ (0x0F << shift)) | (v&0x0F)<<shift
+
+}
+
+/* bcd methods */
+
+// ReadBCD reads n bytes of packed bcd, two digits per byte with the
+// most significant one in the high nibble, from the buffer at the
+// specified offset without modifying the internal offset value
+func (b *Buffer) ReadBCD(off, n int64) (out uint64, err error) {
+
+	if n < 0 {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+
+	for _, c := range b.ReadBytes(off, n) {
+
+		for _, d := range [2]byte{c >> 4, c & 0x0F} {
+
+			if d > 9 {
+
+				return 0, BCDInvalidDigitError
+
+			}
+
+			if out > (math.MaxUint64-uint64(d))/10 {
+
+				return 0, BCDOverflowError
+
+			}
+			out = out*10 + uint64(d)
+
+		}
+
+	}
+	return
+
+}
+
+// ReadBCDNext reads n bytes of packed bcd from the buffer at the
+// current offset and moves the offset forward the amount of bytes
+// read if no error occurs
+func (b *Buffer) ReadBCDNext(n int64) (out uint64, err error) {
+
+	if out, err = b.ReadBCD(b.off, n); err == nil {
+
+		b.traced(b.off*8, n*8)
+		b.SeekByte(n, true)
+
+	}
+	return
+
+}
+
+// ReadBCDString reads n bytes of packed bcd from the buffer at the
+// specified offset as a string of 2n digits, keeping leading zeros,
+// without modifying the internal offset value
+func (b *Buffer) ReadBCDString(off, n int64) (string, error) {
+
+	if n < 0 {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+
+	var sb strings.Builder
+	for _, c := range b.ReadBytes(off, n) {
+
+		if c>>4 > 9 || c&0x0F > 9 {
+
+			return "", BCDInvalidDigitError
+
+		}
+		sb.WriteByte('0' + c>>4)
+		sb.WriteByte('0' + c&0x0F)
+
+	}
+	return sb.String(), nil
+
+}
+
+// WriteBCD writes v as n bytes of packed bcd, padded with leading
+// zeros, to the buffer at the specified offset without modifying the
+// internal offset value. if v has more than 2n digits, nothing is
+// written and BCDOverflowError is returned
+func (b *Buffer) WriteBCD(off, n int64, v uint64) error {
+
+	b.bcdCheck(off, n)
+
+	data := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+
+		data[i] = byte(v%10) | byte(v/10%10)<<4
+		v /= 100
+
+	}
+
+	if v != 0 {
+
+		return BCDOverflowError
+
+	}
+
+	if n == 0 {
+
+		return nil
+
+	}
+
+	b.WriteBytes(off, data)
+	return nil
+
+}
+
+// WriteBCDNext writes v as n bytes of packed bcd to the buffer at the
+// current offset and moves the offset forward the amount of bytes
+// written if no error occurs
+func (b *Buffer) WriteBCDNext(n int64, v uint64) (err error) {
+
+	if err = b.WriteBCD(b.off, n, v); err == nil {
+
+		b.traced(b.off*8, n*8)
+		b.SeekByte(n, true)
+
+	}
+	return
+
+}
+
+// WriteBCDString writes the decimal digits of s as n bytes of packed
+// bcd, padded with leading zeros, to the buffer at the specified
+// offset without modifying the internal offset value
+func (b *Buffer) WriteBCDString(off, n int64, s string) error {
+
+	b.bcdCheck(off, n)
+
+	if int64(len(s)) > n*2 {
+
+		return BCDOverflowError
+
+	}
+
+	data := make([]byte, n)
+	for i := 0; i < len(s); i++ {
+
+		c := s[len(s)-1-i]
+		if c < '0' || c > '9' {
+
+			return BCDInvalidDigitError
+
+		}
+		data[n-1-int64(i/2)] |= (c - '0') << uint(4*(i%2))
+
+	}
+
+	if n == 0 {
+
+		return nil
+
+	}
+
+	b.WriteBytes(off, data)
+	return nil
+
+}
+
+// ReadSwappedBCDString reads n bytes of swapped-nibble bcd, as used
+// for telephone numbers in gsm and smart cards, from the buffer at the
+// specified offset without modifying the internal offset value. the
+// first digit of each byte is in its low nibble, the nibbles 0xA to
+// 0xE stand for the characters "*#abc", and a 0xF nibble ends the
+// string. anything but filler after it is invalid
+func (b *Buffer) ReadSwappedBCDString(off, n int64) (string, error) {
+
+	if n < 0 {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+
+	var (
+		sb  strings.Builder
+		end = false
+	)
+
+	for _, c := range b.ReadBytes(off, n) {
+
+		for _, d := range [2]byte{c & 0x0F, c >> 4} {
+
+			switch {
+
+			case d == 0x0F:
+				end = true
+
+			case end:
+				return "", BCDInvalidDigitError
+
+			default:
+				sb.WriteByte(tbcdDigits[d])
+
+			}
+
+		}
+
+	}
+	return sb.String(), nil
+
+}
+
+// WriteSwappedBCDString writes s as n bytes of swapped-nibble bcd,
+// padded with 0xF filler nibbles, to the buffer at the specified
+// offset without modifying the internal offset value. s may contain
+// decimal digits and the characters "*#abc"
+func (b *Buffer) WriteSwappedBCDString(off, n int64, s string) error {
+
+	b.bcdCheck(off, n)
+
+	if int64(len(s)) > n*2 {
+
+		return BCDOverflowError
+
+	}
+
+	data := make([]byte, n)
+	for i := range data {
+
+		data[i] = 0xFF
+
+	}
+
+	for i := 0; i < len(s); i++ {
+
+		d := strings.IndexByte(tbcdDigits, s[i])
+		if d < 0 {
+
+			return BCDInvalidDigitError
+
+		}
+
+		shift := uint(4 * (i % 2))
+		data[i/2] = (data[i/2] &^ (0x0F << shift)) | byte(d)<<shift
+
+	}
+
+	if n == 0 {
+
+		return nil
+
+	}
+
+	b.WriteBytes(off, data)
+	return nil
+
+}
+
+/* packed decimal methods */
+
+// ReadPackedDecimal reads an n byte signed packed decimal number, as
+// stored by cobol comp-3 fields, from the buffer at the specified
+// offset without modifying the internal offset value. the field holds
+// 2n-1 digits followed by a sign nibble, where 0xB and 0xD mark
+// negative numbers and 0xA, 0xC, 0xE and 0xF positive ones
+func (b *Buffer) ReadPackedDecimal(off, n int64) (out int64, err error) {
+
+	if n < 0 {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+
+	data := b.ReadBytes(off, n)
+	if n == 0 {
+
+		return 0, BCDInvalidDigitError
+
+	}
+
+	var v uint64
+	for i := int64(0); i < n*2-1; i++ {
+
+		d := (data[i/2] >> uint(4*(1-i%2))) & 0x0F
+		if d > 9 {
+
+			return 0, BCDInvalidDigitError
+
+		}
+
+		if v > (math.MaxInt64-uint64(d))/10 {
+
+			return 0, BCDOverflowError
+
+		}
+		v = v*10 + uint64(d)
+
+	}
+
+	switch data[n-1] & 0x0F {
+
+	case 0x0B, 0x0D:
+		return -int64(v), nil
+
+	case 0x0A, 0x0C, 0x0E, 0x0F:
+		return int64(v), nil
+
+	}
+	return 0, BCDInvalidDigitError
+
+}
+
+// ReadPackedDecimalNext reads an n byte signed packed decimal number
+// from the buffer at the current offset and moves the offset forward
+// the amount of bytes read if no error occurs
+func (b *Buffer) ReadPackedDecimalNext(n int64) (out int64, err error) {
+
+	if out, err = b.ReadPackedDecimal(b.off, n); err == nil {
+
+		b.traced(b.off*8, n*8)
+		b.SeekByte(n, true)
+
+	}
+	return
+
+}
+
+// WritePackedDecimal writes v as an n byte signed packed decimal
+// number, padded with leading zeros and using the preferred 0xC and
+// 0xD sign nibbles, to the buffer at the specified offset without
+// modifying the internal offset value
+func (b *Buffer) WritePackedDecimal(off, n int64, v int64) error {
+
+	b.bcdCheck(off, n)
+
+	if n == 0 {
+
+		return BCDOverflowError
+
+	}
+
+	var (
+		data = make([]byte, n)
+		u    = uint64(v)
+	)
+
+	data[n-1] = 0x0C
+	if v < 0 {
+
+		data[n-1] = 0x0D
+		u = -u
+
+	}
+
+	for i := n*2 - 2; i >= 0; i-- {
+
+		data[i/2] |= byte(u%10) << uint(4*(1-i%2))
+		u /= 10
+
+	}
+
+	if u != 0 {
+
+		return BCDOverflowError
+
+	}
+
+	b.WriteBytes(off, data)
+	return nil
+
+}
+
+// WritePackedDecimalNext writes v as an n byte signed packed decimal
+// number to the buffer at the current offset and moves the offset
+// forward the amount of bytes written if no error occurs
+func (b *Buffer) WritePackedDecimalNext(n int64, v int64) (err error) {
+
+	if err = b.WritePackedDecimal(b.off, n, v); err == nil {
+
+		b.traced(b.off*8, n*8)
+		b.SeekByte(n, true)
+
+	}
+	return
+
+}
+
+/* internal use methods */
+
+// bcdCheck panics if an n byte field at off does not fit in the
+// buffer, before anything is allocated for it
+func (b *Buffer) bcdCheck(off, n int64) {
+
+	if n < 0 {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+
+	if off > b.cap || n > b.cap-off {
+
+		panic(BufferOverwriteError.at(off, n, b.cap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderwriteError.at(off, n, b.cap))
+
+	}
+
+}
@@ -0,0 +1,350 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+tests
+
+*/
+
+func TestBufferNibble(t *testing.T) {
+
+	buf := NewBuffer([]byte{0xAB, 0xCD})
+
+	if out := []byte{buf.ReadNibble(0), buf.ReadNibble(1), buf.ReadNibble(3)}; !cmp.Equal([]byte{0x0A, 0x0B, 0x0D}, out) {
+
+		t.Fatalf("expected nibbles do not match the ones gotten (got %#v)", out)
+
+	}
+
+	buf.WriteNibble(1, 0xF3)
+	buf.WriteNibble(2, 0x04)
+	if !cmp.Equal([]byte{0xA3, 0x4D}, buf.Bytes()) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v)", buf.Bytes())
+
+	}
+
+	defer panicChecker(t, BufferOverreadError)
+	buf.ReadNibble(4)
+
+}
+
+func TestBufferBCD(t *testing.T) {
+
+	buf := NewBuffer(make([]byte, 4))
+
+	if err := buf.WriteBCDNext(3, 12345); err != nil {
+
+		t.Fatalf("unexpected error writing bcd (%v)", err)
+
+	}
+
+	if !cmp.Equal([]byte{0x01, 0x23, 0x45, 0x00}, buf.Bytes()) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v)", buf.Bytes())
+
+	}
+
+	if out, err := buf.ReadBCD(0x00, 3); err != nil || out != 12345 {
+
+		t.Fatalf("expected uint64 does not match the one gotten (got %d, %v)", out, err)
+
+	}
+
+	if out, err := buf.ReadBCDString(0x00, 3); err != nil || out != "012345" {
+
+		t.Fatalf("expected string does not match the one gotten (got %q, %v)", out, err)
+
+	}
+
+	if err := buf.WriteBCD(0x00, 1, 100); err != BCDOverflowError {
+
+		t.Fatalf("expected an overflow error (got %v)", err)
+
+	}
+
+	if err := buf.WriteBCDString(0x00, 2, "987"); err != nil || !cmp.Equal([]byte{0x09, 0x87}, buf.Bytes()[:2]) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v, %v)", buf.Bytes(), err)
+
+	}
+
+	if err := buf.WriteBCDString(0x00, 2, "9x"); err != BCDInvalidDigitError {
+
+		t.Fatalf("expected an invalid digit error (got %v)", err)
+
+	}
+
+	buf.WriteByte(0x01, 0x8A)
+	if _, err := buf.ReadBCD(0x00, 2); err != BCDInvalidDigitError {
+
+		t.Fatalf("expected an invalid digit error (got %v)", err)
+
+	}
+
+	buf = NewBuffer([]byte{0x18, 0x44, 0x67, 0x44, 0x07, 0x37, 0x09, 0x55, 0x16, 0x16})
+	if _, err := buf.ReadBCD(0x00, 10); err != BCDOverflowError {
+
+		t.Fatalf("expected an overflow error (got %v)", err)
+
+	}
+
+}
+
+func TestBufferSwappedBCD(t *testing.T) {
+
+	// +1 555 0123 4 stored as a gsm address
+	buf := NewBuffer([]byte{0x51, 0x55, 0x10, 0x32, 0xF4, 0xFF})
+
+	if out, err := buf.ReadSwappedBCDString(0x00, 6); err != nil || out != "155501234" {
+
+		t.Fatalf("expected string does not match the one gotten (got %q, %v)", out, err)
+
+	}
+
+	out := NewBuffer(make([]byte, 6))
+	if err := out.WriteSwappedBCDString(0x00, 6, "155501234"); err != nil || !cmp.Equal(buf.Bytes(), out.Bytes()) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v, %v)", out.Bytes(), err)
+
+	}
+
+	if err := out.WriteSwappedBCDString(0x00, 2, "*#1"); err != nil || !cmp.Equal([]byte{0xBA, 0xF1}, out.Bytes()[:2]) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v, %v)", out.Bytes(), err)
+
+	}
+
+	buf.WriteByte(0x05, 0x1F)
+	if _, err := buf.ReadSwappedBCDString(0x00, 6); err != BCDInvalidDigitError {
+
+		t.Fatalf("expected an invalid digit error (got %v)", err)
+
+	}
+
+}
+
+func TestBufferPackedDecimal(t *testing.T) {
+
+	buf := NewBuffer(make([]byte, 4))
+
+	for v, expected := range map[int64][]byte{
+		12345:   {0x00, 0x12, 0x34, 0x5C},
+		-123:    {0x00, 0x00, 0x12, 0x3D},
+		0:       {0x00, 0x00, 0x00, 0x0C},
+		9999999: {0x99, 0x99, 0x99, 0x9C},
+	} {
+
+		if err := buf.WritePackedDecimal(0x00, 4, v); err != nil || !cmp.Equal(expected, buf.Bytes()) {
+
+			t.Fatalf("expected byte slice does not match the one gotten for %d (got %#v, %v)", v, buf.Bytes(), err)
+
+		}
+
+		if out, err := buf.ReadPackedDecimal(0x00, 4); err != nil || out != v {
+
+			t.Fatalf("expected int64 does not match the one gotten (got %d, %v, expected %d)", out, err, v)
+
+		}
+
+	}
+
+	if err := buf.WritePackedDecimal(0x00, 4, 10000000); err != BCDOverflowError {
+
+		t.Fatalf("expected an overflow error (got %v)", err)
+
+	}
+
+	buf = NewBuffer(make([]byte, 10))
+	if err := buf.WritePackedDecimalNext(10, math.MinInt64+1); err != nil || buf.ByteOffset() != 10 {
+
+		t.Fatalf("unexpected error writing a packed decimal (%v)", err)
+
+	}
+
+	if out, err := buf.ReadPackedDecimal(0x00, 10); err != nil || out != math.MinInt64+1 {
+
+		t.Fatalf("expected int64 does not match the one gotten (got %d, %v)", out, err)
+
+	}
+
+	buf = NewBuffer([]byte{0x12, 0x34})
+	if _, err := buf.ReadPackedDecimal(0x00, 2); err != BCDInvalidDigitError {
+
+		t.Fatalf("expected an invalid sign error (got %v)", err)
+
+	}
+
+	buf = NewBuffer([]byte{0x1A, 0x3F})
+	if _, err := buf.ReadPackedDecimal(0x00, 2); err != BCDInvalidDigitError {
+
+		t.Fatalf("expected an invalid digit error (got %v)", err)
+
+	}
+
+}
+
+func TestCursorBCD(t *testing.T) {
+
+	buf := NewBuffer(make([]byte, 5))
+
+	c := buf.Cursor(0x00)
+	if err := c.WriteBCDNext(2, 1234); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if err := c.WritePackedDecimalNext(3, -123); err != nil || c.ByteOffset() != 0x05 {
+
+		t.Fatalf("unexpected error writing a packed decimal (%v at %d)", err, c.ByteOffset())
+
+	}
+
+	if !cmp.Equal([]byte{0x12, 0x34, 0x00, 0x12, 0x3D}, buf.Bytes()) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v)", buf.Bytes())
+
+	}
+
+	c.SeekByte(0x00, false)
+	if out, err := c.ReadBCDNext(2); err != nil || out != 1234 {
+
+		t.Fatalf("expected uint64 does not match the one gotten (got %d, %v, expected %d)", out, err, 1234)
+
+	}
+
+	if out, err := c.ReadPackedDecimalNext(3); err != nil || out != -123 || c.ByteOffset() != 0x05 {
+
+		t.Fatalf("expected int64 does not match the one gotten (got %d, %v, expected %d)", out, err, -123)
+
+	}
+
+	// the cursor stays put when the data is invalid
+	c.SeekByte(0x03, false)
+	if _, err := c.ReadBCDNext(2); err != BCDInvalidDigitError || c.ByteOffset() != 0x03 {
+
+		t.Fatalf("expected an invalid digit error (got %v at %d)", err, c.ByteOffset())
+
+	}
+
+}
+
+func TestReaderBufferBCD(t *testing.T) {
+
+	buf := NewReaderBuffer(iotest.OneByteReader(bytes.NewReader([]byte{0x12, 0x34, 0x00, 0x12, 0x3D})))
+
+	if out, err := buf.ReadBCDNext(2); err != nil || out != 1234 {
+
+		t.Fatalf("expected uint64 does not match the one gotten (got %d, %v, expected %d)", out, err, 1234)
+
+	}
+
+	if out, err := buf.ReadPackedDecimalNext(3); err != nil || out != -123 {
+
+		t.Fatalf("expected int64 does not match the one gotten (got %d, %v, expected %d)", out, err, -123)
+
+	}
+
+}
+
+func TestBufferBCDEmptyFields(t *testing.T) {
+
+	buf := NewBuffer([]byte{0x12})
+
+	if err := buf.WriteBCD(0x00, 0, 0); err != nil {
+
+		t.Fatalf("unexpected error writing an empty field (%v)", err)
+
+	}
+
+	if err := buf.WriteBCD(0x00, 0, 1); err != BCDOverflowError {
+
+		t.Fatalf("expected an overflow error (got %v)", err)
+
+	}
+
+	if err := buf.WriteBCDString(0x00, 0, ""); err != nil {
+
+		t.Fatalf("unexpected error writing an empty field (%v)", err)
+
+	}
+
+	if err := buf.WriteSwappedBCDString(0x00, 0, ""); err != nil {
+
+		t.Fatalf("unexpected error writing an empty field (%v)", err)
+
+	}
+
+	if out, err := buf.ReadBCD(0x00, 0); err != nil || out != 0 || buf.ReadByte(0x00) != 0x12 {
+
+		t.Fatalf("expected empty fields to leave the buffer untouched (got %d, %v, %#v)", out, err, buf.Bytes())
+
+	}
+
+	for _, access := range []func(){
+		func() { _ = buf.WriteBCD(0x00, -1, 0) },
+		func() { _ = buf.WriteSwappedBCDString(0x00, -1, "") },
+		func() { _, _ = buf.ReadBCDString(0x00, -1) },
+		func() { _ = buf.WritePackedDecimal(0x00, -1, 0) },
+	} {
+
+		func() {
+
+			defer panicChecker(t, BufferInvalidByteCountError)
+			access()
+
+		}()
+
+	}
+
+}
+
+func TestBufferBCDOutOfBounds(t *testing.T) {
+
+	buf := NewBuffer(make([]byte, 4))
+
+	// sizes far beyond the buffer must be rejected before anything
+	// is allocated for them
+	for _, access := range []func(){
+		func() { _ = buf.WriteBCD(0x00, 1<<50, 0) },
+		func() { _ = buf.WriteBCDString(0x00, 1<<50, "") },
+		func() { _ = buf.WriteSwappedBCDString(0x00, 1<<50, "") },
+		func() { _ = buf.WritePackedDecimal(0x00, 1<<50, 0) },
+		func() { _ = buf.WriteBCD(0x03, 2, 0) },
+		func() { _ = buf.WritePackedDecimal(math.MaxInt64, 1, 0) },
+	} {
+
+		func() {
+
+			defer panicChecker(t, BufferOverwriteError)
+			access()
+
+		}()
+
+	}
+
+	defer panicChecker(t, BufferUnderwriteError)
+	_ = buf.WriteBCD(-0x01, 1, 0)
+
+}
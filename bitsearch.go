@@ -0,0 +1,138 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import "math/bits"
+
+/* internal use methods */
+
+// scanBits calls fn with the bit offset of every occurrence of the low
+// n bits of pattern at or after the bit offset off, in the same most
+// significant bit first order ReadBits uses, that differs from it in
+// at most tolerance bits. scanning stops when fn returns false
+func (b *Buffer) scanBits(off int64, pattern uint64, n int64, tolerance int, fn func(off int64) bool) {
+
+	if n < 1 || n > 64 || tolerance < 0 {
+
+		panic(BufferInvalidBitCountError)
+
+	}
+
+	if off > b.bcap {
+
+		panic(BufferOverreadError.atBit(off, n, b.bcap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderreadError.atBit(off, n, b.bcap))
+
+	}
+
+	mask := ^uint64(0) >> uint64(64-n)
+	pattern &= mask
+
+	// window holds the bits preceding the current byte, and the
+	// candidate ending at bit k of it is formed by shifting in the
+	// first k+1 bits of the byte
+	var (
+		window uint64
+		first  = off + n - 1
+	)
+
+	for i := off / 8; i < b.cap; i++ {
+
+		c := uint64(b.buf[i])
+		k := int64(0)
+		if start := first - i*8; start > 0 {
+
+			if start > 7 {
+
+				window = (window << 8) | c
+				continue
+
+			}
+			k = start
+
+		}
+
+		for ; k < 8; k++ {
+
+			w := (window << uint64(k+1)) | (c >> uint64(7-k))
+			if bits.OnesCount64((w^pattern)&mask) <= tolerance {
+
+				if !fn(i*8 + k + 1 - n) {
+
+					return
+
+				}
+
+			}
+
+		}
+		window = (window << 8) | c
+
+	}
+
+}
+
+/* bit search methods */
+
+// IndexBits returns the bit offset of the first occurrence of the low
+// n bits of pattern at or after the bit offset off that differs from
+// it in at most tolerance bits, or -1 if there is none. n must be
+// between 1 and 64
+func (b *Buffer) IndexBits(off int64, pattern uint64, n int64, tolerance int) (out int64) {
+
+	out = -1
+	b.scanBits(off, pattern, n, tolerance, func(off int64) bool {
+
+		out = off
+		return false
+
+	})
+	return
+
+}
+
+// IndexBitsNext returns the bit offset of the first occurrence of the
+// low n bits of pattern at or after the current bit offset that
+// differs from it in at most tolerance bits and moves the bit offset
+// past it, so that calling it again finds the next non-overlapping
+// occurrence. if there is none, -1 is returned and the bit offset is
+// left untouched
+func (b *Buffer) IndexBitsNext(pattern uint64, n int64, tolerance int) (off int64) {
+
+	if off = b.IndexBits(b.boff, pattern, n, tolerance); off >= 0 {
+
+		b.SeekBit(off+n, false)
+
+	}
+	return
+
+}
+
+// IndexAllBits returns the bit offsets of every occurrence of the low
+// n bits of pattern at or after the bit offset off that differs from
+// it in at most tolerance bits, including overlapping ones
+func (b *Buffer) IndexAllBits(off int64, pattern uint64, n int64, tolerance int) (out []int64) {
+
+	b.scanBits(off, pattern, n, tolerance, func(off int64) bool {
+
+		out = append(out, off)
+		return true
+
+	})
+	return
+
+}
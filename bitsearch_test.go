@@ -0,0 +1,140 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+tests
+
+*/
+
+func TestBufferIndexBits(t *testing.T) {
+
+	// the 16-bit sync word 0x1acf at bit offsets 3 and 29, the
+	// second copy having one flipped bit
+	buf := NewBuffer(make([]byte, 8))
+	buf.SetBits(3, 0x1ACF, 16)
+	buf.SetBits(29, 0x1ACF^0x0100, 16)
+
+	if off := buf.IndexBits(0, 0x1ACF, 16, 0); off != 3 {
+
+		t.Fatalf("expected bit offset does not match the one gotten (got %d, expected %d)", off, 3)
+
+	}
+
+	if off := buf.IndexBits(4, 0x1ACF, 16, 0); off != -1 {
+
+		t.Fatalf("expected no exact match (got %d)", off)
+
+	}
+
+	if off := buf.IndexBits(4, 0x1ACF, 16, 1); off != 29 {
+
+		t.Fatalf("expected bit offset does not match the one gotten (got %d, expected %d)", off, 29)
+
+	}
+
+	if out := buf.IndexAllBits(0, 0x1ACF, 16, 1); !cmp.Equal([]int64{3, 29}, out) {
+
+		t.Fatalf("expected bit offsets do not match the ones gotten (got %#v, expected %#v)", out, []int64{3, 29})
+
+	}
+
+	buf.SeekBit(4, false)
+	if off := buf.IndexBitsNext(0x1ACF, 16, 1); off != 29 || buf.BitOffset() != 45 {
+
+		t.Fatalf("expected the bit offset to be moved past the match (got %d at %d)", off, buf.BitOffset())
+
+	}
+
+	if off := buf.IndexBitsNext(0x1ACF, 16, 1); off != -1 || buf.BitOffset() != 45 {
+
+		t.Fatalf("expected the bit offset to be left alone (got %d at %d)", off, buf.BitOffset())
+
+	}
+
+}
+
+func TestBufferIndexBitsRandom(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+
+	data := make([]byte, 256)
+	r.Read(data)
+	buf := NewBuffer(data)
+
+	for _, n := range []int64{1, 7, 13, 32, 64} {
+
+		pattern := r.Uint64() >> uint64(64-n)
+		for _, tolerance := range []int{0, 2} {
+
+			var expected []int64
+			for off := int64(5); off+n <= buf.BitCapacity(); off++ {
+
+				if bits.OnesCount64(buf.ReadBits(off, n)^pattern) <= tolerance {
+
+					expected = append(expected, off)
+
+				}
+
+			}
+
+			if out := buf.IndexAllBits(5, pattern, n, tolerance); !cmp.Equal(expected, out) {
+
+				t.Fatalf("expected bit offsets for %d bits with a tolerance of %d do not match the ones gotten (got %d, expected %d)", n, tolerance, len(out), len(expected))
+
+			}
+
+		}
+
+	}
+
+}
+
+func TestBufferIndexBitsPanic(t *testing.T) {
+
+	defer panicChecker(t, BufferInvalidBitCountError)
+
+	buf := NewBuffer(make([]byte, 8))
+
+	_ = buf.IndexBits(0, 0x00, 65, 0)
+
+}
+
+/*
+
+benchmarks
+
+*/
+
+func BenchmarkBufferIndexBits(b *testing.B) {
+
+	data := make([]byte, 1<<16)
+	rand.New(rand.NewSource(1)).Read(data)
+	buf := NewBuffer(data)
+
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+
+		_ = buf.IndexAllBits(0, 0x1ACFFC1D, 32, 2)
+
+	}
+
+}
@@ -0,0 +1,302 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import "io"
+
+// defaultBitWriterSize is the size of a BitWriter's internal buffer
+// when none is provided
+const defaultBitWriterSize = 4096
+
+// BitWriter implements a buffered bitstream writer that flushes whole
+// bytes to an io.Writer. write errors are sticky: once one occurs,
+// further writes are discarded and the error is returned by Flush
+// and Err
+type BitWriter struct {
+	w   io.Writer
+	buf *Buffer
+	pad byte
+	err error
+	n   int64
+}
+
+// NewBitWriter initializes a new BitWriter that writes to w, holding
+// up to size bytes before flushing them
+func NewBitWriter(w io.Writer, size int64) *BitWriter {
+
+	if size <= 0 {
+
+		size = defaultBitWriterSize
+
+	}
+
+	return &BitWriter{
+		w:   w,
+		buf: NewBuffer(make([]byte, size)),
+	}
+
+}
+
+/* internal use methods */
+
+// flushBytes writes the whole bytes held by the writer and moves the
+// trailing partial byte to the start of the buffer
+func (w *BitWriter) flushBytes() {
+
+	n := w.buf.boff / 8
+	if n == 0 {
+
+		return
+
+	}
+
+	if w.err == nil {
+
+		_, w.err = w.w.Write(w.buf.buf[:n])
+
+	}
+
+	w.buf.buf[0] = w.buf.buf[n%w.buf.cap]
+	w.buf.SeekBit(w.buf.boff%8, false)
+
+}
+
+// writeBytes writes data as whole bytes at the current bit offset
+func (w *BitWriter) writeBytes(data []byte) {
+
+	if w.buf.boff%8 != 0 {
+
+		for _, c := range data {
+
+			w.SetBitsNext(uint64(c), 8)
+
+		}
+		return
+
+	}
+
+	for len(data) > 0 {
+
+		if w.buf.boff == w.buf.bcap {
+
+			w.flushBytes()
+
+		}
+
+		var (
+			off = w.buf.boff / 8
+			n   = int64(copy(w.buf.buf[off:], data))
+		)
+		w.buf.SeekBit(n*8, true)
+		w.n += n * 8
+		data = data[n:]
+
+	}
+
+}
+
+/* bitfield methods */
+
+// SetPadding sets the value of the bits used to pad partial bytes
+func (w *BitWriter) SetPadding(bit byte) {
+
+	w.pad = bit & 1
+
+}
+
+// SetBitNext writes a 1 bit
+func (w *BitWriter) SetBitNext() {
+
+	w.SetBitsNext(1, 1)
+
+}
+
+// ClearBitNext writes a 0 bit
+func (w *BitWriter) ClearBitNext() {
+
+	w.SetBitsNext(0, 1)
+
+}
+
+// SetBitsNext writes the low n bits of data, most significant bit
+// first
+func (w *BitWriter) SetBitsNext(data uint64, n int64) {
+
+	if n < 0 || n > 64 {
+
+		panic(BufferInvalidBitCountError)
+
+	}
+
+	for n > 0 {
+
+		if w.buf.boff == w.buf.bcap {
+
+			w.flushBytes()
+
+		}
+
+		k := w.buf.bcap - w.buf.boff
+		if k > n {
+
+			k = n
+
+		}
+
+		w.buf.SetBitsNext(data>>uint64(n-k), k)
+		w.n += k
+		n -= k
+
+	}
+
+}
+
+// AlignBit pads the current byte with the padding bit so that the
+// next write starts on a byte boundary
+func (w *BitWriter) AlignBit() {
+
+	if r := w.buf.boff % 8; r != 0 {
+
+		w.SetBitsNext(uint64(0xFF*int(w.pad)), 8-r)
+
+	}
+
+}
+
+/* byte buffer methods */
+
+// WriteBytesNext writes bytes at the current bit offset
+func (w *BitWriter) WriteBytesNext(data []byte) {
+
+	w.writeBytes(data)
+
+}
+
+// WriteByteNext writes a byte at the current bit offset
+func (w *BitWriter) WriteByteNext(data byte) {
+
+	w.SetBitsNext(uint64(data), 8)
+
+}
+
+// WriteU16LENext writes a slice of uint16s at the current bit offset
+// in little-endian
+func (w *BitWriter) WriteU16LENext(data []uint16) {
+
+	for _, d := range data {
+
+		w.writeBytes([]byte{byte(d), byte(d >> 8)})
+
+	}
+
+}
+
+// WriteU16BENext writes a slice of uint16s at the current bit offset
+// in big-endian
+func (w *BitWriter) WriteU16BENext(data []uint16) {
+
+	for _, d := range data {
+
+		w.writeBytes([]byte{byte(d >> 8), byte(d)})
+
+	}
+
+}
+
+// WriteU32LENext writes a slice of uint32s at the current bit offset
+// in little-endian
+func (w *BitWriter) WriteU32LENext(data []uint32) {
+
+	for _, d := range data {
+
+		w.writeBytes([]byte{byte(d), byte(d >> 8), byte(d >> 16), byte(d >> 24)})
+
+	}
+
+}
+
+// WriteU32BENext writes a slice of uint32s at the current bit offset
+// in big-endian
+func (w *BitWriter) WriteU32BENext(data []uint32) {
+
+	for _, d := range data {
+
+		w.writeBytes([]byte{byte(d >> 24), byte(d >> 16), byte(d >> 8), byte(d)})
+
+	}
+
+}
+
+// WriteU64LENext writes a slice of uint64s at the current bit offset
+// in little-endian
+func (w *BitWriter) WriteU64LENext(data []uint64) {
+
+	for _, d := range data {
+
+		w.writeBytes([]byte{
+			byte(d), byte(d >> 8), byte(d >> 16), byte(d >> 24),
+			byte(d >> 32), byte(d >> 40), byte(d >> 48), byte(d >> 56),
+		})
+
+	}
+
+}
+
+// WriteU64BENext writes a slice of uint64s at the current bit offset
+// in big-endian
+func (w *BitWriter) WriteU64BENext(data []uint64) {
+
+	for _, d := range data {
+
+		w.writeBytes([]byte{
+			byte(d >> 56), byte(d >> 48), byte(d >> 40), byte(d >> 32),
+			byte(d >> 24), byte(d >> 16), byte(d >> 8), byte(d),
+		})
+
+	}
+
+}
+
+/* generic methods */
+
+// Flush pads the last partial byte with the padding bit and writes
+// everything held by the writer to the underlying io.Writer
+func (w *BitWriter) Flush() error {
+
+	w.AlignBit()
+	w.flushBytes()
+	return w.err
+
+}
+
+// Err returns the first error returned by the underlying io.Writer
+func (w *BitWriter) Err() error {
+
+	return w.err
+
+}
+
+// BitsWritten returns the amount of bits written so far, including
+// padding
+func (w *BitWriter) BitsWritten() int64 {
+
+	return w.n
+
+}
+
+// Buffered returns the amount of bits held by the writer that have
+// not been flushed yet
+func (w *BitWriter) Buffered() int64 {
+
+	return w.buf.boff
+
+}
@@ -0,0 +1,127 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+utilities
+
+*/
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+
+	return 0, errors.New("write failed")
+
+}
+
+/*
+
+tests
+
+*/
+
+func TestBitWriter(t *testing.T) {
+
+	expected := []byte{0xB1, 0x23, 0x40, 0x10, 0x20, 0xDE, 0xAD, 0xBE, 0xEF, 0x80}
+
+	out := &bytes.Buffer{}
+	w := NewBitWriter(out, 2)
+
+	w.SetBitNext()
+	w.ClearBitNext()
+	w.SetBitsNext(0x3, 2)
+	w.SetBitsNext(0x1234, 16)
+	w.WriteU16LENext([]uint16{0x0201})
+	w.AlignBit()
+	w.WriteU32BENext([]uint32{0xDEADBEEF})
+	w.SetBitNext()
+
+	if w.BitsWritten() != 73 {
+
+		t.Fatalf("expected int64 does not match the one gotten (got %d, expected %d)", w.BitsWritten(), 73)
+
+	}
+
+	if err := w.Flush(); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if !cmp.Equal(expected, out.Bytes()) {
+
+		t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", out.Bytes(), expected)
+
+	}
+
+}
+
+func TestBitWriterPadding(t *testing.T) {
+
+	expected := []byte{0xBF, 0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+
+	out := &bytes.Buffer{}
+	w := NewBitWriter(out, 0)
+	w.SetPadding(1)
+
+	w.SetBitsNext(0x2, 2)
+	w.AlignBit()
+	w.ClearBitNext()
+	w.WriteU64BENext([]uint64{0xFFFFFFFFFFFFFFFF})
+
+	if err := w.Flush(); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if !cmp.Equal(expected, out.Bytes()) {
+
+		t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", out.Bytes(), expected)
+
+	}
+
+}
+
+func TestBitWriterError(t *testing.T) {
+
+	w := NewBitWriter(failingWriter{}, 1)
+
+	w.WriteBytesNext([]byte{0x01, 0x02, 0x03})
+
+	if w.Err() == nil || w.Flush() == nil {
+
+		t.Fatalf("expected the write error to be reported")
+
+	}
+
+}
+
+func TestBitWriterSetBitsNextPanic(t *testing.T) {
+
+	defer panicChecker(t, BufferInvalidBitCountError)
+
+	w := NewBitWriter(&bytes.Buffer{}, 0)
+
+	w.SetBitsNext(0, 65)
+
+}
@@ -24,6 +24,17 @@ type Buffer struct {
 
 	// temp?
 	obuf unsafe.Pointer
+
+	// grow replaces the default growth strategy for buffers whose
+	// storage is not managed by the go runtime
+	grow func(n int64)
+
+	// pool is the pool the buffer was taken from, if any
+	pool *BufferPool
+
+	// tracer records the fields read or written through the *Next
+	// methods if tracing is enabled
+	tracer *Tracer
 }
 
 // NewBuffer initilaizes a new Buffer with the provided byte slice(s)
@@ -69,6 +80,22 @@ func NewBuffer(slices ...[]byte) (buf *Buffer) {
 
 /* internal use methods */
 
+// appendBytes grows the buffer and writes data to the end of it
+// without modifying the internal offset values
+func (b *Buffer) appendBytes(data []byte) {
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	off := b.cap
+	b.Grow(int64(len(data)))
+	b.WriteBytes(off, data)
+
+}
+
 /* bitfield methods */
 
 // ReadBit returns the bit located at the specified offset without
@@ -77,13 +104,13 @@ func (b *Buffer) ReadBit(off int64) byte {
 
 	if off > (b.bcap - 1) {
 
-		panic(BufferOverreadError)
+		panic(BufferOverreadError.atBit(off, 1, b.bcap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderreadError)
+		panic(BufferUnderreadError.atBit(off, 1, b.bcap))
 
 	}
 
@@ -96,15 +123,40 @@ func (b *Buffer) ReadBit(off int64) byte {
 func (b *Buffer) ReadBitNext() (out byte) {
 
 	out = b.ReadBit(b.boff)
+	b.traced(b.boff, 1)
 	b.SeekBit(1, true)
 	return
 
 }
 
 // ReadBits returns the next n bits from the specified offset without
-// modifying the internal offset value
+// modifying the internal offset value. n must be between 0 and 64
 func (b *Buffer) ReadBits(off, n int64) (out uint64) {
 
+	if n < 0 || n > 64 {
+
+		panic(BufferInvalidBitCountError)
+
+	}
+
+	if (off + n) > b.bcap {
+
+		panic(BufferOverreadError.atBit(off, n, b.bcap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderreadError.atBit(off, n, b.bcap))
+
+	}
+
+	if n == 0 {
+
+		return
+
+	}
+
 	i := int64(0)
 
 	{
@@ -127,6 +179,103 @@ func (b *Buffer) ReadBits(off, n int64) (out uint64) {
 func (b *Buffer) ReadBitsNext(n int64) (out uint64) {
 
 	out = b.ReadBits(b.boff, n)
+	b.traced(b.boff, n)
+	b.SeekBit(n, true)
+	return
+
+}
+
+// ReadBitsSigned returns the next n bits from the specified offset as
+// a two's complement signed integer without modifying the internal
+// offset value. n must be between 0 and 64
+func (b *Buffer) ReadBitsSigned(off, n int64) int64 {
+
+	out := b.ReadBits(off, n)
+	if n == 0 || n == 64 {
+
+		return int64(out)
+
+	}
+
+	shift := uint64(64 - n)
+	return int64(out<<shift) >> shift
+
+}
+
+// ReadBitsSignedNext returns the next n bits from the current offset
+// as a two's complement signed integer and moves the offset forward
+// the amount of bits read
+func (b *Buffer) ReadBitsSignedNext(n int64) (out int64) {
+
+	out = b.ReadBitsSigned(b.boff, n)
+	b.traced(b.boff, n)
+	b.SeekBit(n, true)
+	return
+
+}
+
+// ReadBitsBytes returns the next n bits from the specified offset,
+// which may be any amount, packed into bytes starting from the most
+// significant bit of the first one. the unused low bits of the last
+// byte are zero
+func (b *Buffer) ReadBitsBytes(off, n int64) (out []byte) {
+
+	if n < 0 {
+
+		panic(BufferInvalidBitCountError)
+
+	}
+
+	if (off + n) > b.bcap {
+
+		panic(BufferOverreadError.atBit(off, n, b.bcap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderreadError.atBit(off, n, b.bcap))
+
+	}
+
+	out = make([]byte, (n+7)/8)
+	if off%8 == 0 {
+
+		copy(out, b.buf[off/8:])
+
+	} else {
+
+		shift := uint64(off % 8)
+		for i := range out {
+
+			j := off/8 + int64(i)
+			out[i] = b.buf[j] << shift
+			if j+1 < b.cap {
+
+				out[i] |= b.buf[j+1] >> (8 - shift)
+
+			}
+
+		}
+
+	}
+
+	if n%8 != 0 {
+
+		out[len(out)-1] &= 0xFF << uint64(8-n%8)
+
+	}
+	return
+
+}
+
+// ReadBitsBytesNext returns the next n bits from the current offset
+// packed into bytes and moves the offset forward the amount of bits
+// read
+func (b *Buffer) ReadBitsBytesNext(n int64) (out []byte) {
+
+	out = b.ReadBitsBytes(b.boff, n)
+	b.traced(b.boff, n)
 	b.SeekBit(n, true)
 	return
 
@@ -138,13 +287,13 @@ func (b *Buffer) SetBit(off int64) {
 
 	if off > (b.bcap - 1) {
 
-		panic(BufferOverwriteError)
+		panic(BufferOverwriteError.atBit(off, 1, b.bcap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderwriteError)
+		panic(BufferUnderwriteError.atBit(off, 1, b.bcap))
 
 	}
 
@@ -157,6 +306,7 @@ func (b *Buffer) SetBit(off int64) {
 func (b *Buffer) SetBitNext() {
 
 	b.SetBit(b.boff)
+	b.traced(b.boff, 1)
 	b.SeekBit(1, true)
 
 }
@@ -167,13 +317,13 @@ func (b *Buffer) ClearBit(off int64) {
 
 	if off > (b.bcap - 1) {
 
-		panic(BufferOverwriteError)
+		panic(BufferOverwriteError.atBit(off, 1, b.bcap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderwriteError)
+		panic(BufferUnderwriteError.atBit(off, 1, b.bcap))
 
 	}
 
@@ -186,14 +336,39 @@ func (b *Buffer) ClearBit(off int64) {
 func (b *Buffer) ClearBitNext() {
 
 	b.ClearBit(b.boff)
+	b.traced(b.boff, 1)
 	b.SeekBit(1, true)
 
 }
 
 // SetBits sets the next n bits from the specified offset without
-// modifying the internal offset value
+// modifying the internal offset value. n must be between 0 and 64
 func (b *Buffer) SetBits(off int64, data uint64, n int64) {
 
+	if n < 0 || n > 64 {
+
+		panic(BufferInvalidBitCountError)
+
+	}
+
+	if (off + n) > b.bcap {
+
+		panic(BufferOverwriteError.atBit(off, n, b.bcap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderwriteError.atBit(off, n, b.bcap))
+
+	}
+
+	if n == 0 {
+
+		return
+
+	}
+
 	i := int64(0)
 
 	{
@@ -222,6 +397,64 @@ func (b *Buffer) SetBits(off int64, data uint64, n int64) {
 func (b *Buffer) SetBitsNext(data uint64, n int64) {
 
 	b.SetBits(b.boff, data, n)
+	b.traced(b.boff, n)
+	b.SeekBit(n, true)
+
+}
+
+// SetBitsBytes sets the next n bits from the specified offset, which
+// may be any amount, to the first n bits of data starting from the
+// most significant bit of its first byte, without modifying the
+// internal offset value
+func (b *Buffer) SetBitsBytes(off int64, data []byte, n int64) {
+
+	if n < 0 || n > int64(len(data))*8 {
+
+		panic(BufferInvalidBitCountError)
+
+	}
+
+	if (off + n) > b.bcap {
+
+		panic(BufferOverwriteError.atBit(off, n, b.bcap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderwriteError.atBit(off, n, b.bcap))
+
+	}
+
+	if off%8 == 0 {
+
+		copy(b.buf[off/8:], data[:n/8])
+
+	} else {
+
+		for i, c := range data[:n/8] {
+
+			b.packedSet(0, off+int64(i)*8, 8, uint64(c), PackedMSB)
+
+		}
+
+	}
+
+	if n%8 != 0 {
+
+		b.packedSet(0, off+n/8*8, n%8, uint64(data[n/8]>>uint64(8-n%8)), PackedMSB)
+
+	}
+
+}
+
+// SetBitsBytesNext sets the next n bits from the current offset to
+// the first n bits of data and moves the offset forward the amount of
+// bits set
+func (b *Buffer) SetBitsBytesNext(data []byte, n int64) {
+
+	b.SetBitsBytes(b.boff, data, n)
+	b.traced(b.boff, n)
 	b.SeekBit(n, true)
 
 }
@@ -232,13 +465,13 @@ func (b *Buffer) FlipBit(off int64) {
 
 	if off > (b.bcap - 1) {
 
-		panic(BufferOverwriteError)
+		panic(BufferOverwriteError.atBit(off, 1, b.bcap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderwriteError)
+		panic(BufferUnderwriteError.atBit(off, 1, b.bcap))
 
 	}
 
@@ -251,6 +484,7 @@ func (b *Buffer) FlipBit(off int64) {
 func (b *Buffer) FlipBitNext() {
 
 	b.FlipBit(b.boff)
+	b.traced(b.boff, 1)
 	b.SeekBit(1, true)
 
 }
@@ -360,13 +594,13 @@ func (b *Buffer) WriteBytes(off int64, data []byte) {
 
 	if (off + int64(len(data))) > b.cap {
 
-		panic(BufferOverwriteError)
+		panic(BufferOverwriteError.at(off, int64(len(data)), b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderwriteError)
+		panic(BufferUnderwriteError.at(off, int64(len(data)), b.cap))
 
 	}
 
@@ -412,6 +646,7 @@ func (b *Buffer) WriteBytes(off int64, data []byte) {
 func (b *Buffer) WriteBytesNext(data []byte) {
 
 	b.WriteBytes(b.off, data)
+	b.traced(b.off*8, int64(len(data))*8)
 	b.SeekByte(int64(len(data)), true)
 
 }
@@ -429,6 +664,7 @@ func (b *Buffer) WriteByte(off int64, data byte) {
 func (b *Buffer) WriteByteNext(data byte) {
 
 	b.WriteBytes(b.off, []byte{data})
+	b.traced(b.off*8, 8)
 	b.SeekByte(1, true)
 
 }
@@ -440,32 +676,17 @@ func (b *Buffer) WriteU16LE(off int64, data []uint16) {
 
 	if (off + int64(len(data))*2) > b.cap {
 
-		panic(BufferOverwriteError)
+		panic(BufferOverwriteError.at(off, int64(len(data))*2, b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderwriteError)
+		panic(BufferUnderwriteError.at(off, int64(len(data))*2, b.cap))
 
 	}
 
-	var (
-		i = 0
-		n = len(data)
-	)
-	{
-	write_loop:
-		b.buf[off+int64(i*2)] = byte(data[i])
-		b.buf[off+int64(1+(i*2))] = byte(data[i] >> 8)
-
-		i++
-		if i < n {
-
-			goto write_loop
-
-		}
-	}
+	bulkWriteU16(b.buf[off:off+int64(len(data))*2], data, false)
 
 }
 
@@ -475,6 +696,7 @@ func (b *Buffer) WriteU16LE(off int64, data []uint16) {
 func (b *Buffer) WriteU16LENext(data []uint16) {
 
 	b.WriteU16LE(b.off, data)
+	b.traced(b.off*8, int64(len(data))*16)
 	b.SeekByte(int64(len(data))*2, true)
 
 }
@@ -486,32 +708,17 @@ func (b *Buffer) WriteU16BE(off int64, data []uint16) {
 
 	if (off + int64(len(data))*2) > b.cap {
 
-		panic(BufferOverwriteError)
+		panic(BufferOverwriteError.at(off, int64(len(data))*2, b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderwriteError)
+		panic(BufferUnderwriteError.at(off, int64(len(data))*2, b.cap))
 
 	}
 
-	var (
-		i = 0
-		n = len(data)
-	)
-	{
-	write_loop:
-		b.buf[off+int64(i*2)] = byte(data[i] >> 8)
-		b.buf[off+int64(1+(i*2))] = byte(data[i])
-
-		i++
-		if i < n {
-
-			goto write_loop
-
-		}
-	}
+	bulkWriteU16(b.buf[off:off+int64(len(data))*2], data, true)
 
 }
 
@@ -521,6 +728,7 @@ func (b *Buffer) WriteU16BE(off int64, data []uint16) {
 func (b *Buffer) WriteU16BENext(data []uint16) {
 
 	b.WriteU16BE(b.off, data)
+	b.traced(b.off*8, int64(len(data))*16)
 	b.SeekByte(int64(len(data))*2, true)
 
 }
@@ -532,34 +740,17 @@ func (b *Buffer) WriteU32LE(off int64, data []uint32) {
 
 	if (off + int64(len(data))*4) > b.cap {
 
-		panic(BufferOverwriteError)
+		panic(BufferOverwriteError.at(off, int64(len(data))*4, b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderwriteError)
+		panic(BufferUnderwriteError.at(off, int64(len(data))*4, b.cap))
 
 	}
 
-	var (
-		i = 0
-		n = len(data)
-	)
-	{
-	write_loop:
-		b.buf[off+int64(i*4)] = byte(data[i])
-		b.buf[off+int64(1+(i*4))] = byte(data[i] >> 8)
-		b.buf[off+int64(2+(i*4))] = byte(data[i] >> 16)
-		b.buf[off+int64(3+(i*4))] = byte(data[i] >> 24)
-
-		i++
-		if i < n {
-
-			goto write_loop
-
-		}
-	}
+	bulkWriteU32(b.buf[off:off+int64(len(data))*4], data, false)
 
 }
 
@@ -569,6 +760,7 @@ func (b *Buffer) WriteU32LE(off int64, data []uint32) {
 func (b *Buffer) WriteU32LENext(data []uint32) {
 
 	b.WriteU32LE(b.off, data)
+	b.traced(b.off*8, int64(len(data))*32)
 	b.SeekByte(int64(len(data))*4, true)
 
 }
@@ -580,34 +772,17 @@ func (b *Buffer) WriteU32BE(off int64, data []uint32) {
 
 	if (off + int64(len(data))*4) > b.cap {
 
-		panic(BufferOverwriteError)
+		panic(BufferOverwriteError.at(off, int64(len(data))*4, b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderwriteError)
+		panic(BufferUnderwriteError.at(off, int64(len(data))*4, b.cap))
 
 	}
 
-	var (
-		i = 0
-		n = len(data)
-	)
-	{
-	write_loop:
-		b.buf[off+int64(i*4)] = byte(data[i] >> 24)
-		b.buf[off+int64(1+(i*4))] = byte(data[i] >> 16)
-		b.buf[off+int64(2+(i*4))] = byte(data[i] >> 8)
-		b.buf[off+int64(3+(i*4))] = byte(data[i])
-
-		i++
-		if i < n {
-
-			goto write_loop
-
-		}
-	}
+	bulkWriteU32(b.buf[off:off+int64(len(data))*4], data, true)
 
 }
 
@@ -617,6 +792,7 @@ func (b *Buffer) WriteU32BE(off int64, data []uint32) {
 func (b *Buffer) WriteU32BENext(data []uint32) {
 
 	b.WriteU32BE(b.off, data)
+	b.traced(b.off*8, int64(len(data))*32)
 	b.SeekByte(int64(len(data))*4, true)
 
 }
@@ -627,38 +803,17 @@ func (b *Buffer) WriteU64LE(off int64, data []uint64) {
 
 	if (off + int64(len(data))*8) > b.cap {
 
-		panic(BufferOverwriteError)
+		panic(BufferOverwriteError.at(off, int64(len(data))*8, b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderwriteError)
+		panic(BufferUnderwriteError.at(off, int64(len(data))*8, b.cap))
 
 	}
 
-	var (
-		i = 0
-		n = len(data)
-	)
-	{
-	write_loop:
-		b.buf[off+int64(i*8)] = byte(data[i])
-		b.buf[off+int64(1+(i*8))] = byte(data[i] >> 8)
-		b.buf[off+int64(2+(i*8))] = byte(data[i] >> 16)
-		b.buf[off+int64(3+(i*8))] = byte(data[i] >> 24)
-		b.buf[off+int64(4+(i*8))] = byte(data[i] >> 32)
-		b.buf[off+int64(5+(i*8))] = byte(data[i] >> 40)
-		b.buf[off+int64(6+(i*8))] = byte(data[i] >> 48)
-		b.buf[off+int64(7+(i*8))] = byte(data[i] >> 56)
-
-		i++
-		if i < n {
-
-			goto write_loop
-
-		}
-	}
+	bulkWriteU64(b.buf[off:off+int64(len(data))*8], data, false)
 
 }
 
@@ -668,6 +823,7 @@ func (b *Buffer) WriteU64LE(off int64, data []uint64) {
 func (b *Buffer) WriteU64LENext(data []uint64) {
 
 	b.WriteU64LE(b.off, data)
+	b.traced(b.off*8, int64(len(data))*64)
 	b.SeekByte(int64(len(data))*8, true)
 
 }
@@ -679,38 +835,17 @@ func (b *Buffer) WriteU64BE(off int64, data []uint64) {
 
 	if (off + int64(len(data))*8) > b.cap {
 
-		panic(BufferOverwriteError)
+		panic(BufferOverwriteError.at(off, int64(len(data))*8, b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderwriteError)
+		panic(BufferUnderwriteError.at(off, int64(len(data))*8, b.cap))
 
 	}
 
-	var (
-		i = 0
-		n = len(data)
-	)
-	{
-	write_loop:
-		b.buf[off+int64(i*8)] = byte(data[i] >> 56)
-		b.buf[off+int64(1+(i*8))] = byte(data[i] >> 48)
-		b.buf[off+int64(2+(i*8))] = byte(data[i] >> 40)
-		b.buf[off+int64(3+(i*8))] = byte(data[i] >> 32)
-		b.buf[off+int64(4+(i*8))] = byte(data[i] >> 24)
-		b.buf[off+int64(5+(i*8))] = byte(data[i] >> 16)
-		b.buf[off+int64(6+(i*8))] = byte(data[i] >> 8)
-		b.buf[off+int64(7+(i*8))] = byte(data[i])
-
-		i++
-		if i < n {
-
-			goto write_loop
-
-		}
-	}
+	bulkWriteU64(b.buf[off:off+int64(len(data))*8], data, true)
 
 }
 
@@ -720,6 +855,7 @@ func (b *Buffer) WriteU64BE(off int64, data []uint64) {
 func (b *Buffer) WriteU64BENext(data []uint64) {
 
 	b.WriteU64BE(b.off, data)
+	b.traced(b.off*8, int64(len(data))*64)
 	b.SeekByte(int64(len(data))*8, true)
 
 }
@@ -730,13 +866,13 @@ func (b *Buffer) ReadBytes(off, n int64) []byte {
 
 	if (off + n) > b.cap {
 
-		panic(BufferOverreadError)
+		panic(BufferOverreadError.at(off, n, b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderreadError)
+		panic(BufferUnderreadError.at(off, n, b.cap))
 
 	}
 
@@ -749,6 +885,7 @@ func (b *Buffer) ReadBytes(off, n int64) []byte {
 func (b *Buffer) ReadBytesNext(n int64) (out []byte) {
 
 	out = b.ReadBytes(b.off, n)
+	b.traced(b.off*8, n*8)
 	b.SeekByte(n, true)
 	return
 
@@ -767,6 +904,7 @@ func (b *Buffer) ReadByte(off int64) byte {
 func (b *Buffer) ReadByteNext() (out byte) {
 
 	out = b.ReadBytes(b.off, 1)[0]
+	b.traced(b.off*8, 8)
 	b.SeekByte(1, true)
 	return
 
@@ -779,31 +917,19 @@ func (b *Buffer) ReadU16LE(off, n int64) (out []uint16) {
 
 	if (off + n*2) > b.cap {
 
-		panic(BufferOverreadError)
+		panic(BufferOverreadError.at(off, n*2, b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderreadError)
+		panic(BufferUnderreadError.at(off, n*2, b.cap))
 
 	}
 
 	out = make([]uint16, n)
 
-	i := int64(0)
-	{
-	read_loop:
-		out[i] = uint16(b.buf[off+(i*2)]) |
-			uint16(b.buf[off+(1+(i*2))])<<8
-
-		i++
-		if i < n {
-
-			goto read_loop
-
-		}
-	}
+	bulkReadU16(out, b.buf[off:off+n*2], false)
 
 	return
 
@@ -815,6 +941,7 @@ func (b *Buffer) ReadU16LE(off, n int64) (out []uint16) {
 func (b *Buffer) ReadU16LENext(n int64) (out []uint16) {
 
 	out = b.ReadU16LE(b.off, n)
+	b.traced(b.off*8, n*16)
 	b.SeekByte(n*2, true)
 	return
 
@@ -827,31 +954,19 @@ func (b *Buffer) ReadU16BE(off, n int64) (out []uint16) {
 
 	if (off + n*2) > b.cap {
 
-		panic(BufferOverreadError)
+		panic(BufferOverreadError.at(off, n*2, b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderreadError)
+		panic(BufferUnderreadError.at(off, n*2, b.cap))
 
 	}
 
 	out = make([]uint16, n)
 
-	i := int64(0)
-	{
-	read_loop:
-		out[i] = uint16(b.buf[off+(1+(i*2))]) |
-			uint16(b.buf[off+(i*2)])<<8
-
-		i++
-		if i < n {
-
-			goto read_loop
-
-		}
-	}
+	bulkReadU16(out, b.buf[off:off+n*2], true)
 
 	return
 
@@ -863,6 +978,7 @@ func (b *Buffer) ReadU16BE(off, n int64) (out []uint16) {
 func (b *Buffer) ReadU16BENext(n int64) (out []uint16) {
 
 	out = b.ReadU16BE(b.off, n)
+	b.traced(b.off*8, n*16)
 	b.SeekByte(n*2, true)
 	return
 
@@ -875,33 +991,19 @@ func (b *Buffer) ReadU32LE(off, n int64) (out []uint32) {
 
 	if (off + n*4) > b.cap {
 
-		panic(BufferOverreadError)
+		panic(BufferOverreadError.at(off, n*4, b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderreadError)
+		panic(BufferUnderreadError.at(off, n*4, b.cap))
 
 	}
 
 	out = make([]uint32, n)
 
-	i := int64(0)
-	{
-	read_loop:
-		out[i] = uint32(b.buf[off+(i*4)]) |
-			uint32(b.buf[off+(1+(i*4))])<<8 |
-			uint32(b.buf[off+(2+(i*4))])<<16 |
-			uint32(b.buf[off+(3+(i*4))])<<24
-
-		i++
-		if i < n {
-
-			goto read_loop
-
-		}
-	}
+	bulkReadU32(out, b.buf[off:off+n*4], false)
 
 	return
 
@@ -913,6 +1015,7 @@ func (b *Buffer) ReadU32LE(off, n int64) (out []uint32) {
 func (b *Buffer) ReadU32LENext(n int64) (out []uint32) {
 
 	out = b.ReadU32LE(b.off, n)
+	b.traced(b.off*8, n*32)
 	b.SeekByte(n*4, true)
 	return
 
@@ -925,33 +1028,19 @@ func (b *Buffer) ReadU32BE(off, n int64) (out []uint32) {
 
 	if (off + n*4) > b.cap {
 
-		panic(BufferOverreadError)
+		panic(BufferOverreadError.at(off, n*4, b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderreadError)
+		panic(BufferUnderreadError.at(off, n*4, b.cap))
 
 	}
 
 	out = make([]uint32, n)
 
-	i := int64(0)
-	{
-	read_loop:
-		out[i] = uint32(b.buf[off+(3+(i*4))]) |
-			uint32(b.buf[off+(2+(i*4))])<<8 |
-			uint32(b.buf[off+(1+(i*4))])<<16 |
-			uint32(b.buf[off+(i*4)])<<24
-
-		i++
-		if i < n {
-
-			goto read_loop
-
-		}
-	}
+	bulkReadU32(out, b.buf[off:off+n*4], true)
 
 	return
 
@@ -963,6 +1052,7 @@ func (b *Buffer) ReadU32BE(off, n int64) (out []uint32) {
 func (b *Buffer) ReadU32BENext(n int64) (out []uint32) {
 
 	out = b.ReadU32BE(b.off, n)
+	b.traced(b.off*8, n*32)
 	b.SeekByte(n*4, true)
 	return
 
@@ -975,37 +1065,19 @@ func (b *Buffer) ReadU64LE(off, n int64) (out []uint64) {
 
 	if (off + n*8) > b.cap {
 
-		panic(BufferOverreadError)
+		panic(BufferOverreadError.at(off, n*8, b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderreadError)
+		panic(BufferUnderreadError.at(off, n*8, b.cap))
 
 	}
 
 	out = make([]uint64, n)
 
-	i := int64(0)
-	{
-	read_loop:
-		out[i] = uint64(b.buf[off+(i*8)]) |
-			uint64(b.buf[off+(1+(i*8))])<<8 |
-			uint64(b.buf[off+(2+(i*8))])<<16 |
-			uint64(b.buf[off+(3+(i*8))])<<24 |
-			uint64(b.buf[off+(4+(i*8))])<<32 |
-			uint64(b.buf[off+(5+(i*8))])<<40 |
-			uint64(b.buf[off+(6+(i*8))])<<48 |
-			uint64(b.buf[off+(7+(i*8))])<<56
-
-		i++
-		if i < n {
-
-			goto read_loop
-
-		}
-	}
+	bulkReadU64(out, b.buf[off:off+n*8], false)
 
 	return
 
@@ -1017,6 +1089,7 @@ func (b *Buffer) ReadU64LE(off, n int64) (out []uint64) {
 func (b *Buffer) ReadU64LENext(n int64) (out []uint64) {
 
 	out = b.ReadU64LE(b.off, n)
+	b.traced(b.off*8, n*64)
 	b.SeekByte(n*8, true)
 	return
 
@@ -1029,37 +1102,19 @@ func (b *Buffer) ReadU64BE(off, n int64) (out []uint64) {
 
 	if (off + n*8) > b.cap {
 
-		panic(BufferOverreadError)
+		panic(BufferOverreadError.at(off, n*8, b.cap))
 
 	}
 
 	if off < 0x00 {
 
-		panic(BufferUnderreadError)
+		panic(BufferUnderreadError.at(off, n*8, b.cap))
 
 	}
 
 	out = make([]uint64, n)
 
-	i := int64(0)
-	{
-	read_loop:
-		out[i] = uint64(b.buf[off+(7+(i*8))]) |
-			uint64(b.buf[off+(6+(i*8))])<<8 |
-			uint64(b.buf[off+(5+(i*8))])<<16 |
-			uint64(b.buf[off+(4+(i*8))])<<24 |
-			uint64(b.buf[off+(3+(i*8))])<<32 |
-			uint64(b.buf[off+(2+(i*8))])<<40 |
-			uint64(b.buf[off+(1+(i*8))])<<48 |
-			uint64(b.buf[off+(i*8)])<<56
-
-		i++
-		if i < n {
-
-			goto read_loop
-
-		}
-	}
+	bulkReadU64(out, b.buf[off:off+n*8], true)
 
 	return
 
@@ -1071,6 +1126,7 @@ func (b *Buffer) ReadU64BE(off, n int64) (out []uint64) {
 func (b *Buffer) ReadU64BENext(n int64) (out []uint64) {
 
 	out = b.ReadU64BE(b.off, n)
+	b.traced(b.off*8, n*64)
 	b.SeekByte(n*8, true)
 	return
 
@@ -1151,6 +1207,13 @@ func (b *Buffer) Grow(n int64) {
 
 	}
 
+	if b.grow != nil {
+
+		b.grow(n)
+		return
+
+	}
+
 	if n <= int64(cap(b.buf))-b.cap {
 
 		b.buf = b.buf[0 : b.cap+n]
@@ -1175,14 +1238,27 @@ func (b *Buffer) Refresh() {
 
 		b.obuf = unsafe.Pointer(&b.buf[0])
 
+	} else {
+
+		b.obuf = nil
+
 	}
 
 }
 
-// Reset resets the entire buffer
+// Reset resets the entire buffer. pooled buffers drop their backing
+// array if it grew past the pool's retention limit
 func (b *Buffer) Reset() {
 
-	b.buf = b.buf[0:0]
+	if b.pool != nil && int64(cap(b.buf)) > b.pool.max {
+
+		b.buf = []byte{}
+
+	} else {
+
+		b.buf = b.buf[0:0]
+
+	}
 	b.off = 0x00
 	b.boff = 0x00
 	b.cap = 0
@@ -1190,6 +1266,26 @@ func (b *Buffer) Reset() {
 
 }
 
+// Release returns the buffer to the pool it was taken from so that
+// its backing array can be reused. the buffer must not be used
+// afterwards. buffers that were not taken from a pool only drop
+// their backing array
+func (b *Buffer) Release() {
+
+	if b.pool != nil {
+
+		b.pool.Put(b)
+		return
+
+	}
+
+	b.buf = []byte{}
+	b.off = 0x00
+	b.boff = 0x00
+	b.Refresh()
+
+}
+
 /* value retrieval */
 
 // Bytes returns the internal byte slice of the buffer
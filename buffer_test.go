@@ -952,6 +952,148 @@ func TestBufferReadBitsNext(t *testing.T) {
 
 }
 
+func TestBufferReadBitsSigned(t *testing.T) {
+
+	buf := NewBuffer([]byte{0xF6, 0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+
+	for _, c := range []struct {
+		off, n   int64
+		expected int64
+	}{
+		{0x00, 4, -1},
+		{0x04, 4, 6},
+		{0x03, 3, -3},
+		{0x08, 1, 0},
+		{0x00, 0, 0},
+		{0x08, 64, 0x7FFFFFFFFFFFFFFF},
+		{0x00, 64, -0x0980000000000001},
+	} {
+
+		if out := buf.ReadBitsSigned(c.off, c.n); out != c.expected {
+
+			t.Fatalf("expected int64 does not match the one gotten for %d bits at %d (got %d, expected %d)", c.n, c.off, out, c.expected)
+
+		}
+
+	}
+
+}
+
+func TestBufferReadBitsBytes(t *testing.T) {
+
+	buf := NewBuffer([]byte{0x0A, 0xBC, 0xDE, 0xF1})
+
+	if out := buf.ReadBitsBytes(0x04, 20); !cmp.Equal([]byte{0xAB, 0xCD, 0xE0}, out) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v, expected %#v)", out, []byte{0xAB, 0xCD, 0xE0})
+
+	}
+
+	if out := buf.ReadBitsBytes(0x08, 16); !cmp.Equal([]byte{0xBC, 0xDE}, out) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v, expected %#v)", out, []byte{0xBC, 0xDE})
+
+	}
+
+	// a field wider than 64 bits at an unaligned offset
+	data := make([]byte, 40)
+	for i := range data {
+
+		data[i] = byte(i * 37)
+
+	}
+
+	buf = NewBuffer(data)
+	out := buf.ReadBitsBytes(0x05, 300)
+	for i := int64(0); i < 300; i++ {
+
+		if bit := out[i/8] >> uint64(7-i%8) & 1; bit != buf.ReadBit(0x05+i) {
+
+			t.Fatalf("expected bit does not match the one gotten at %d (got %d, expected %d)", i, bit, buf.ReadBit(0x05+i))
+
+		}
+
+	}
+
+	if out[len(out)-1]&0x0F != 0 {
+
+		t.Fatalf("expected the unused bits of the last byte to be zero (got %#x)", out[len(out)-1])
+
+	}
+
+}
+
+func TestBufferSetBitsBytes(t *testing.T) {
+
+	buf := NewBuffer([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+
+	buf.SetBitsBytes(0x04, []byte{0x12, 0x34, 0x5F}, 20)
+	if !cmp.Equal([]byte{0xF1, 0x23, 0x45, 0xFF}, buf.Bytes()) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v)", buf.Bytes())
+
+	}
+
+	payload := make([]byte, 38)
+	for i := range payload {
+
+		payload[i] = byte(i*91 + 7)
+
+	}
+
+	buf = NewBuffer(make([]byte, 40))
+	buf.SeekBit(0x0B, false)
+	buf.SetBitsBytesNext(payload, 300)
+
+	if buf.BitOffset() != 0x0B+300 {
+
+		t.Fatalf("expected bit offset does not match the one gotten (got %d, expected %d)", buf.BitOffset(), 0x0B+300)
+
+	}
+
+	payload[len(payload)-1] &= 0xF0
+	if out := buf.ReadBitsBytes(0x0B, 300); !cmp.Equal(payload, out) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v, expected %#v)", out, payload)
+
+	}
+
+}
+
+func TestBufferBitsInvalidCount(t *testing.T) {
+
+	buf := NewBuffer([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+
+	func() {
+
+		defer panicChecker(t, BufferInvalidBitCountError)
+		buf.ReadBits(0x00, 65)
+
+	}()
+
+	func() {
+
+		defer panicChecker(t, BufferInvalidBitCountError)
+		buf.SetBits(0x00, 0, -1)
+
+	}()
+
+	func() {
+
+		defer panicChecker(t, BufferInvalidBitCountError)
+		buf.SetBitsBytes(0x00, []byte{0x00}, 9)
+
+	}()
+
+	func() {
+
+		defer panicChecker(t, BufferOverreadError)
+		buf.ReadBitsBytes(0x01, 72)
+
+	}()
+
+}
+
 func TestBufferSetBit(t *testing.T) {
 
 	var expected byte = 1
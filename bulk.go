@@ -0,0 +1,263 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"encoding/binary"
+	"reflect"
+	"unsafe"
+)
+
+/* internal use methods */
+
+// asBytes returns a byte slice sharing memory with the n elements of
+// the specified size located at p
+func asBytes(p unsafe.Pointer, n, size int) (out []byte) {
+
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&out))
+	h.Data = uintptr(p)
+	h.Len = n * size
+	h.Cap = n * size
+	return
+
+}
+
+// bulkReadU16 fills dst with the uint16s stored in src, in big endian
+// if big is set. src must hold exactly len(dst) elements. when the byte
+// order is the one of the host, the data is copied over as is
+func bulkReadU16(dst []uint16, src []byte, big bool) {
+
+	if len(dst) == 0 {
+
+		return
+
+	}
+
+	if big != hostLittleEndian {
+
+		copy(asBytes(unsafe.Pointer(&dst[0]), len(dst), 2), src)
+		return
+
+	}
+
+	// the compiler turns these into a load and a byte swap each, and
+	// the early check removes the bounds checks from the loop
+	_ = src[len(dst)*2-1]
+	if big {
+
+		for i := range dst {
+
+			dst[i] = binary.BigEndian.Uint16(src[i*2:])
+
+		}
+		return
+
+	}
+
+	for i := range dst {
+
+		dst[i] = binary.LittleEndian.Uint16(src[i*2:])
+
+	}
+
+}
+
+// bulkReadU32 fills dst with the uint32s stored in src, in big endian
+// if big is set. src must hold exactly len(dst) elements. when the byte
+// order is the one of the host, the data is copied over as is
+func bulkReadU32(dst []uint32, src []byte, big bool) {
+
+	if len(dst) == 0 {
+
+		return
+
+	}
+
+	if big != hostLittleEndian {
+
+		copy(asBytes(unsafe.Pointer(&dst[0]), len(dst), 4), src)
+		return
+
+	}
+
+	// the compiler turns these into a load and a byte swap each, and
+	// the early check removes the bounds checks from the loop
+	_ = src[len(dst)*4-1]
+	if big {
+
+		for i := range dst {
+
+			dst[i] = binary.BigEndian.Uint32(src[i*4:])
+
+		}
+		return
+
+	}
+
+	for i := range dst {
+
+		dst[i] = binary.LittleEndian.Uint32(src[i*4:])
+
+	}
+
+}
+
+// bulkReadU64 fills dst with the uint64s stored in src, in big endian
+// if big is set. src must hold exactly len(dst) elements. when the byte
+// order is the one of the host, the data is copied over as is
+func bulkReadU64(dst []uint64, src []byte, big bool) {
+
+	if len(dst) == 0 {
+
+		return
+
+	}
+
+	if big != hostLittleEndian {
+
+		copy(asBytes(unsafe.Pointer(&dst[0]), len(dst), 8), src)
+		return
+
+	}
+
+	// the compiler turns these into a load and a byte swap each, and
+	// the early check removes the bounds checks from the loop
+	_ = src[len(dst)*8-1]
+	if big {
+
+		for i := range dst {
+
+			dst[i] = binary.BigEndian.Uint64(src[i*8:])
+
+		}
+		return
+
+	}
+
+	for i := range dst {
+
+		dst[i] = binary.LittleEndian.Uint64(src[i*8:])
+
+	}
+
+}
+
+// bulkWriteU16 stores the uint16s of src in dst, in big endian if big
+// is set. dst must have room for exactly len(src) elements
+func bulkWriteU16(dst []byte, src []uint16, big bool) {
+
+	if len(src) == 0 {
+
+		return
+
+	}
+
+	if big != hostLittleEndian {
+
+		copy(dst, asBytes(unsafe.Pointer(&src[0]), len(src), 2))
+		return
+
+	}
+
+	_ = dst[len(src)*2-1]
+	if big {
+
+		for i, v := range src {
+
+			binary.BigEndian.PutUint16(dst[i*2:], v)
+
+		}
+		return
+
+	}
+
+	for i, v := range src {
+
+		binary.LittleEndian.PutUint16(dst[i*2:], v)
+
+	}
+
+}
+
+// bulkWriteU32 stores the uint32s of src in dst, in big endian if big
+// is set. dst must have room for exactly len(src) elements
+func bulkWriteU32(dst []byte, src []uint32, big bool) {
+
+	if len(src) == 0 {
+
+		return
+
+	}
+
+	if big != hostLittleEndian {
+
+		copy(dst, asBytes(unsafe.Pointer(&src[0]), len(src), 4))
+		return
+
+	}
+
+	_ = dst[len(src)*4-1]
+	if big {
+
+		for i, v := range src {
+
+			binary.BigEndian.PutUint32(dst[i*4:], v)
+
+		}
+		return
+
+	}
+
+	for i, v := range src {
+
+		binary.LittleEndian.PutUint32(dst[i*4:], v)
+
+	}
+
+}
+
+// bulkWriteU64 stores the uint64s of src in dst, in big endian if big
+// is set. dst must have room for exactly len(src) elements
+func bulkWriteU64(dst []byte, src []uint64, big bool) {
+
+	if len(src) == 0 {
+
+		return
+
+	}
+
+	if big != hostLittleEndian {
+
+		copy(dst, asBytes(unsafe.Pointer(&src[0]), len(src), 8))
+		return
+
+	}
+
+	_ = dst[len(src)*8-1]
+	if big {
+
+		for i, v := range src {
+
+			binary.BigEndian.PutUint64(dst[i*8:], v)
+
+		}
+		return
+
+	}
+
+	for i, v := range src {
+
+		binary.LittleEndian.PutUint64(dst[i*8:], v)
+
+	}
+
+}
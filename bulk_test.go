@@ -0,0 +1,256 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+utilities
+
+*/
+
+// bulkSizes are the element counts the bulk conversion benchmarks are
+// run with
+var bulkSizes = []int{1 << 10, 1 << 16, 1 << 20}
+
+// bulkOrders maps the byte orders bulk conversions are tested with to
+// whether they are big endian
+var bulkOrders = map[binary.ByteOrder]bool{
+	binary.LittleEndian: false,
+	binary.BigEndian:    true,
+}
+
+/*
+
+tests
+
+*/
+
+func TestBulkConversions(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+
+	// the byte slices start at an odd offset to exercise unaligned
+	// access
+	for _, n := range []int{0, 1, 7, 256, 1027} {
+
+		data := make([]byte, 1+n*8)
+		r.Read(data)
+		data = data[1:]
+
+		for order, big := range bulkOrders {
+
+			var (
+				u16 = make([]uint16, n)
+				u32 = make([]uint32, n)
+				u64 = make([]uint64, n)
+			)
+
+			bulkReadU16(u16, data[:n*2], big)
+			bulkReadU32(u32, data[:n*4], big)
+			bulkReadU64(u64, data[:n*8], big)
+
+			for i := 0; i < n; i++ {
+
+				if u16[i] != order.Uint16(data[i*2:]) || u32[i] != order.Uint32(data[i*4:]) || u64[i] != order.Uint64(data[i*8:]) {
+
+					t.Fatalf("expected %s values do not match the ones gotten at index %d of %d", order, i, n)
+
+				}
+
+			}
+
+			for size, write := range map[int]func(out []byte){
+				2: func(out []byte) { bulkWriteU16(out, u16, big) },
+				4: func(out []byte) { bulkWriteU32(out, u32, big) },
+				8: func(out []byte) { bulkWriteU64(out, u64, big) },
+			} {
+
+				out := make([]byte, n*size)
+				write(out)
+				if !cmp.Equal(data[:n*size], out) {
+
+					t.Fatalf("expected %s bytes do not match the ones gotten for %d elements of size %d", order, n, size)
+
+				}
+
+			}
+
+		}
+
+	}
+
+}
+
+/*
+
+benchmarks
+
+*/
+
+func BenchmarkBulkReadU32LE(b *testing.B) {
+
+	for _, n := range bulkSizes {
+
+		buf := NewBuffer(make([]byte, n*4))
+		b.Run(fmt.Sprintf("crunch/%d", n), func(b *testing.B) {
+
+			b.SetBytes(int64(n * 4))
+			for i := 0; i < b.N; i++ {
+
+				_ = buf.ReadU32LE(0x00, int64(n))
+
+			}
+
+		})
+
+		b.Run(fmt.Sprintf("binary/%d", n), func(b *testing.B) {
+
+			b.SetBytes(int64(n * 4))
+			for i := 0; i < b.N; i++ {
+
+				out := make([]uint32, n)
+				for j := range out {
+
+					out[j] = binary.LittleEndian.Uint32(buf.buf[j*4:])
+
+				}
+
+			}
+
+		})
+
+	}
+
+}
+
+func BenchmarkBulkReadU64BE(b *testing.B) {
+
+	for _, n := range bulkSizes {
+
+		buf := NewBuffer(make([]byte, n*8))
+		b.Run(fmt.Sprintf("crunch/%d", n), func(b *testing.B) {
+
+			b.SetBytes(int64(n * 8))
+			for i := 0; i < b.N; i++ {
+
+				_ = buf.ReadU64BE(0x00, int64(n))
+
+			}
+
+		})
+
+		b.Run(fmt.Sprintf("binary/%d", n), func(b *testing.B) {
+
+			b.SetBytes(int64(n * 8))
+			for i := 0; i < b.N; i++ {
+
+				out := make([]uint64, n)
+				for j := range out {
+
+					out[j] = binary.BigEndian.Uint64(buf.buf[j*8:])
+
+				}
+
+			}
+
+		})
+
+	}
+
+}
+
+func BenchmarkBulkWriteU32LE(b *testing.B) {
+
+	for _, n := range bulkSizes {
+
+		var (
+			buf  = NewBuffer(make([]byte, n*4))
+			data = make([]uint32, n)
+		)
+
+		b.Run(fmt.Sprintf("crunch/%d", n), func(b *testing.B) {
+
+			b.SetBytes(int64(n * 4))
+			for i := 0; i < b.N; i++ {
+
+				buf.WriteU32LE(0x00, data)
+
+			}
+
+		})
+
+		b.Run(fmt.Sprintf("binary/%d", n), func(b *testing.B) {
+
+			b.SetBytes(int64(n * 4))
+			for i := 0; i < b.N; i++ {
+
+				for j, v := range data {
+
+					binary.LittleEndian.PutUint32(buf.buf[j*4:], v)
+
+				}
+
+			}
+
+		})
+
+	}
+
+}
+
+func BenchmarkBulkWriteU64BE(b *testing.B) {
+
+	for _, n := range bulkSizes {
+
+		var (
+			buf  = NewBuffer(make([]byte, n*8))
+			data = make([]uint64, n)
+		)
+
+		b.Run(fmt.Sprintf("crunch/%d", n), func(b *testing.B) {
+
+			b.SetBytes(int64(n * 8))
+			for i := 0; i < b.N; i++ {
+
+				buf.WriteU64BE(0x00, data)
+
+			}
+
+		})
+
+		b.Run(fmt.Sprintf("binary/%d", n), func(b *testing.B) {
+
+			b.SetBytes(int64(n * 8))
+			for i := 0; i < b.N; i++ {
+
+				for j, v := range data {
+
+					binary.BigEndian.PutUint64(buf.buf[j*8:], v)
+
+				}
+
+			}
+
+		})
+
+	}
+
+}
@@ -0,0 +1,244 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import "hash/adler32"
+
+/* internet checksum */
+
+// ChecksumAdd adds data to the partial ones' complement sum described
+// in rfc 1071 as a series of big-endian 16-bit words. an odd trailing
+// byte is padded with zero, so only the last chunk of a message may
+// have an odd length
+func ChecksumAdd(sum uint32, data []byte) uint32 {
+
+	var (
+		i = 0
+		n = len(data) &^ 1
+	)
+	for ; i < n; i += 2 {
+
+		sum += uint32(data[i])<<8 | uint32(data[i+1])
+
+		// fold early so that the sum can never overflow
+		if sum&0x80000000 != 0 {
+
+			sum = (sum & 0xFFFF) + (sum >> 16)
+
+		}
+
+	}
+
+	if len(data)&1 == 1 {
+
+		sum += uint32(data[n]) << 8
+
+	}
+
+	return sum
+
+}
+
+// ChecksumFold folds a partial ones' complement sum into a 16-bit
+// internet checksum
+func ChecksumFold(sum uint32) uint16 {
+
+	for sum > 0xFFFF {
+
+		sum = (sum & 0xFFFF) + (sum >> 16)
+
+	}
+
+	return ^uint16(sum)
+
+}
+
+// UpdateChecksum returns the internet checksum sum updated for a
+// 16-bit word changing from prev to next, as described in rfc 1624
+func UpdateChecksum(sum, prev, next uint16) uint16 {
+
+	s := uint32(^sum) + uint32(^prev) + uint32(next)
+	s = (s & 0xFFFF) + (s >> 16)
+	s = (s & 0xFFFF) + (s >> 16)
+
+	return ^uint16(s)
+
+}
+
+// InternetChecksum returns the rfc 1071 checksum of the n bytes
+// located at the specified offset without modifying the internal
+// offset value. partial sums, such as the one of a tcp or udp
+// pseudo-header, may be provided to be added in
+func (b *Buffer) InternetChecksum(off, n int64, partial ...uint32) uint16 {
+
+	var sum uint32
+	for _, p := range partial {
+
+		sum = ChecksumAdd(sum, []byte{byte(p >> 24), byte(p >> 16), byte(p >> 8), byte(p)})
+
+	}
+
+	return ChecksumFold(ChecksumAdd(sum, b.ReadBytes(off, n)))
+
+}
+
+// PutInternetChecksum clears the 16-bit field at the offset at,
+// computes the rfc 1071 checksum of the n bytes located at the
+// specified offset and writes it to the field in big-endian
+func (b *Buffer) PutInternetChecksum(off, n, at int64, partial ...uint32) {
+
+	b.WriteU16BE(at, []uint16{0x0000})
+	b.WriteU16BE(at, []uint16{b.InternetChecksum(off, n, partial...)})
+
+}
+
+// PatchU16BE writes a uint16 to the buffer at the specified offset in
+// big-endian and incrementally updates the internet checksum stored
+// at the offset at to account for it, as described in rfc 1624
+func (b *Buffer) PatchU16BE(off int64, data uint16, at int64) {
+
+	var (
+		prev = b.ReadU16BE(off, 1)[0]
+		sum  = b.ReadU16BE(at, 1)[0]
+		next = data
+	)
+
+	// words at an odd distance from the checksum field straddle two
+	// words of the sum, which is the same as adding them byte-swapped
+	if (off-at)&1 != 0 {
+
+		prev = prev<<8 | prev>>8
+		next = next<<8 | next>>8
+
+	}
+
+	b.WriteU16BE(off, []uint16{data})
+	b.WriteU16BE(at, []uint16{UpdateChecksum(sum, prev, next)})
+
+}
+
+/* fletcher and adler */
+
+// Fletcher16 returns the fletcher-16 checksum of the n bytes located
+// at the specified offset without modifying the internal offset value
+func (b *Buffer) Fletcher16(off, n int64) uint16 {
+
+	var (
+		data   = b.ReadBytes(off, n)
+		s1, s2 uint32
+	)
+
+	for len(data) > 0 {
+
+		// 5802 bytes is the most that can be summed before s2
+		// overflows a uint32
+		chunk := data
+		if len(chunk) > 5802 {
+
+			chunk = chunk[:5802]
+
+		}
+		data = data[len(chunk):]
+
+		for _, c := range chunk {
+
+			s1 += uint32(c)
+			s2 += s1
+
+		}
+		s1 %= 255
+		s2 %= 255
+
+	}
+
+	return uint16(s2<<8 | s1)
+
+}
+
+// Fletcher32 returns the fletcher-32 checksum of the n bytes located
+// at the specified offset without modifying the internal offset
+// value. the data is summed as little-endian 16-bit words and an odd
+// trailing byte is padded with zero
+func (b *Buffer) Fletcher32(off, n int64) uint32 {
+
+	var (
+		data   = b.ReadBytes(off, n)
+		s1, s2 uint32
+	)
+
+	for len(data) > 0 {
+
+		// 359 words is the most that can be summed before s2
+		// overflows a uint32
+		chunk := data
+		if len(chunk) > 718 {
+
+			chunk = chunk[:718]
+
+		}
+		data = data[len(chunk):]
+
+		for i := 0; i < len(chunk); i += 2 {
+
+			w := uint32(chunk[i])
+			if i+1 < len(chunk) {
+
+				w |= uint32(chunk[i+1]) << 8
+
+			}
+
+			s1 += w
+			s2 += s1
+
+		}
+		s1 %= 65535
+		s2 %= 65535
+
+	}
+
+	return s2<<16 | s1
+
+}
+
+// Adler32 returns the adler-32 checksum of the n bytes located at the
+// specified offset without modifying the internal offset value
+func (b *Buffer) Adler32(off, n int64) uint32 {
+
+	return adler32.Checksum(b.ReadBytes(off, n))
+
+}
+
+// PutFletcher16 computes the fletcher-16 checksum of the n bytes
+// located at the specified offset and writes it to the offset at in
+// big-endian
+func (b *Buffer) PutFletcher16(off, n, at int64) {
+
+	b.WriteU16BE(at, []uint16{b.Fletcher16(off, n)})
+
+}
+
+// PutFletcher32 computes the fletcher-32 checksum of the n bytes
+// located at the specified offset and writes it to the offset at in
+// big-endian
+func (b *Buffer) PutFletcher32(off, n, at int64) {
+
+	b.WriteU32BE(at, []uint32{b.Fletcher32(off, n)})
+
+}
+
+// PutAdler32 computes the adler-32 checksum of the n bytes located at
+// the specified offset and writes it to the offset at in big-endian
+func (b *Buffer) PutAdler32(off, n, at int64) {
+
+	b.WriteU32BE(at, []uint32{b.Adler32(off, n)})
+
+}
@@ -0,0 +1,186 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import "testing"
+
+/*
+
+tests
+
+*/
+
+func TestBufferInternetChecksum(t *testing.T) {
+
+	var expected uint16 = 0x220D
+
+	// example taken from rfc 1071
+	buf := NewBuffer([]byte{0x00, 0x01, 0xF2, 0x03, 0xF4, 0xF5, 0xF6, 0xF7})
+
+	out := buf.InternetChecksum(0x00, 8)
+	if out != expected {
+
+		t.Fatalf("expected uint16 does not match the one gotten (got %#x, expected %#x)", out, expected)
+
+	}
+
+	out = buf.InternetChecksum(0x00, 4, 0xF4F5F6F7)
+	if out != expected {
+
+		t.Fatalf("expected uint16 does not match the one gotten (got %#x, expected %#x)", out, expected)
+
+	}
+
+}
+
+func TestBufferPutInternetChecksum(t *testing.T) {
+
+	var expected uint16 = 0xB861
+
+	// an ipv4 header with the checksum field zeroed
+	buf := NewBuffer([]byte{
+		0x45, 0x00, 0x00, 0x73, 0x00, 0x00, 0x40, 0x00,
+		0x40, 0x11, 0x00, 0x00, 0xC0, 0xA8, 0x00, 0x01,
+		0xC0, 0xA8, 0x00, 0xC7,
+	})
+	buf.WriteU16BE(0x0A, []uint16{0xFFFF})
+	buf.PutInternetChecksum(0x00, 20, 0x0A)
+
+	out := buf.ReadU16BE(0x0A, 1)[0]
+	if out != expected {
+
+		t.Fatalf("expected uint16 does not match the one gotten (got %#x, expected %#x)", out, expected)
+
+	}
+
+	if buf.InternetChecksum(0x00, 20) != 0x0000 {
+
+		t.Fatalf("expected checksum over a valid header to be zero")
+
+	}
+
+}
+
+func TestBufferPatchU16BE(t *testing.T) {
+
+	// an ipv4 header with the checksum field zeroed
+	buf := NewBuffer([]byte{
+		0x45, 0x00, 0x00, 0x73, 0x00, 0x00, 0x40, 0x00,
+		0x40, 0x11, 0x00, 0x00, 0xC0, 0xA8, 0x00, 0x01,
+		0xC0, 0xA8, 0x00, 0xC7,
+	})
+	buf.PutInternetChecksum(0x00, 20, 0x0A)
+
+	// total length, then ttl and protocol, then a word straddling the
+	// source address
+	buf.PatchU16BE(0x02, 0x05DC, 0x0A)
+	buf.PatchU16BE(0x08, 0x3F11, 0x0A)
+	buf.PatchU16BE(0x0D, 0x1234, 0x0A)
+
+	expected := buf.ReadU16BE(0x0A, 1)[0]
+	buf.PutInternetChecksum(0x00, 20, 0x0A)
+
+	out := buf.ReadU16BE(0x0A, 1)[0]
+	if out != expected {
+
+		t.Fatalf("expected uint16 does not match the one gotten (got %#x, expected %#x)", expected, out)
+
+	}
+
+}
+
+func TestBufferFletcher(t *testing.T) {
+
+	buf := NewBuffer([]byte("abcdef"))
+
+	if out := buf.Fletcher16(0x00, 5); out != 0xC8F0 {
+
+		t.Fatalf("expected uint16 does not match the one gotten (got %#x, expected %#x)", out, 0xC8F0)
+
+	}
+
+	if out := buf.Fletcher16(0x00, 6); out != 0x2057 {
+
+		t.Fatalf("expected uint16 does not match the one gotten (got %#x, expected %#x)", out, 0x2057)
+
+	}
+
+	if out := buf.Fletcher32(0x00, 5); out != 0xF04FC729 {
+
+		t.Fatalf("expected uint32 does not match the one gotten (got %#x, expected %#x)", out, 0xF04FC729)
+
+	}
+
+	if out := buf.Fletcher32(0x00, 6); out != 0x56502D2A {
+
+		t.Fatalf("expected uint32 does not match the one gotten (got %#x, expected %#x)", out, 0x56502D2A)
+
+	}
+
+}
+
+func TestBufferFletcherLong(t *testing.T) {
+
+	data := make([]byte, 100000)
+	for i := range data {
+
+		data[i] = 0xFF
+
+	}
+
+	buf := NewBuffer(data)
+
+	// every byte is congruent to 0 modulo 255, as is every word
+	// modulo 65535
+	if out := buf.Fletcher16(0x00, int64(len(data))); out != 0x0000 {
+
+		t.Fatalf("expected uint16 does not match the one gotten (got %#x, expected %#x)", out, 0x0000)
+
+	}
+
+	if out := buf.Fletcher32(0x00, int64(len(data))); out != 0x00000000 {
+
+		t.Fatalf("expected uint32 does not match the one gotten (got %#x, expected %#x)", out, 0x00000000)
+
+	}
+
+}
+
+func TestBufferPutAdler32(t *testing.T) {
+
+	var expected uint32 = 0x11E60398
+
+	buf := NewBuffer([]byte("Wikipedia"), []byte{0x00, 0x00, 0x00, 0x00})
+	buf.PutAdler32(0x00, 9, 0x09)
+
+	out := buf.ReadU32BE(0x09, 1)[0]
+	if out != expected {
+
+		t.Fatalf("expected uint32 does not match the one gotten (got %#x, expected %#x)", out, expected)
+
+	}
+
+}
+
+func TestBufferPutCRC(t *testing.T) {
+
+	buf := NewBuffer([]byte("123456789"), []byte{0x00, 0x00, 0x00})
+	buf.PutCRC(0x00, 9, 0x09, CRC24OpenPGP)
+
+	out := buf.ReadBytes(0x09, 3)
+	if out[0] != 0x21 || out[1] != 0xCF || out[2] != 0x02 {
+
+		t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", out, []byte{0x21, 0xCF, 0x02})
+
+	}
+
+}
@@ -0,0 +1,790 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+// crunch is a command-line interface to crunch buffers meant for
+// inspecting and patching binary files from the shell. every
+// subcommand reads the file named by its last argument, or standard
+// input if it is omitted or is "-"
+//
+//	crunch dump [range] [file]
+//	crunch read [-x] type@offset... [file]
+//	crunch write [-i] type@offset=value... [file]
+//	crunch bits range [file]
+//	crunch find [-s] pattern [file]
+//	crunch parse -schema schema.json [-offset n] [file]
+//
+// ranges are written as start:end or start+length, patterns are hex
+// digits where ? matches any nibble, types are u8, u16le, u16be,
+// u32le, u32be, u64le and u64be along with their signed s
+// counterparts, and numbers may be given in decimal, hexadecimal,
+// octal or binary using the usual go prefixes
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/superwhiskers/crunch"
+)
+
+// usage is printed when the command is invoked incorrectly
+const usage = `usage: crunch <command> [arguments] [file]
+
+commands:
+  dump [range] [file]                       hexdump the file or a byte range of it
+  read [-x] type@offset... [file]           print integers stored in the file
+  write [-i] type@offset=value... [file]    patch integers and print the result
+  bits range [file]                         print a range of bits
+  find [-s] pattern [file]                  print the offsets of a hex pattern such as "DE ?? BE EF"
+  parse -schema file [-offset n] [file]     parse the file with a json schema
+`
+
+// errUsage is returned when the command is invoked incorrectly
+var errUsage = errors.New("invalid usage")
+
+func main() {
+
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+
+		if _, ok := err.(crunch.Error); ok {
+
+			// crunch errors carry their own prefix
+			fmt.Fprintln(os.Stderr, err)
+
+		} else if err != errUsage {
+
+			fmt.Fprintf(os.Stderr, "crunch: %v\n", err)
+
+		} else {
+
+			fmt.Fprint(os.Stderr, usage)
+
+		}
+		os.Exit(1)
+
+	}
+
+}
+
+// run executes the command described by args
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) (err error) {
+
+	// buffer methods report out of range accesses by panicking
+	defer crunch.Recover(&err)
+
+	if len(args) == 0 {
+
+		return errUsage
+
+	}
+
+	cmd := &command{
+		name:   args[0],
+		flags:  flag.NewFlagSet(args[0], flag.ContinueOnError),
+		stdin:  stdin,
+		stdout: stdout,
+	}
+	cmd.flags.SetOutput(stderr)
+
+	switch cmd.name {
+
+	case "dump":
+		return cmd.dump(args[1:])
+
+	case "read":
+		return cmd.read(args[1:])
+
+	case "write":
+		return cmd.write(args[1:])
+
+	case "bits":
+		return cmd.bits(args[1:])
+
+	case "find":
+		return cmd.find(args[1:])
+
+	case "parse":
+		return cmd.parse(args[1:])
+
+	}
+	return errUsage
+
+}
+
+// command holds the state of a single invocation
+type command struct {
+	name   string
+	flags  *flag.FlagSet
+	stdin  io.Reader
+	stdout io.Writer
+	file   string
+}
+
+// load parses the flags and returns the remaining arguments along
+// with a buffer holding the input. if the last argument does not
+// satisfy isArg, it is taken to be the name of the input file
+func (c *command) load(args []string, isArg func(string) bool) ([]string, *crunch.Buffer, error) {
+
+	if err := c.flags.Parse(args); err != nil {
+
+		return nil, nil, errUsage
+
+	}
+
+	args = c.flags.Args()
+	if n := len(args); n > 0 && !isArg(args[n-1]) {
+
+		c.file = args[n-1]
+		args = args[:n-1]
+
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+
+	if c.file == "" || c.file == "-" {
+
+		data, err = ioutil.ReadAll(c.stdin)
+
+	} else {
+
+		data, err = ioutil.ReadFile(c.file)
+
+	}
+
+	if err != nil {
+
+		return nil, nil, err
+
+	}
+	return args, crunch.NewBuffer(data), nil
+
+}
+
+/* subcommands */
+
+// dump writes a hexdump of the input or of a range of it
+func (c *command) dump(args []string) error {
+
+	args, buf, err := c.load(args, isRange)
+	if err != nil {
+
+		return err
+
+	}
+
+	start, end := int64(0), buf.ByteCapacity()
+	switch len(args) {
+
+	case 0:
+
+	case 1:
+		if start, end, err = parseRange(args[0]); err != nil {
+
+			return err
+
+		}
+
+		if end > buf.ByteCapacity() {
+
+			end = buf.ByteCapacity()
+
+		}
+
+		if start > end {
+
+			return crunch.BufferOverreadError
+
+		}
+
+	default:
+		return errUsage
+
+	}
+
+	hexdump(c.stdout, buf.ReadBytes(start, end-start), start)
+	return nil
+
+}
+
+// read prints the integers located at the specified offsets
+func (c *command) read(args []string) error {
+
+	useHex := c.flags.Bool("x", false, "print values in hexadecimal")
+
+	args, buf, err := c.load(args, isSpec)
+	if err != nil {
+
+		return err
+
+	}
+
+	if len(args) == 0 {
+
+		return errUsage
+
+	}
+
+	for _, arg := range args {
+
+		kind, off, err := parseSpec(arg)
+		if err != nil {
+
+			return err
+
+		}
+
+		v := kind.read(buf, off)
+		switch {
+
+		case *useHex:
+			fmt.Fprintf(c.stdout, "%#0*x\n", kind.size*2, v)
+
+		case kind.signed:
+			fmt.Fprintln(c.stdout, kind.extend(v))
+
+		default:
+			fmt.Fprintln(c.stdout, v)
+
+		}
+
+	}
+
+	return nil
+
+}
+
+// write patches the integers located at the specified offsets and
+// writes the result to standard output or back to the input file
+func (c *command) write(args []string) error {
+
+	inPlace := c.flags.Bool("i", false, "modify the input file in place")
+
+	args, buf, err := c.load(args, isSpec)
+	if err != nil {
+
+		return err
+
+	}
+
+	if len(args) == 0 || (*inPlace && (c.file == "" || c.file == "-")) {
+
+		return errUsage
+
+	}
+
+	for _, arg := range args {
+
+		i := strings.IndexByte(arg, '=')
+		if i < 0 {
+
+			return fmt.Errorf("missing value in %q", arg)
+
+		}
+
+		kind, off, err := parseSpec(arg[:i])
+		if err != nil {
+
+			return err
+
+		}
+
+		v, err := kind.parse(arg[i+1:])
+		if err != nil {
+
+			return fmt.Errorf("invalid value in %q: %v", arg, err)
+
+		}
+		kind.write(buf, off, v)
+
+	}
+
+	if *inPlace {
+
+		return ioutil.WriteFile(c.file, buf.Bytes(), 0644)
+
+	}
+
+	_, err = c.stdout.Write(buf.Bytes())
+	return err
+
+}
+
+// bits prints a range of bits followed by its value if it fits in an
+// unsigned 64-bit integer
+func (c *command) bits(args []string) error {
+
+	args, buf, err := c.load(args, isRange)
+	if err != nil {
+
+		return err
+
+	}
+
+	if len(args) != 1 {
+
+		return errUsage
+
+	}
+
+	start, end, err := parseRange(args[0])
+	if err != nil {
+
+		return err
+
+	}
+
+	if end <= start {
+
+		return crunch.BufferInvalidBitCountError
+
+	}
+
+	var out strings.Builder
+	for i := start; i < end; i++ {
+
+		out.WriteByte('0' + buf.ReadBit(i))
+
+	}
+
+	if end-start <= 64 {
+
+		fmt.Fprintf(&out, "\t%d", buf.ReadBits(start, end-start))
+
+	}
+
+	fmt.Fprintln(c.stdout, out.String())
+	return nil
+
+}
+
+// find prints the offset of every occurrence of a pattern
+func (c *command) find(args []string) error {
+
+	literal := c.flags.Bool("s", false, "treat the pattern as a string instead of hex")
+
+	// the pattern always comes first, so only a second argument can
+	// be the input file
+	args, buf, err := c.load(args, func(arg string) bool {
+
+		return len(c.flags.Args()) < 2
+
+	})
+	if err != nil {
+
+		return err
+
+	}
+
+	if len(args) != 1 {
+
+		return errUsage
+
+	}
+
+	var p *crunch.Pattern
+	if *literal {
+
+		mask := bytes.Repeat([]byte{0xFF}, len(args[0]))
+		p = crunch.NewPattern([]byte(args[0]), mask)
+
+	} else if p, err = crunch.ParsePattern(args[0]); err != nil {
+
+		return err
+
+	}
+
+	if p.Len() == 0 {
+
+		return errUsage
+
+	}
+
+	for off := int64(0); off < buf.ByteCapacity(); off++ {
+
+		if off = buf.IndexPatternFrom(off, p); off < 0 {
+
+			break
+
+		}
+		fmt.Fprintf(c.stdout, "%#x\n", off)
+
+	}
+
+	return nil
+
+}
+
+// parse prints the tree produced by a schema as json
+func (c *command) parse(args []string) error {
+
+	var (
+		schemaFile = c.flags.String("schema", "", "json schema to parse the input with")
+		offset     = c.flags.String("offset", "0", "offset to start parsing at")
+	)
+
+	args, buf, err := c.load(args, func(string) bool {
+
+		return false
+
+	})
+	if err != nil {
+
+		return err
+
+	}
+
+	if len(args) != 0 || *schemaFile == "" {
+
+		return errUsage
+
+	}
+
+	off, err := parseNumber(*offset)
+	if err != nil {
+
+		return err
+
+	}
+
+	data, err := ioutil.ReadFile(*schemaFile)
+	if err != nil {
+
+		return err
+
+	}
+
+	s, err := crunch.ParseSchema(data)
+	if err != nil {
+
+		return err
+
+	}
+
+	root, err := s.Parse(buf, off)
+	if err != nil {
+
+		return err
+
+	}
+
+	out, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+
+		return err
+
+	}
+
+	_, err = fmt.Fprintf(c.stdout, "%s\n", out)
+	return err
+
+}
+
+/* argument parsing */
+
+// intKind describes an integer type that can be read or written
+type intKind struct {
+	size   int64
+	signed bool
+	little bool
+}
+
+// intKinds maps the names of integer types to their descriptions
+var intKinds = map[string]intKind{
+	"u8":    {1, false, false},
+	"u16le": {2, false, true},
+	"u16be": {2, false, false},
+	"u32le": {4, false, true},
+	"u32be": {4, false, false},
+	"u64le": {8, false, true},
+	"u64be": {8, false, false},
+	"s8":    {1, true, false},
+	"s16le": {2, true, true},
+	"s16be": {2, true, false},
+	"s32le": {4, true, true},
+	"s32be": {4, true, false},
+	"s64le": {8, true, true},
+	"s64be": {8, true, false},
+}
+
+// read reads an integer of the kind located at off in buf
+func (k intKind) read(buf *crunch.Buffer, off int64) uint64 {
+
+	switch {
+
+	case k.size == 1:
+		return uint64(buf.ReadByte(off))
+
+	case k.size == 2 && k.little:
+		return uint64(buf.ReadU16LE(off, 1)[0])
+
+	case k.size == 2:
+		return uint64(buf.ReadU16BE(off, 1)[0])
+
+	case k.size == 4 && k.little:
+		return uint64(buf.ReadU32LE(off, 1)[0])
+
+	case k.size == 4:
+		return uint64(buf.ReadU32BE(off, 1)[0])
+
+	case k.little:
+		return buf.ReadU64LE(off, 1)[0]
+
+	}
+	return buf.ReadU64BE(off, 1)[0]
+
+}
+
+// write writes an integer of the kind to off in buf
+func (k intKind) write(buf *crunch.Buffer, off int64, v uint64) {
+
+	switch {
+
+	case k.size == 1:
+		buf.WriteByte(off, byte(v))
+
+	case k.size == 2 && k.little:
+		buf.WriteU16LE(off, []uint16{uint16(v)})
+
+	case k.size == 2:
+		buf.WriteU16BE(off, []uint16{uint16(v)})
+
+	case k.size == 4 && k.little:
+		buf.WriteU32LE(off, []uint32{uint32(v)})
+
+	case k.size == 4:
+		buf.WriteU32BE(off, []uint32{uint32(v)})
+
+	case k.little:
+		buf.WriteU64LE(off, []uint64{v})
+
+	default:
+		buf.WriteU64BE(off, []uint64{v})
+
+	}
+
+}
+
+// extend sign-extends v from the size of the kind
+func (k intKind) extend(v uint64) int64 {
+
+	shift := uint64(64 - k.size*8)
+	return int64(v<<shift) >> shift
+
+}
+
+// parse parses a value that fits in the kind
+func (k intKind) parse(s string) (uint64, error) {
+
+	if k.signed {
+
+		v, err := strconv.ParseInt(withBase(s), 0, int(k.size*8))
+		return uint64(v), err
+
+	}
+	return strconv.ParseUint(withBase(s), 0, int(k.size*8))
+
+}
+
+// isSpec reports whether arg looks like a type@offset specification
+func isSpec(arg string) bool {
+
+	return strings.Contains(arg, "@")
+
+}
+
+// parseSpec parses a type@offset specification
+func parseSpec(arg string) (kind intKind, off int64, err error) {
+
+	i := strings.IndexByte(arg, '@')
+	if i < 0 {
+
+		return kind, 0, fmt.Errorf("missing offset in %q", arg)
+
+	}
+
+	kind, ok := intKinds[strings.ToLower(arg[:i])]
+	if !ok {
+
+		return kind, 0, fmt.Errorf("unknown type in %q", arg)
+
+	}
+
+	off, err = parseNumber(arg[i+1:])
+	return
+
+}
+
+// isRange reports whether arg looks like a range
+func isRange(arg string) bool {
+
+	_, _, err := parseRange(arg)
+	return err == nil
+
+}
+
+// parseRange parses a range written as start:end or start+length
+func parseRange(arg string) (start, end int64, err error) {
+
+	sep := strings.IndexAny(arg, ":+")
+	if sep < 0 {
+
+		return 0, 0, fmt.Errorf("invalid range %q", arg)
+
+	}
+
+	if start, err = parseNumber(arg[:sep]); err != nil {
+
+		return
+
+	}
+
+	if end, err = parseNumber(arg[sep+1:]); err != nil {
+
+		return
+
+	}
+
+	if arg[sep] == '+' {
+
+		end += start
+
+	}
+
+	if start < 0 || end < start {
+
+		return 0, 0, fmt.Errorf("invalid range %q", arg)
+
+	}
+	return
+
+}
+
+// withBase rewrites the 0b and 0o prefixes of s, which strconv only
+// understands from go 1.13 onwards, so that s can be parsed with a
+// base of 0. the 0x and 0 prefixes are left as they are
+func withBase(s string) string {
+
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+
+		sign, s = s[:1], s[1:]
+
+	}
+
+	if len(s) < 3 || s[0] != '0' || s[2] == '-' || s[2] == '+' {
+
+		return sign + s
+
+	}
+
+	var base int
+	switch s[1] {
+
+	case 'b', 'B':
+		base = 2
+
+	case 'o', 'O':
+		base = 8
+
+	default:
+		return sign + s
+
+	}
+
+	// the digits are checked here and converted to hexadecimal, which
+	// every supported version of strconv accepts with a base of 0
+	v, err := strconv.ParseUint(s[2:], base, 64)
+	if err != nil {
+
+		return sign + s
+
+	}
+	return sign + "0x" + strconv.FormatUint(v, 16)
+
+}
+
+// parseNumber parses a number in decimal, hexadecimal, octal or binary
+// written with the prefixes go uses
+func parseNumber(s string) (int64, error) {
+
+	n, err := strconv.ParseInt(withBase(s), 0, 64)
+	if err != nil {
+
+		return 0, fmt.Errorf("invalid number %q", s)
+
+	}
+	return n, nil
+
+}
+
+/* output */
+
+// hexdump writes data to w in the canonical hex and ascii format,
+// labeling each line with its offset in the input
+func hexdump(w io.Writer, data []byte, base int64) {
+
+	for line := 0; line < len(data); line += 16 {
+
+		var out strings.Builder
+		fmt.Fprintf(&out, "%08x ", base+int64(line))
+
+		for i := line; i < line+16; i++ {
+
+			if i%8 == 0 {
+
+				out.WriteByte(' ')
+
+			}
+
+			if i < len(data) {
+
+				fmt.Fprintf(&out, "%02x ", data[i])
+
+			} else {
+
+				out.WriteString("   ")
+
+			}
+
+		}
+
+		out.WriteString(" |")
+		for i := line; i < line+16 && i < len(data); i++ {
+
+			if c := data[i]; c >= 0x20 && c < 0x7F {
+
+				out.WriteByte(c)
+
+			} else {
+
+				out.WriteByte('.')
+
+			}
+
+		}
+		out.WriteString("|\n")
+
+		io.WriteString(w, out.String())
+
+	}
+
+}
@@ -0,0 +1,191 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/superwhiskers/crunch"
+)
+
+/*
+
+utilities
+
+*/
+
+// testInput is fed to every command through standard input
+var testInput = []byte{
+	0x00, 0x01, 0x02, 0x03, 0xDE, 0xAD, 0xBE, 0xEF,
+	0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F,
+	'c', 'r', 'u', 'n', 'c', 'h', 0xDE, 0xAD,
+}
+
+// runWith runs the command described by args against testInput
+func runWith(args ...string) (string, error) {
+
+	out := &bytes.Buffer{}
+	err := run(args, bytes.NewReader(testInput), out, ioutil.Discard)
+	return out.String(), err
+
+}
+
+/*
+
+tests
+
+*/
+
+func TestRun(t *testing.T) {
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"read", "u32be@4", "u16le@0x02", "s8@0x04"}, "3735928559\n770\n-34\n"},
+		{[]string{"read", "-x", "u16be@0x04", "-"}, "0xdead\n"},
+		{[]string{"bits", "0x20+12"}, "110111101010\t3562\n"},
+		{[]string{"bits", "0x20:0x2c"}, "110111101010\t3562\n"},
+		{[]string{"read", "u8@0b100", "u16be@0o4"}, "222\n57005\n"},
+		{[]string{"find", "de ad"}, "0x4\n0x16\n"},
+		{[]string{"find", "-s", "crunch"}, "0x10\n"},
+		{[]string{"find", "d? ?? be"}, "0x4\n"},
+		{[]string{"dump", "0x10:0x18"}, "00000010  63 72 75 6e 63 68 de ad                           |crunch..|\n"},
+	}
+
+	for _, test := range tests {
+
+		out, err := runWith(test.args...)
+		if err != nil {
+
+			t.Fatalf("failed to run %v: %v", test.args, err)
+
+		}
+
+		if out != test.expected {
+
+			t.Fatalf("expected output of %v does not match the one gotten (got %q, expected %q)", test.args, out, test.expected)
+
+		}
+
+	}
+
+}
+
+func TestRunWrite(t *testing.T) {
+
+	out, err := runWith("write", "u16be@0x00=0xBEEF", "s8@0x02=-1")
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	expected := append([]byte{0xBE, 0xEF, 0xFF}, testInput[3:]...)
+	if !bytes.Equal([]byte(out), expected) {
+
+		t.Fatalf("expected output does not match the one gotten (got %#v, expected %#v)", []byte(out), expected)
+
+	}
+
+	dir, err := ioutil.TempDir("", "crunch")
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "firmware.bin")
+	if err = ioutil.WriteFile(file, testInput, 0644); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if _, err = runWith("write", "-i", "u32le@0x04=0x01020304", file); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	data, _ := ioutil.ReadFile(file)
+	if !bytes.Equal(data[4:8], []byte{0x04, 0x03, 0x02, 0x01}) {
+
+		t.Fatalf("expected file to be patched in place (got %#v)", data)
+
+	}
+
+}
+
+func TestRunParse(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "crunch")
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+	defer os.RemoveAll(dir)
+
+	schema := filepath.Join(dir, "schema.json")
+	if err = ioutil.WriteFile(schema, []byte(`{"seq": [{"id": "name", "type": "str", "size": 6}, {"id": "tail", "type": "u2be"}]}`), 0644); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	out, err := runWith("parse", "-schema", schema, "-offset", "0x10")
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	expected := "{\n  \"name\": \"crunch\",\n  \"tail\": 57005\n}\n"
+	if out != expected {
+
+		t.Fatalf("expected output does not match the one gotten (got %q, expected %q)", out, expected)
+
+	}
+
+}
+
+func TestRunErrors(t *testing.T) {
+
+	if _, err := runWith("read", "u32be@0x16"); !crunch.BufferOverreadError.Is(err) {
+
+		t.Fatalf("expected an overread error (got %v)", err)
+
+	}
+
+	if _, err := runWith("write", "u8@0x00=0x100"); err == nil {
+
+		t.Fatalf("expected an out of range value to be rejected")
+
+	}
+
+	for _, args := range [][]string{{}, {"nope"}, {"read"}, {"write", "-i", "u8@0=1"}} {
+
+		if _, err := runWith(args...); err != errUsage {
+
+			t.Fatalf("expected %v to be rejected (got %v)", args, err)
+
+		}
+
+	}
+
+}
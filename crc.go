@@ -0,0 +1,368 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// CRCParams implements the parameters of a crc algorithm in the
+// rocksoft model. Check holds the crc of the ascii string
+// "123456789" and is only used for reference
+type CRCParams struct {
+	Name   string
+	Width  uint
+	Poly   uint64
+	Init   uint64
+	RefIn  bool
+	RefOut bool
+	XorOut uint64
+	Check  uint64
+}
+
+// catalogue of commonly used crc algorithms, taken from the crc
+// reveng catalogue
+var (
+	CRC3GSM         = CRCParams{"CRC-3/GSM", 3, 0x3, 0x0, false, false, 0x7, 0x4}
+	CRC5USB         = CRCParams{"CRC-5/USB", 5, 0x05, 0x1F, true, true, 0x1F, 0x19}
+	CRC5EPC         = CRCParams{"CRC-5/EPC-C1G2", 5, 0x09, 0x09, false, false, 0x00, 0x00}
+	CRC7MMC         = CRCParams{"CRC-7/MMC", 7, 0x09, 0x00, false, false, 0x00, 0x75}
+	CRC8SMBus       = CRCParams{"CRC-8/SMBUS", 8, 0x07, 0x00, false, false, 0x00, 0xF4}
+	CRC8Maxim       = CRCParams{"CRC-8/MAXIM-DOW", 8, 0x31, 0x00, true, true, 0x00, 0xA1}
+	CRC8AUTOSAR     = CRCParams{"CRC-8/AUTOSAR", 8, 0x2F, 0xFF, false, false, 0xFF, 0xDF}
+	CRC10ATM        = CRCParams{"CRC-10/ATM", 10, 0x233, 0x000, false, false, 0x000, 0x199}
+	CRC11FlexRay    = CRCParams{"CRC-11/FLEXRAY", 11, 0x385, 0x01A, false, false, 0x000, 0x5A3}
+	CRC12DECT       = CRCParams{"CRC-12/DECT", 12, 0x80F, 0x000, false, false, 0x000, 0xF5B}
+	CRC15CAN        = CRCParams{"CRC-15/CAN", 15, 0x4599, 0x0000, false, false, 0x0000, 0x059E}
+	CRC16ARC        = CRCParams{"CRC-16/ARC", 16, 0x8005, 0x0000, true, true, 0x0000, 0xBB3D}
+	CRC16CCITTFalse = CRCParams{"CRC-16/CCITT-FALSE", 16, 0x1021, 0xFFFF, false, false, 0x0000, 0x29B1}
+	CRC16Kermit     = CRCParams{"CRC-16/KERMIT", 16, 0x1021, 0x0000, true, true, 0x0000, 0x2189}
+	CRC16XModem     = CRCParams{"CRC-16/XMODEM", 16, 0x1021, 0x0000, false, false, 0x0000, 0x31C3}
+	CRC16Modbus     = CRCParams{"CRC-16/MODBUS", 16, 0x8005, 0xFFFF, true, true, 0x0000, 0x4B37}
+	CRC16X25        = CRCParams{"CRC-16/X-25", 16, 0x1021, 0xFFFF, true, true, 0xFFFF, 0x906E}
+	CRC24OpenPGP    = CRCParams{"CRC-24/OPENPGP", 24, 0x864CFB, 0xB704CE, false, false, 0x000000, 0x21CF02}
+	CRC32           = CRCParams{"CRC-32", 32, 0x04C11DB7, 0xFFFFFFFF, true, true, 0xFFFFFFFF, 0xCBF43926}
+	CRC32C          = CRCParams{"CRC-32C", 32, 0x1EDC6F41, 0xFFFFFFFF, true, true, 0xFFFFFFFF, 0xE3069283}
+	CRC32BZIP2      = CRCParams{"CRC-32/BZIP2", 32, 0x04C11DB7, 0xFFFFFFFF, false, false, 0xFFFFFFFF, 0xFC891918}
+	CRC32MPEG2      = CRCParams{"CRC-32/MPEG-2", 32, 0x04C11DB7, 0xFFFFFFFF, false, false, 0x00000000, 0x0376E6E7}
+	CRC64ECMA       = CRCParams{"CRC-64/ECMA-182", 64, 0x42F0E1EBA9EA3693, 0x0, false, false, 0x0, 0x6C40DF5F0B497347}
+	CRC64XZ         = CRCParams{"CRC-64/XZ", 64, 0x42F0E1EBA9EA3693, 0xFFFFFFFFFFFFFFFF, true, true, 0xFFFFFFFFFFFFFFFF, 0x995DC9BBDF1939FA}
+	CRC64GoISO      = CRCParams{"CRC-64/GO-ISO", 64, 0x1B, 0xFFFFFFFFFFFFFFFF, true, true, 0xFFFFFFFFFFFFFFFF, 0xB90956C775A41001}
+)
+
+var (
+	crcCatalogue = map[string]CRCParams{}
+
+	// engines are cached per parameter set so that the tables only
+	// need to be built once
+	crcEngines sync.Map
+)
+
+func init() {
+
+	for _, params := range []CRCParams{
+		CRC3GSM, CRC5USB, CRC5EPC, CRC7MMC, CRC8SMBus, CRC8Maxim,
+		CRC8AUTOSAR, CRC10ATM, CRC11FlexRay, CRC12DECT, CRC15CAN,
+		CRC16ARC, CRC16CCITTFalse, CRC16Kermit, CRC16XModem,
+		CRC16Modbus, CRC16X25, CRC24OpenPGP, CRC32, CRC32C,
+		CRC32BZIP2, CRC32MPEG2, CRC64ECMA, CRC64XZ, CRC64GoISO,
+	} {
+
+		crcCatalogue[params.Name] = params
+
+	}
+
+	// common aliases
+	crcCatalogue["CRC-16/CCITT"] = CRC16CCITTFalse
+	crcCatalogue["CRC-32/ISO-HDLC"] = CRC32
+	crcCatalogue["CRC-32/ISCSI"] = CRC32C
+
+}
+
+// LookupCRCParams returns the parameters of the catalogued crc
+// algorithm with the provided name
+func LookupCRCParams(name string) (params CRCParams, ok bool) {
+
+	params, ok = crcCatalogue[name]
+	return
+
+}
+
+// CRC implements a table-driven crc engine for a single parameter set
+type CRC struct {
+	params CRCParams
+	mask   uint64
+	table  [256]uint64
+}
+
+// NewCRC initializes a new CRC engine with the provided parameters
+func NewCRC(params CRCParams) (c *CRC) {
+
+	if params.Width < 1 || params.Width > 64 {
+
+		panic(CRCInvalidWidthError)
+
+	}
+
+	c = &CRC{
+		params: params,
+		mask:   ^uint64(0) >> (64 - params.Width),
+	}
+
+	var (
+		i = 0
+		j = 0
+		r uint64
+	)
+	if params.RefIn {
+
+		// reflected engines keep the register lsb-aligned
+		poly := reflectBits(params.Poly, params.Width)
+		for i = 0; i < 256; i++ {
+
+			r = uint64(i)
+			for j = 0; j < 8; j++ {
+
+				if r&1 == 1 {
+
+					r = (r >> 1) ^ poly
+
+				} else {
+
+					r >>= 1
+
+				}
+
+			}
+			c.table[i] = r
+
+		}
+
+	} else {
+
+		// normal engines keep the register msb-aligned in 64 bits,
+		// which lets the table handle widths below 8 as well
+		poly := params.Poly << (64 - params.Width)
+		for i = 0; i < 256; i++ {
+
+			r = uint64(i) << 56
+			for j = 0; j < 8; j++ {
+
+				if r&(1<<63) != 0 {
+
+					r = (r << 1) ^ poly
+
+				} else {
+
+					r <<= 1
+
+				}
+
+			}
+			c.table[i] = r
+
+		}
+
+	}
+
+	return
+
+}
+
+// Params returns the parameters the engine was initialized with
+func (c *CRC) Params() CRCParams {
+
+	return c.params
+
+}
+
+// Checksum returns the crc of data
+func (c *CRC) Checksum(data []byte) (r uint64) {
+
+	shift := 64 - c.params.Width
+
+	if c.params.RefIn {
+
+		r = reflectBits(c.params.Init&c.mask, c.params.Width)
+		for _, d := range data {
+
+			r = (r >> 8) ^ c.table[byte(r)^d]
+
+		}
+
+		if !c.params.RefOut {
+
+			r = reflectBits(r, c.params.Width)
+
+		}
+
+	} else {
+
+		r = (c.params.Init & c.mask) << shift
+		for _, d := range data {
+
+			r = (r << 8) ^ c.table[byte(r>>56)^d]
+
+		}
+
+		r >>= shift
+		if c.params.RefOut {
+
+			r = reflectBits(r, c.params.Width)
+
+		}
+
+	}
+
+	return (r ^ c.params.XorOut) & c.mask
+
+}
+
+// ChecksumBits returns the crc of n bits located at the specified
+// bit offset of the buffer using a bitwise engine. the bits are fed
+// msb first, or lsb first within each group of 8 bits when RefIn is
+// set, so that byte-aligned data gives the same result as Checksum. a
+// trailing group of fewer than 8 bits is reflected on its own
+func (c *CRC) ChecksumBits(b *Buffer, off, n int64) uint64 {
+
+	if n < 0 {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+
+	var (
+		top = uint64(1) << (c.params.Width - 1)
+		r   = c.params.Init & c.mask
+
+		i   = int64(0)
+		pos = int64(0)
+	)
+	for i = 0; i < n; i++ {
+
+		pos = i
+		if c.params.RefIn {
+
+			group := n - (i - i%8)
+			if group > 8 {
+
+				group = 8
+
+			}
+			pos = i - i%8 + group - 1 - i%8
+
+		}
+
+		msb := r&top != 0
+		r = (r << 1) & c.mask
+		if msb != (b.ReadBit(off+pos) == 1) {
+
+			r ^= c.params.Poly & c.mask
+
+		}
+
+	}
+
+	if c.params.RefOut {
+
+		r = reflectBits(r, c.params.Width)
+
+	}
+
+	return (r ^ c.params.XorOut) & c.mask
+
+}
+
+// crcEngine returns the cached engine for params
+func crcEngine(params CRCParams) *CRC {
+
+	if c, ok := crcEngines.Load(params); ok {
+
+		return c.(*CRC)
+
+	}
+
+	c, _ := crcEngines.LoadOrStore(params, NewCRC(params))
+	return c.(*CRC)
+
+}
+
+// reflectBits reverses the order of the low width bits of v
+func reflectBits(v uint64, width uint) uint64 {
+
+	return bits.Reverse64(v) >> (64 - width)
+
+}
+
+// CRC returns the crc of the n bytes located at the specified offset
+// without modifying the internal offset value
+func (b *Buffer) CRC(off, n int64, params CRCParams) uint64 {
+
+	return crcEngine(params).Checksum(b.ReadBytes(off, n))
+
+}
+
+// CRCNext returns the crc of the next n bytes from the current offset
+// and moves the offset forward the amount of bytes read
+func (b *Buffer) CRCNext(n int64, params CRCParams) (out uint64) {
+
+	out = b.CRC(b.off, n, params)
+	b.SeekByte(n, true)
+	return
+
+}
+
+// CRCBits returns the crc of the n bits located at the specified bit
+// offset without modifying the internal offset value
+func (b *Buffer) CRCBits(off, n int64, params CRCParams) uint64 {
+
+	if n > 0 && off+n > b.bcap {
+
+		panic(BufferOverreadError.atBit(off, n, b.bcap))
+
+	}
+
+	return crcEngine(params).ChecksumBits(b, off, n)
+
+}
+
+// CRCBitsNext returns the crc of the next n bits from the current bit
+// offset and moves the bit offset forward the amount of bits read
+func (b *Buffer) CRCBitsNext(n int64, params CRCParams) (out uint64) {
+
+	out = b.CRCBits(b.boff, n, params)
+	b.SeekBit(n, true)
+	return
+
+}
+
+// PutCRC computes the crc of the n bytes located at the specified
+// offset and writes it to the offset at in big-endian, using as many
+// bytes as the width of the crc requires
+func (b *Buffer) PutCRC(off, n, at int64, params CRCParams) {
+
+	var (
+		sum   = b.CRC(off, n, params)
+		width = int64(params.Width+7) / 8
+		out   = make([]byte, width)
+	)
+
+	for i := int64(0); i < width; i++ {
+
+		out[i] = byte(sum >> uint(8*(width-i-1)))
+
+	}
+
+	b.WriteBytes(at, out)
+
+}
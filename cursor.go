@@ -0,0 +1,637 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import "time"
+
+// Cursor implements an independent pair of byte and bit offsets over
+// a Buffer. any number of cursors may share a buffer, each moving on
+// its own while reading and writing the same storage with the same
+// bounds checks and recording their accesses to the buffer's Tracer.
+// the buffer's own offsets are never touched
+type Cursor struct {
+	b    *Buffer
+	off  int64
+	boff int64
+}
+
+// Cursor returns a new Cursor over the buffer positioned at the
+// specified byte offset, with the bit offset aligned to it
+func (b *Buffer) Cursor(off int64) *Cursor {
+
+	return &Cursor{
+		b:    b,
+		off:  off,
+		boff: off * 8,
+	}
+
+}
+
+// Clone returns a new Cursor positioned at the same offsets, which is
+// useful for following a pointer without losing one's place
+func (c *Cursor) Clone() *Cursor {
+
+	return &Cursor{
+		b:    c.b,
+		off:  c.off,
+		boff: c.boff,
+	}
+
+}
+
+// Buffer returns the Buffer the cursor moves over
+func (c *Cursor) Buffer() *Buffer {
+
+	return c.b
+
+}
+
+/* bitfield methods */
+
+// ReadBitNext returns the next bit from the current offset and moves
+// the offset forward a bit
+func (c *Cursor) ReadBitNext() (out byte) {
+
+	out = c.b.ReadBit(c.boff)
+	c.b.traced(c.boff, 1)
+	c.SeekBit(1, true)
+	return
+
+}
+
+// ReadBitsNext returns the next n bits from the current offset and
+// moves the offset forward the amount of bits read
+func (c *Cursor) ReadBitsNext(n int64) (out uint64) {
+
+	out = c.b.ReadBits(c.boff, n)
+	c.b.traced(c.boff, n)
+	c.SeekBit(n, true)
+	return
+
+}
+
+// ReadBitsSignedNext returns the next n bits from the current offset
+// as a two's complement signed integer and moves the offset forward
+// the amount of bits read
+func (c *Cursor) ReadBitsSignedNext(n int64) (out int64) {
+
+	out = c.b.ReadBitsSigned(c.boff, n)
+	c.b.traced(c.boff, n)
+	c.SeekBit(n, true)
+	return
+
+}
+
+// ReadBitsBytesNext returns the next n bits from the current offset
+// packed into bytes and moves the offset forward the amount of bits
+// read
+func (c *Cursor) ReadBitsBytesNext(n int64) (out []byte) {
+
+	out = c.b.ReadBitsBytes(c.boff, n)
+	c.b.traced(c.boff, n)
+	c.SeekBit(n, true)
+	return
+
+}
+
+// SetBitNext sets the next bit from the current offset and moves the
+// offset forward a bit
+func (c *Cursor) SetBitNext() {
+
+	c.b.SetBit(c.boff)
+	c.b.traced(c.boff, 1)
+	c.SeekBit(1, true)
+
+}
+
+// ClearBitNext clears the next bit from the current offset and moves
+// the offset forward a bit
+func (c *Cursor) ClearBitNext() {
+
+	c.b.ClearBit(c.boff)
+	c.b.traced(c.boff, 1)
+	c.SeekBit(1, true)
+
+}
+
+// SetBitsNext sets the next n bits from the current offset and moves
+// the offset forward the amount of bits set
+func (c *Cursor) SetBitsNext(data uint64, n int64) {
+
+	c.b.SetBits(c.boff, data, n)
+	c.b.traced(c.boff, n)
+	c.SeekBit(n, true)
+
+}
+
+// SetBitsBytesNext sets the next n bits from the current offset to
+// the first n bits of data and moves the offset forward the amount of
+// bits set
+func (c *Cursor) SetBitsBytesNext(data []byte, n int64) {
+
+	c.b.SetBitsBytes(c.boff, data, n)
+	c.b.traced(c.boff, n)
+	c.SeekBit(n, true)
+
+}
+
+// FlipBitNext flips the next bit from the current offset and moves
+// the offset forward a bit
+func (c *Cursor) FlipBitNext() {
+
+	c.b.FlipBit(c.boff)
+	c.b.traced(c.boff, 1)
+	c.SeekBit(1, true)
+
+}
+
+// SeekBit seeks to bit position off of the the buffer relative to
+// the current position or exact
+func (c *Cursor) SeekBit(off int64, relative bool) {
+
+	if relative {
+
+		c.boff += off
+
+	} else {
+
+		c.boff = off
+
+	}
+
+}
+
+// AfterBit returns the amount of bits located after the current bit
+// position
+func (c *Cursor) AfterBit() int64 {
+
+	return c.b.bcap - c.boff - 1
+
+}
+
+// AlignBit aligns the bit offset to the byte offset
+func (c *Cursor) AlignBit() {
+
+	c.boff = c.off * 8
+
+}
+
+/* byte buffer methods */
+
+// WriteBytesNext writes bytes to the buffer at the current offset
+// and moves the offset forward the amount of bytes written
+func (c *Cursor) WriteBytesNext(data []byte) {
+
+	c.b.WriteBytes(c.off, data)
+	c.b.traced(c.off*8, int64(len(data))*8)
+	c.SeekByte(int64(len(data)), true)
+
+}
+
+// WriteByteNext writes a byte to the buffer at the current
+// offset and moves the offset forward the amount of bytes written
+func (c *Cursor) WriteByteNext(data byte) {
+
+	c.b.WriteBytes(c.off, []byte{data})
+	c.b.traced(c.off*8, 8)
+	c.SeekByte(1, true)
+
+}
+
+// WriteU16LENext writes a slice of uint16s to the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes written
+func (c *Cursor) WriteU16LENext(data []uint16) {
+
+	c.b.WriteU16LE(c.off, data)
+	c.b.traced(c.off*8, int64(len(data))*16)
+	c.SeekByte(int64(len(data))*2, true)
+
+}
+
+// WriteU16BENext writes a slice of uint16s to the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes written
+func (c *Cursor) WriteU16BENext(data []uint16) {
+
+	c.b.WriteU16BE(c.off, data)
+	c.b.traced(c.off*8, int64(len(data))*16)
+	c.SeekByte(int64(len(data))*2, true)
+
+}
+
+// WriteU32LENext writes a slice of uint32s to the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes written
+func (c *Cursor) WriteU32LENext(data []uint32) {
+
+	c.b.WriteU32LE(c.off, data)
+	c.b.traced(c.off*8, int64(len(data))*32)
+	c.SeekByte(int64(len(data))*4, true)
+
+}
+
+// WriteU32BENext writes a slice of uint32s to the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes written
+func (c *Cursor) WriteU32BENext(data []uint32) {
+
+	c.b.WriteU32BE(c.off, data)
+	c.b.traced(c.off*8, int64(len(data))*32)
+	c.SeekByte(int64(len(data))*4, true)
+
+}
+
+// WriteU64LENext writes a slice of uint64s to the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes written
+func (c *Cursor) WriteU64LENext(data []uint64) {
+
+	c.b.WriteU64LE(c.off, data)
+	c.b.traced(c.off*8, int64(len(data))*64)
+	c.SeekByte(int64(len(data))*8, true)
+
+}
+
+// WriteU64BENext writes a slice of uint64s to the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes written
+func (c *Cursor) WriteU64BENext(data []uint64) {
+
+	c.b.WriteU64BE(c.off, data)
+	c.b.traced(c.off*8, int64(len(data))*64)
+	c.SeekByte(int64(len(data))*8, true)
+
+}
+
+// ReadBytesNext returns the next n bytes from the current offset
+// and moves the offset forward the amount of bytes read
+func (c *Cursor) ReadBytesNext(n int64) (out []byte) {
+
+	out = c.b.ReadBytes(c.off, n)
+	c.b.traced(c.off*8, n*8)
+	c.SeekByte(n, true)
+	return
+
+}
+
+// ReadByteNext returns the next byte from the current offset and
+// moves the offset forward a byte
+func (c *Cursor) ReadByteNext() (out byte) {
+
+	out = c.b.ReadBytes(c.off, 1)[0]
+	c.b.traced(c.off*8, 8)
+	c.SeekByte(1, true)
+	return
+
+}
+
+// ReadU16LENext reads a slice of uint16s from the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes read
+func (c *Cursor) ReadU16LENext(n int64) (out []uint16) {
+
+	out = c.b.ReadU16LE(c.off, n)
+	c.b.traced(c.off*8, n*16)
+	c.SeekByte(n*2, true)
+	return
+
+}
+
+// ReadU16BENext reads a slice of uint16s from the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes read
+func (c *Cursor) ReadU16BENext(n int64) (out []uint16) {
+
+	out = c.b.ReadU16BE(c.off, n)
+	c.b.traced(c.off*8, n*16)
+	c.SeekByte(n*2, true)
+	return
+
+}
+
+// ReadU32LENext reads a slice of uint32s from the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes read
+func (c *Cursor) ReadU32LENext(n int64) (out []uint32) {
+
+	out = c.b.ReadU32LE(c.off, n)
+	c.b.traced(c.off*8, n*32)
+	c.SeekByte(n*4, true)
+	return
+
+}
+
+// ReadU32BENext reads a slice of uint32s from the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes read
+func (c *Cursor) ReadU32BENext(n int64) (out []uint32) {
+
+	out = c.b.ReadU32BE(c.off, n)
+	c.b.traced(c.off*8, n*32)
+	c.SeekByte(n*4, true)
+	return
+
+}
+
+// ReadU64LENext reads a slice of uint64s from the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes read
+func (c *Cursor) ReadU64LENext(n int64) (out []uint64) {
+
+	out = c.b.ReadU64LE(c.off, n)
+	c.b.traced(c.off*8, n*64)
+	c.SeekByte(n*8, true)
+	return
+
+}
+
+// ReadU64BENext reads a slice of uint64s from the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes read
+func (c *Cursor) ReadU64BENext(n int64) (out []uint64) {
+
+	out = c.b.ReadU64BE(c.off, n)
+	c.b.traced(c.off*8, n*64)
+	c.SeekByte(n*8, true)
+	return
+
+}
+
+// SeekByte seeks to position off of the buffer relative to the
+// current position or exact
+func (c *Cursor) SeekByte(off int64, relative bool) {
+
+	if relative {
+
+		c.off += off
+
+	} else {
+
+		c.off = off
+
+	}
+
+}
+
+// AfterByte returns the amount of bytes located after the current
+// position
+func (c *Cursor) AfterByte() int64 {
+
+	return c.b.cap - c.off - 1
+
+}
+
+// AlignByte aligns the byte offset to the bit offset
+func (c *Cursor) AlignByte() {
+
+	c.off = c.boff / 8
+
+}
+
+/* packed integer methods */
+
+// ReadPackedUintsNext reads count unsigned integers of the specified
+// width in bits stored with layout at the current offset and moves the
+// offset forward the amount of bytes they take up
+func (c *Cursor) ReadPackedUintsNext(width, count int64, layout PackedLayout) (out []uint64) {
+
+	size := PackedSize(width, count, layout)
+	out = c.b.ReadPackedUints(c.off, width, count, layout)
+	c.b.traced(c.off*8, size*8)
+	c.SeekByte(size, true)
+	return
+
+}
+
+// WritePackedUintsNext writes data as unsigned integers of the
+// specified width in bits stored with layout at the current offset and
+// moves the offset forward the amount of bytes they take up
+func (c *Cursor) WritePackedUintsNext(width int64, data []uint64, layout PackedLayout) {
+
+	size := PackedSize(width, int64(len(data)), layout)
+	c.b.WritePackedUints(c.off, width, data, layout)
+	c.b.traced(c.off*8, size*8)
+	c.SeekByte(size, true)
+
+}
+
+/* fixed-point methods */
+
+// ReadFixedLENext reads a byte-aligned fixed-point number in the
+// format q from the buffer at the current offset in little-endian and
+// moves the offset forward the amount of bytes read
+func (c *Cursor) ReadFixedLENext(q QFormat) (out float64) {
+
+	out = c.b.ReadFixedLE(c.off, q)
+	c.b.traced(c.off*8, q.Width)
+	c.SeekByte(q.bytes(), true)
+	return
+
+}
+
+// ReadFixedBENext reads a byte-aligned fixed-point number in the
+// format q from the buffer at the current offset in big-endian and
+// moves the offset forward the amount of bytes read
+func (c *Cursor) ReadFixedBENext(q QFormat) (out float64) {
+
+	out = c.b.ReadFixedBE(c.off, q)
+	c.b.traced(c.off*8, q.Width)
+	c.SeekByte(q.bytes(), true)
+	return
+
+}
+
+// WriteFixedLENext writes v as a byte-aligned fixed-point number in
+// the format q to the buffer at the current offset in little-endian
+// and moves the offset forward the amount of bytes written
+func (c *Cursor) WriteFixedLENext(q QFormat, v float64) {
+
+	c.b.WriteFixedLE(c.off, q, v)
+	c.b.traced(c.off*8, q.Width)
+	c.SeekByte(q.bytes(), true)
+
+}
+
+// WriteFixedBENext writes v as a byte-aligned fixed-point number in
+// the format q to the buffer at the current offset in big-endian and
+// moves the offset forward the amount of bytes written
+func (c *Cursor) WriteFixedBENext(q QFormat, v float64) {
+
+	c.b.WriteFixedBE(c.off, q, v)
+	c.b.traced(c.off*8, q.Width)
+	c.SeekByte(q.bytes(), true)
+
+}
+
+// ReadFixedBitsNext reads a fixed-point number in the format q stored
+// in the next Width bits from the current bit offset and moves the bit
+// offset forward the amount of bits read
+func (c *Cursor) ReadFixedBitsNext(q QFormat) (out float64) {
+
+	out = c.b.ReadFixedBits(c.boff, q)
+	c.b.traced(c.boff, q.Width)
+	c.SeekBit(q.Width, true)
+	return
+
+}
+
+// SetFixedBitsNext stores v as a fixed-point number in the format q in
+// the next Width bits from the current bit offset and moves the bit
+// offset forward the amount of bits set
+func (c *Cursor) SetFixedBitsNext(q QFormat, v float64) {
+
+	c.b.SetFixedBits(c.boff, q, v)
+	c.b.traced(c.boff, q.Width)
+	c.SeekBit(q.Width, true)
+
+}
+
+/* bcd methods */
+
+// ReadBCDNext reads n bytes of packed bcd from the buffer at the
+// current offset and moves the offset forward the amount of bytes
+// read if no error occurs
+func (c *Cursor) ReadBCDNext(n int64) (out uint64, err error) {
+
+	if out, err = c.b.ReadBCD(c.off, n); err == nil {
+
+		c.b.traced(c.off*8, n*8)
+		c.SeekByte(n, true)
+
+	}
+	return
+
+}
+
+// WriteBCDNext writes v as n bytes of packed bcd to the buffer at the
+// current offset and moves the offset forward the amount of bytes
+// written if no error occurs
+func (c *Cursor) WriteBCDNext(n int64, v uint64) (err error) {
+
+	if err = c.b.WriteBCD(c.off, n, v); err == nil {
+
+		c.b.traced(c.off*8, n*8)
+		c.SeekByte(n, true)
+
+	}
+	return
+
+}
+
+// ReadPackedDecimalNext reads an n byte signed packed decimal number
+// from the buffer at the current offset and moves the offset forward
+// the amount of bytes read if no error occurs
+func (c *Cursor) ReadPackedDecimalNext(n int64) (out int64, err error) {
+
+	if out, err = c.b.ReadPackedDecimal(c.off, n); err == nil {
+
+		c.b.traced(c.off*8, n*8)
+		c.SeekByte(n, true)
+
+	}
+	return
+
+}
+
+// WritePackedDecimalNext writes v as an n byte signed packed decimal
+// number to the buffer at the current offset and moves the offset
+// forward the amount of bytes written if no error occurs
+func (c *Cursor) WritePackedDecimalNext(n int64, v int64) (err error) {
+
+	if err = c.b.WritePackedDecimal(c.off, n, v); err == nil {
+
+		c.b.traced(c.off*8, n*8)
+		c.SeekByte(n, true)
+
+	}
+	return
+
+}
+
+/* time methods */
+
+// ReadTimeLENext reads a timestamp of the specified kind from the
+// buffer at the current offset in little-endian and moves the offset
+// forward the amount of bytes read if no error occurs
+func (c *Cursor) ReadTimeLENext(kind TimeKind) (out time.Time, err error) {
+
+	if out, err = c.b.ReadTimeLE(c.off, kind); err == nil {
+
+		c.b.traced(c.off*8, kind.Size()*8)
+		c.SeekByte(kind.Size(), true)
+
+	}
+	return
+
+}
+
+// ReadTimeBENext reads a timestamp of the specified kind from the
+// buffer at the current offset in big-endian and moves the offset
+// forward the amount of bytes read if no error occurs
+func (c *Cursor) ReadTimeBENext(kind TimeKind) (out time.Time, err error) {
+
+	if out, err = c.b.ReadTimeBE(c.off, kind); err == nil {
+
+		c.b.traced(c.off*8, kind.Size()*8)
+		c.SeekByte(kind.Size(), true)
+
+	}
+	return
+
+}
+
+// WriteTimeLENext writes t as a timestamp of the specified kind to
+// the buffer at the current offset in little-endian and moves the
+// offset forward the amount of bytes written if no error occurs
+func (c *Cursor) WriteTimeLENext(kind TimeKind, t time.Time) (err error) {
+
+	if err = c.b.WriteTimeLE(c.off, kind, t); err == nil {
+
+		c.b.traced(c.off*8, kind.Size()*8)
+		c.SeekByte(kind.Size(), true)
+
+	}
+	return
+
+}
+
+// WriteTimeBENext writes t as a timestamp of the specified kind to
+// the buffer at the current offset in big-endian and moves the
+// offset forward the amount of bytes written if no error occurs
+func (c *Cursor) WriteTimeBENext(kind TimeKind, t time.Time) (err error) {
+
+	if err = c.b.WriteTimeBE(c.off, kind, t); err == nil {
+
+		c.b.traced(c.off*8, kind.Size()*8)
+		c.SeekByte(kind.Size(), true)
+
+	}
+	return
+
+}
+
+/* value retrieval */
+
+// ByteOffset returns the current offset of the cursor
+func (c *Cursor) ByteOffset() int64 {
+
+	return c.off
+
+}
+
+// BitOffset returns the current bit offset of the cursor
+func (c *Cursor) BitOffset() int64 {
+
+	return c.boff
+
+}
@@ -0,0 +1,182 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+tests
+
+*/
+
+func TestCursorOffsetTable(t *testing.T) {
+
+	expected := []string{"abc", "de"}
+
+	// a count followed by a table of (offset, length) pairs pointing
+	// at the strings stored after it
+	buf := NewBuffer([]byte{
+		0x02,
+		0x0B, 0x00, 0x00, 0x00, 0x03,
+		0x0E, 0x00, 0x00, 0x00, 0x02,
+	}, []byte("abcde"))
+
+	var (
+		table = buf.Cursor(0x00)
+		out   []string
+	)
+
+	count := table.ReadByteNext()
+	for i := byte(0); i < count; i++ {
+
+		var (
+			off = table.ReadU32LENext(1)[0]
+			n   = table.ReadByteNext()
+		)
+
+		out = append(out, string(buf.Cursor(int64(off)).ReadBytesNext(int64(n))))
+
+	}
+
+	if !cmp.Equal(expected, out) {
+
+		t.Fatalf("expected strings do not match the ones gotten (got %#v, expected %#v)", out, expected)
+
+	}
+
+	if table.ByteOffset() != 0x0B || buf.ByteOffset() != 0x00 {
+
+		t.Fatalf("unexpected offsets (cursor at %d, buffer at %d)", table.ByteOffset(), buf.ByteOffset())
+
+	}
+
+}
+
+func TestCursorSharedStorage(t *testing.T) {
+
+	expected := []byte{0xDE, 0xAD, 0xBE, 0xEF, 0xA0, 0x00}
+
+	buf := NewBuffer([]byte{0x00, 0x00, 0x00, 0x00})
+
+	var (
+		a = buf.Cursor(0x00)
+		b = a.Clone()
+	)
+
+	a.WriteU16BENext([]uint16{0xDEAD})
+	if out := b.ReadU16BENext(1)[0]; out != 0xDEAD {
+
+		t.Fatalf("expected uint16 does not match the one gotten (got %#x, expected %#x)", out, 0xDEAD)
+
+	}
+
+	// cursors keep working after the buffer is reallocated
+	buf.Grow(2)
+	b.WriteU16BENext([]uint16{0xBEEF})
+	b.AlignBit()
+	b.SetBitsNext(0x5, 3)
+
+	if !cmp.Equal(expected, buf.Bytes()) {
+
+		t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", buf.Bytes(), expected)
+
+	}
+
+	if b.BitOffset() != 35 || b.AfterBit() != 12 || a.AfterByte() != 3 {
+
+		t.Fatalf("unexpected cursor statistics (bit offset %d, %d bits after, %d bytes after)", b.BitOffset(), b.AfterBit(), a.AfterByte())
+
+	}
+
+}
+
+func TestCursorTrace(t *testing.T) {
+
+	expected := []TraceField{
+		{"length", 16, 16},
+		{"flags", 32, 3},
+	}
+
+	buf := NewBuffer([]byte{0x00, 0x00, 0x00, 0x04, 0xE0})
+	buf.SetTracer(NewTracer())
+
+	c := buf.Cursor(0x02)
+	buf.Trace("length")
+	_ = c.ReadU16BENext(1)
+	buf.Trace("flags")
+	c.AlignBit()
+	_ = c.ReadBitsNext(3)
+
+	out := buf.Tracer().Fields()
+	if !cmp.Equal(expected, out) {
+
+		t.Fatalf("expected fields do not match the ones gotten (got %#v, expected %#v)", out, expected)
+
+	}
+
+}
+
+func TestCursorBitsNext(t *testing.T) {
+
+	buf := NewBuffer([]byte{0x00, 0x00})
+
+	c := buf.Cursor(0x00)
+	c.SeekBit(4, false)
+	c.SetBitsBytesNext([]byte{0xAB, 0xC0}, 10)
+	if c.BitOffset() != 14 || !cmp.Equal([]byte{0x0A, 0xBC}, buf.Bytes()) {
+
+		t.Fatalf("unexpected cursor state (at %d, holding %#v)", c.BitOffset(), buf.Bytes())
+
+	}
+
+	c.SeekBit(4, false)
+	if out := c.ReadBitsSignedNext(4); out != -6 {
+
+		t.Fatalf("expected int64 does not match the one gotten (got %d, expected %d)", out, -6)
+
+	}
+
+	if out := c.ReadBitsBytesNext(6); !cmp.Equal([]byte{0xBC}, out) || c.BitOffset() != 14 {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v at %d)", out, c.BitOffset())
+
+	}
+
+}
+
+func TestCursorReadPanic(t *testing.T) {
+
+	defer panicChecker(t, BufferOverreadError)
+
+	buf := NewBuffer([]byte{0x00, 0x00, 0x00, 0x00})
+
+	c := buf.Cursor(0x02)
+	_ = c.ReadU32BENext(1)
+
+}
+
+func TestCursorWritePanic(t *testing.T) {
+
+	defer panicChecker(t, BufferUnderwriteError)
+
+	buf := NewBuffer([]byte{0x00, 0x00})
+
+	c := buf.Cursor(0x00)
+	c.SeekByte(-0x01, true)
+	c.WriteByteNext(0x00)
+
+}
@@ -0,0 +1,170 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffOp represents the kind of difference a DiffRange describes
+type DiffOp int
+
+const (
+	// DiffChanged marks a range present in both buffers with
+	// different contents
+	DiffChanged DiffOp = iota
+
+	// DiffAdded marks a range only present in the second buffer
+	DiffAdded
+
+	// DiffRemoved marks a range only present in the first buffer
+	DiffRemoved
+)
+
+// String returns the name of the operation
+func (op DiffOp) String() string {
+
+	switch op {
+
+	case DiffChanged:
+		return "changed"
+
+	case DiffAdded:
+		return "added"
+
+	case DiffRemoved:
+		return "removed"
+
+	}
+	return fmt.Sprintf("DiffOp(%d)", int(op))
+
+}
+
+// DiffRange represents a range of bytes, or bits for ranges returned
+// by DiffBits, that differs between two buffers
+type DiffRange struct {
+	Op     DiffOp
+	Offset int64
+	Length int64
+}
+
+// String formats the range as the operation followed by its offset
+// and length
+func (r DiffRange) String() string {
+
+	return fmt.Sprintf("%s %#x+%d", r.Op, r.Offset, r.Length)
+
+}
+
+// diffRanges returns the ranges of units differing between two
+// sequences of the specified lengths, where differ reports whether
+// the unit located at an offset shared by both differs
+func diffRanges(alen, blen int64, differ func(off int64) bool) (out []DiffRange) {
+
+	common := alen
+	if blen < common {
+
+		common = blen
+
+	}
+
+	for off := int64(0); off < common; off++ {
+
+		if !differ(off) {
+
+			continue
+
+		}
+
+		start := off
+		for off < common && differ(off) {
+
+			off++
+
+		}
+
+		out = append(out, DiffRange{DiffChanged, start, off - start})
+
+	}
+
+	switch {
+
+	case blen > alen:
+		out = append(out, DiffRange{DiffAdded, alen, blen - alen})
+
+	case alen > blen:
+		out = append(out, DiffRange{DiffRemoved, blen, alen - blen})
+
+	}
+
+	return
+
+}
+
+// Diff compares the buffer with other byte by byte and returns the
+// ranges that differ between them, in order. bytes past the end of the
+// shorter buffer are reported as added to or removed from other
+func (b *Buffer) Diff(other *Buffer) []DiffRange {
+
+	return diffRanges(b.cap, other.cap, func(off int64) bool {
+
+		return b.buf[off] != other.buf[off]
+
+	})
+
+}
+
+// DiffBits compares the buffer with other bit by bit and returns the
+// ranges of bits that differ between them, in order
+func (b *Buffer) DiffBits(other *Buffer) []DiffRange {
+
+	return diffRanges(b.bcap, other.bcap, func(off int64) bool {
+
+		return (b.buf[off/8]^other.buf[off/8])&(0x80>>uint64(off%8)) != 0
+
+	})
+
+}
+
+// DiffString returns a human-readable description of the differences
+// between the buffer and other, listing the bytes of every range on
+// each side, or an empty string if they are equal. it is meant for
+// test failure messages
+func (b *Buffer) DiffString(other *Buffer) string {
+
+	var sb strings.Builder
+	for _, r := range b.Diff(other) {
+
+		var (
+			before = "--"
+			after  = "--"
+		)
+
+		if r.Op != DiffAdded {
+
+			before = fmt.Sprintf("% x", b.buf[r.Offset:r.Offset+r.Length])
+
+		}
+
+		if r.Op != DiffRemoved {
+
+			after = fmt.Sprintf("% x", other.buf[r.Offset:r.Offset+r.Length])
+
+		}
+
+		fmt.Fprintf(&sb, "%s: %s -> %s\n", r, before, after)
+
+	}
+	return sb.String()
+
+}
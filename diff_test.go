@@ -0,0 +1,95 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+tests
+
+*/
+
+func TestBufferDiff(t *testing.T) {
+
+	expected := []DiffRange{
+		{DiffChanged, 0x01, 2},
+		{DiffChanged, 0x05, 1},
+		{DiffAdded, 0x07, 2},
+	}
+
+	var (
+		a = NewBuffer([]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06})
+		b = NewBuffer([]byte{0x00, 0xFF, 0xFE, 0x03, 0x04, 0x15, 0x06, 0x07, 0x08})
+	)
+
+	if out := a.Diff(b); !cmp.Equal(expected, out) {
+
+		t.Fatalf("expected ranges do not match the ones gotten (got %v, expected %v)", out, expected)
+
+	}
+
+	if out := b.Diff(a); out[2] != (DiffRange{DiffRemoved, 0x07, 2}) {
+
+		t.Fatalf("expected trailing bytes to be reported as removed (got %v)", out[2])
+
+	}
+
+	if out := a.Diff(a); len(out) != 0 {
+
+		t.Fatalf("expected no differences between a buffer and itself (got %v)", out)
+
+	}
+
+}
+
+func TestBufferDiffBits(t *testing.T) {
+
+	expected := []DiffRange{
+		{DiffChanged, 7, 2},
+		{DiffChanged, 12, 1},
+		{DiffRemoved, 16, 8},
+	}
+
+	var (
+		a = NewBuffer([]byte{0x00, 0x00, 0xFF})
+		b = NewBuffer([]byte{0x01, 0x88})
+	)
+
+	if out := a.DiffBits(b); !cmp.Equal(expected, out) {
+
+		t.Fatalf("expected ranges do not match the ones gotten (got %v, expected %v)", out, expected)
+
+	}
+
+}
+
+func TestBufferDiffString(t *testing.T) {
+
+	expected := "changed 0x1+2: 01 02 -> ff fe\nadded 0x4+1: -- -> 04\n"
+
+	var (
+		a = NewBuffer([]byte{0x00, 0x01, 0x02, 0x03})
+		b = NewBuffer([]byte{0x00, 0xFF, 0xFE, 0x03, 0x04})
+	)
+
+	if out := a.DiffString(b); out != expected {
+
+		t.Fatalf("expected string does not match the one gotten (got %q, expected %q)", out, expected)
+
+	}
+
+}
@@ -11,18 +11,149 @@ file, you can obtain one at http://mozilla.org/MPL/2.0/.
 
 package crunch
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
-// Error implements a custom error type used in crunch
+// Error implements a custom error type used in crunch. errors raised
+// by an operation carry the details of that operation and match the
+// sentinel value they were created from through Is
 type Error struct {
 	scope string
 	error string
+
+	// Offset is the offset the operation was attempted at
+	Offset int64
+
+	// Size is the amount of bytes, or bits if Bits is set, that the
+	// operation attempted to access
+	Size int64
+
+	// Capacity is the capacity of the buffer at the time of the
+	// operation, in the same unit as Size
+	Capacity int64
+
+	// Bits is set if Offset, Size and Capacity are measured in bits
+	Bits bool
+
+	// Path is the dot-separated name of the field being decoded, if
+	// one was provided
+	Path string
+
+	detailed bool
 }
 
 // Error formats the error held in a Error as a string
 func (e Error) Error() string {
 
-	return fmt.Sprintf("crunch: %s: %s", e.scope, e.error)
+	var details []string
+
+	if e.detailed {
+
+		unit := "byte"
+		if e.Bits {
+
+			unit = "bit"
+
+		}
+
+		details = append(details, fmt.Sprintf("%s offset %d, size %d, capacity %d", unit, e.Offset, e.Size, e.Capacity))
+
+	}
+
+	if e.Path != "" {
+
+		details = append(details, fmt.Sprintf("field %s", e.Path))
+
+	}
+
+	if len(details) == 0 {
+
+		return fmt.Sprintf("crunch: %s: %s", e.scope, e.error)
+
+	}
+	return fmt.Sprintf("crunch: %s: %s (%s)", e.scope, e.error, strings.Join(details, ", "))
+
+}
+
+// Is reports whether target is an Error of the same kind, regardless
+// of the details either of them carry
+func (e Error) Is(target error) bool {
+
+	switch t := target.(type) {
+
+	case Error:
+		return e.scope == t.scope && e.error == t.error
+
+	case *Error:
+		return t != nil && e.scope == t.scope && e.error == t.error
+
+	}
+	return false
+
+}
+
+// WithPath returns a copy of the error with name prepended to its
+// field path
+func (e Error) WithPath(name string) Error {
+
+	if e.Path == "" {
+
+		e.Path = name
+
+	} else if name != "" {
+
+		e.Path = name + "." + e.Path
+
+	}
+	return e
+
+}
+
+// at returns a copy of the error carrying the details of an access of
+// size bytes at off in a buffer holding capacity bytes
+func (e Error) at(off, size, capacity int64) Error {
+
+	e.Offset = off
+	e.Size = size
+	e.Capacity = capacity
+	e.detailed = true
+	return e
+
+}
+
+// atBit returns a copy of the error carrying the details of an access
+// of size bits at off in a buffer holding capacity bits
+func (e Error) atBit(off, size, capacity int64) Error {
+
+	e = e.at(off, size, capacity)
+	e.Bits = true
+	return e
+
+}
+
+// Recover is meant to be deferred by functions that decode data with
+// crunch. it turns a panic raised with an Error into an error stored
+// in err, prepending the provided field path to it, and lets other
+// panics through untouched
+func Recover(err *error, path ...string) {
+
+	r := recover()
+	if r == nil {
+
+		return
+
+	}
+
+	e, ok := r.(Error)
+	if !ok {
+
+		panic(r)
+
+	}
+
+	*err = e.WithPath(strings.Join(path, "."))
 
 }
 
@@ -62,10 +193,243 @@ var (
 		error: "invalid byte count requested",
 	}
 
+	// BufferInvalidBitCountError represents an instance in which an
+	// invalid bit count was passed to one of the buffer's methods
+	BufferInvalidBitCountError = Error{
+		scope: "buffer",
+		error: "invalid bit count requested",
+	}
+
 	// BytesBufNegativeReadError represents an instance in which a
 	// reader returned a negative count from its Read method
 	BytesBufNegativeReadError = Error{
 		scope: "bytesbuf",
 		error: "reader returned negative count from Read",
 	}
+
+	// FramingInvalidCOBSError represents an instance in which a COBS
+	// frame contained an invalid code byte or was truncated
+	FramingInvalidCOBSError = Error{
+		scope: "framing",
+		error: "invalid cobs frame",
+	}
+
+	// FramingInvalidSLIPEscapeError represents an instance in which a
+	// SLIP frame contained an escape byte followed by an invalid byte
+	FramingInvalidSLIPEscapeError = Error{
+		scope: "framing",
+		error: "invalid slip escape sequence",
+	}
+
+	// FramingHDLCAbortError represents an instance in which an HDLC
+	// frame was aborted by seven or more consecutive 1 bits
+	FramingHDLCAbortError = Error{
+		scope: "framing",
+		error: "hdlc frame aborted",
+	}
+
+	// FramingHDLCMisalignedError represents an instance in which an
+	// HDLC frame did not contain a whole number of bytes
+	FramingHDLCMisalignedError = Error{
+		scope: "framing",
+		error: "hdlc frame is not a whole number of bytes",
+	}
+
+	// CRCInvalidWidthError represents an instance in which a crc
+	// engine was requested with a width outside of 1 to 64 bits
+	CRCInvalidWidthError = Error{
+		scope: "crc",
+		error: "crc width must be between 1 and 64 bits",
+	}
+
+	// MappedBufferReadOnlyError represents an instance in which a
+	// read-only mapped buffer was asked to grow the file behind it
+	MappedBufferReadOnlyError = Error{
+		scope: "mappedbuffer",
+		error: "cannot grow a read-only mapping",
+	}
+
+	// MappedBufferUnsupportedError represents an instance in which a
+	// mapped buffer was requested on an unsupported platform
+	MappedBufferUnsupportedError = Error{
+		scope: "mappedbuffer",
+		error: "memory-mapped buffers are not supported on this platform",
+	}
+
+	// ReaderBufferEOFError represents an instance in which a read
+	// was attempted after a reader buffer's source ran out of data
+	ReaderBufferEOFError = Error{
+		scope: "readerbuffer",
+		error: "source has no more data",
+	}
+
+	// ReaderBufferUnexpectedEOFError represents an instance in which
+	// a reader buffer's source ran out of data partway through a read
+	ReaderBufferUnexpectedEOFError = Error{
+		scope: "readerbuffer",
+		error: "source ran out of data in the middle of a read",
+	}
+
+	// RingBufferOverflowError represents an instance in which a write
+	// did not fit in the free space of a ring buffer
+	RingBufferOverflowError = Error{
+		scope: "ringbuffer",
+		error: "write exceeds free space",
+	}
+
+	// RingBufferUnderflowError represents an instance in which a read
+	// requested more bytes than a ring buffer held
+	RingBufferUnderflowError = Error{
+		scope: "ringbuffer",
+		error: "read exceeds available data",
+	}
+
+	// RingBufferClosedError represents an instance in which a ring
+	// buffer was used after being closed
+	RingBufferClosedError = Error{
+		scope: "ringbuffer",
+		error: "ring buffer is closed",
+	}
+
+	// SchemaInvalidError represents an instance in which a schema
+	// was malformed
+	SchemaInvalidError = Error{
+		scope: "schema",
+		error: "invalid schema",
+	}
+
+	// SchemaUnknownTypeError represents an instance in which a schema
+	// referred to a type that does not exist
+	SchemaUnknownTypeError = Error{
+		scope: "schema",
+		error: "unknown type",
+	}
+
+	// SchemaInvalidExpressionError represents an instance in which an
+	// expression in a schema could not be parsed
+	SchemaInvalidExpressionError = Error{
+		scope: "schema",
+		error: "invalid expression",
+	}
+
+	// SchemaUnknownIdentifierError represents an instance in which an
+	// expression referred to a field that has not been parsed
+	SchemaUnknownIdentifierError = Error{
+		scope: "schema",
+		error: "unknown identifier",
+	}
+
+	// SchemaTypeMismatchError represents an instance in which an
+	// expression was applied to values of the wrong type
+	SchemaTypeMismatchError = Error{
+		scope: "schema",
+		error: "mismatched operand types",
+	}
+
+	// SchemaDivisionByZeroError represents an instance in which an
+	// expression divided by zero
+	SchemaDivisionByZeroError = Error{
+		scope: "schema",
+		error: "division by zero",
+	}
+
+	// SchemaNoMatchingCaseError represents an instance in which a
+	// switch had no case for the value it was switched on
+	SchemaNoMatchingCaseError = Error{
+		scope: "schema",
+		error: "no matching case",
+	}
+
+	// PatternInvalidError represents an instance in which a search
+	// pattern was malformed
+	PatternInvalidError = Error{
+		scope: "pattern",
+		error: "invalid pattern",
+	}
+
+	// PatchInvalidError represents an instance in which a patch was
+	// malformed
+	PatchInvalidError = Error{
+		scope: "patch",
+		error: "invalid patch",
+	}
+
+	// PatchSourceMismatchError represents an instance in which a patch
+	// was applied to data other than the one it was created from
+	PatchSourceMismatchError = Error{
+		scope: "patch",
+		error: "source does not match the patch",
+	}
+
+	// PatchTargetMismatchError represents an instance in which the
+	// result of applying a patch did not match its checksum
+	PatchTargetMismatchError = Error{
+		scope: "patch",
+		error: "result does not match the patch",
+	}
+
+	// PatchChecksumError represents an instance in which a patch did
+	// not match its own checksum
+	PatchChecksumError = Error{
+		scope: "patch",
+		error: "patch checksum mismatch",
+	}
+
+	// PatchTooLargeError represents an instance in which a change was
+	// located past the offsets a patch format can describe
+	PatchTooLargeError = Error{
+		scope: "patch",
+		error: "data exceeds the limits of the patch format",
+	}
+
+	// PatchTargetTooLargeError represents an instance in which a patch
+	// described a result larger than it was allowed to produce
+	PatchTargetTooLargeError = Error{
+		scope: "patch",
+		error: "patch result exceeds the size limit",
+	}
+
+	// FixedPointInvalidFormatError represents an instance in which a
+	// fixed-point format was malformed or did not fit the access it
+	// was used for
+	FixedPointInvalidFormatError = Error{
+		scope: "fixedpoint",
+		error: "invalid fixed-point format",
+	}
+
+	// FixedPointOverflowError represents an instance in which a value
+	// could not be represented in a fixed-point format that does not
+	// saturate
+	FixedPointOverflowError = Error{
+		scope: "fixedpoint",
+		error: "value out of range for the fixed-point format",
+	}
+
+	// BCDInvalidDigitError represents an instance in which a decimal
+	// field held a nibble that is not a valid digit or sign
+	BCDInvalidDigitError = Error{
+		scope: "bcd",
+		error: "invalid digit",
+	}
+
+	// BCDOverflowError represents an instance in which a value did not
+	// fit in a decimal field or the integer it was read into
+	BCDOverflowError = Error{
+		scope: "bcd",
+		error: "value does not fit",
+	}
+
+	// TimeInvalidError represents an instance in which an encoded
+	// timestamp was malformed or of an unknown kind
+	TimeInvalidError = Error{
+		scope: "time",
+		error: "invalid timestamp",
+	}
+
+	// TimeOutOfRangeError represents an instance in which a time could
+	// not be represented by a timestamp encoding
+	TimeOutOfRangeError = Error{
+		scope: "time",
+		error: "time out of range for the timestamp encoding",
+	}
 )
@@ -11,7 +11,9 @@ file, you can obtain one at http://mozilla.org/MPL/2.0/.
 
 package crunch
 
-import "testing"
+import (
+	"testing"
+)
 
 /*
 
@@ -31,3 +33,112 @@ func TestErrorError(t *testing.T) {
 	}
 
 }
+
+func TestErrorDetails(t *testing.T) {
+
+	var err error
+
+	func() {
+
+		defer Recover(&err, "header", "length")
+
+		buf := NewBuffer([]byte{0x00, 0x00, 0x00})
+		_ = buf.ReadU16BE(0x02, 1)
+
+	}()
+
+	details, ok := err.(Error)
+	if !ok {
+
+		t.Fatalf("expected error to be a crunch error (got %#v)", err)
+
+	}
+
+	if !details.Is(BufferOverreadError) || details.Is(BufferUnderreadError) {
+
+		t.Fatalf("expected error to match its sentinel only (got %v)", err)
+
+	}
+
+	if details.Offset != 0x02 || details.Size != 2 || details.Capacity != 3 || details.Bits || details.Path != "header.length" {
+
+		t.Fatalf("unexpected error details (got %#v)", details)
+
+	}
+
+	expected := "crunch: buffer: read exceeds buffer capacity (byte offset 2, size 2, capacity 3, field header.length)"
+	if err.Error() != expected {
+
+		t.Fatalf("expected string does not match the one gotten (got \"%s\", expected \"%s\")", err.Error(), expected)
+
+	}
+
+}
+
+func TestErrorBitDetails(t *testing.T) {
+
+	defer func() {
+
+		err, _ := recover().(Error)
+		if err != BufferUnderwriteError.atBit(-1, 1, 16) {
+
+			t.Fatalf("unexpected error details (got %#v)", err)
+
+		}
+
+	}()
+
+	buf := NewBuffer([]byte{0x00, 0x00})
+	buf.SetBit(-1)
+
+}
+
+func TestErrorBitRangeDetails(t *testing.T) {
+
+	buf := NewBuffer([]byte{0x00, 0x00, 0x00})
+
+	for _, test := range []struct {
+		access   func()
+		expected Error
+	}{
+		{func() { buf.ReadBits(20, 8) }, BufferOverreadError.atBit(20, 8, 24)},
+		{func() { buf.SetBits(-3, 0, 5) }, BufferUnderwriteError.atBit(-3, 5, 24)},
+		{func() { buf.CRCBits(4, 24, CRC32) }, BufferOverreadError.atBit(4, 24, 24)},
+	} {
+
+		var err error
+		func() {
+
+			defer Recover(&err)
+			test.access()
+
+		}()
+
+		if err != test.expected {
+
+			t.Fatalf("unexpected error details (got %#v, expected %#v)", err, test.expected)
+
+		}
+
+	}
+
+}
+
+func TestRecoverPassthrough(t *testing.T) {
+
+	defer func() {
+
+		if r := recover(); r != "not crunch" {
+
+			t.Fatalf("expected foreign panic to be passed through (got %#v)", r)
+
+		}
+
+	}()
+
+	var err error
+	defer Recover(&err)
+
+	panic("not crunch")
+
+}
@@ -0,0 +1,444 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// QRounding represents how a value is rounded when it is converted to
+// a fixed-point format that cannot represent it exactly
+type QRounding int
+
+const (
+	// QRoundNearest rounds to the nearest representable value, with
+	// halfway cases rounded away from zero
+	QRoundNearest QRounding = iota
+
+	// QRoundNearestEven rounds to the nearest representable value,
+	// with halfway cases rounded to the even one
+	QRoundNearestEven
+
+	// QRoundTowardZero truncates the bits that cannot be represented
+	QRoundTowardZero
+
+	// QRoundDown rounds toward negative infinity, which is what an
+	// arithmetic right shift does
+	QRoundDown
+
+	// QRoundUp rounds toward positive infinity
+	QRoundUp
+)
+
+// QFormat describes a binary fixed-point number format
+type QFormat struct {
+	// Width is the total amount of bits of the number, including the
+	// sign bit of signed formats
+	Width int64
+
+	// Frac is the amount of fractional bits
+	Frac int64
+
+	// Signed is set if the number is stored in two's complement
+	Signed bool
+
+	// Rounding is how values are rounded when they are written
+	Rounding QRounding
+
+	// Saturate makes values that are out of range be clamped to the
+	// closest representable one when they are written instead of
+	// panicking with FixedPointOverflowError
+	Saturate bool
+}
+
+var (
+	// Q15 is the signed 16-bit format with 15 fractional bits
+	Q15 = QFormat{Width: 16, Frac: 15, Signed: true}
+
+	// Q31 is the signed 32-bit format with 31 fractional bits
+	Q31 = QFormat{Width: 32, Frac: 31, Signed: true}
+
+	// Q16_16 is the signed 32-bit format with 16 fractional bits
+	Q16_16 = QFormat{Width: 32, Frac: 16, Signed: true}
+
+	// UQ16_16 is the unsigned 32-bit format with 16 fractional bits
+	UQ16_16 = QFormat{Width: 32, Frac: 16}
+)
+
+// ParseQFormat parses a fixed-point format written in q notation,
+// where Qm.n is a signed format with m integer bits, including the
+// sign bit, and n fractional bits, Qn is the same as Q1.n, and a
+// leading U makes the format unsigned, with UQn being the same as
+// UQ0.n. for example, "Q15", "Q16.16" and "UQ8.8" are all valid
+func ParseQFormat(s string) (q QFormat, err error) {
+
+	q.Signed = true
+	if strings.HasPrefix(s, "U") {
+
+		q.Signed = false
+		s = s[1:]
+
+	}
+
+	if !strings.HasPrefix(s, "Q") {
+
+		return QFormat{}, FixedPointInvalidFormatError
+
+	}
+	s = s[1:]
+
+	var (
+		m, n       int64
+		merr, nerr error
+	)
+
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+
+		m, merr = strconv.ParseInt(s[:i], 10, 64)
+		n, nerr = strconv.ParseInt(s[i+1:], 10, 64)
+
+	} else {
+
+		n, nerr = strconv.ParseInt(s, 10, 64)
+		if q.Signed {
+
+			m = 1
+
+		}
+
+	}
+
+	if merr != nil || nerr != nil || m < 0 || n < 0 {
+
+		return QFormat{}, FixedPointInvalidFormatError
+
+	}
+
+	q.Width = m + n
+	q.Frac = n
+	if !q.valid() {
+
+		return QFormat{}, FixedPointInvalidFormatError
+
+	}
+	return
+
+}
+
+/* internal use methods */
+
+// valid reports whether the format can be stored in an integer of at
+// most 64 bits
+func (q QFormat) valid() bool {
+
+	return q.Width >= 1 && q.Width <= 64 && q.Frac >= 0 && q.Frac <= q.Width
+
+}
+
+// check panics if the format is invalid
+func (q QFormat) check() {
+
+	if !q.valid() {
+
+		panic(FixedPointInvalidFormatError)
+
+	}
+
+}
+
+// bytes returns the amount of bytes a byte-aligned number in the
+// format takes up, panicking if its width is not a multiple of 8
+func (q QFormat) bytes() int64 {
+
+	q.check()
+	if q.Width%8 != 0 {
+
+		panic(FixedPointInvalidFormatError)
+
+	}
+	return q.Width / 8
+
+}
+
+/* conversion methods */
+
+// Float converts raw, a number in the format stored in its low Width
+// bits, to a float64
+func (q QFormat) Float(raw uint64) float64 {
+
+	q.check()
+
+	shift := uint64(64 - q.Width)
+	if q.Signed {
+
+		return math.Ldexp(float64(int64(raw<<shift)>>shift), -int(q.Frac))
+
+	}
+	return math.Ldexp(float64(raw<<shift>>shift), -int(q.Frac))
+
+}
+
+// Raw converts v to a number in the format stored in the low Width
+// bits of the result, rounding it as specified by the format. values
+// out of range, including nan, are clamped if the format saturates,
+// with nan becoming zero, or cause a panic otherwise
+func (q QFormat) Raw(v float64) uint64 {
+
+	q.check()
+
+	var (
+		mask = ^uint64(0) >> uint64(64-q.Width)
+		x    = math.Ldexp(v, int(q.Frac))
+	)
+
+	switch q.Rounding {
+
+	case QRoundNearestEven:
+		x = math.RoundToEven(x)
+
+	case QRoundTowardZero:
+		x = math.Trunc(x)
+
+	case QRoundDown:
+		x = math.Floor(x)
+
+	case QRoundUp:
+		x = math.Ceil(x)
+
+	default:
+		x = math.Round(x)
+
+	}
+
+	// the bounds are powers of two, which float64 represents exactly
+	var (
+		lo = 0.0
+		hi = math.Ldexp(1, int(q.Width))
+	)
+
+	if q.Signed {
+
+		lo = -math.Ldexp(1, int(q.Width-1))
+		hi = -lo
+
+	}
+
+	switch {
+
+	case math.IsNaN(x):
+		if !q.Saturate {
+
+			panic(FixedPointOverflowError)
+
+		}
+		return 0
+
+	case x < lo:
+		if !q.Saturate {
+
+			panic(FixedPointOverflowError)
+
+		}
+
+		if q.Signed {
+
+			return (mask >> 1) + 1
+
+		}
+		return 0
+
+	case x >= hi:
+		if !q.Saturate {
+
+			panic(FixedPointOverflowError)
+
+		}
+
+		if q.Signed {
+
+			return mask >> 1
+
+		}
+		return mask
+
+	}
+
+	if q.Signed {
+
+		return uint64(int64(x)) & mask
+
+	}
+	return uint64(x)
+
+}
+
+/* buffer methods */
+
+// ReadFixedLE reads a byte-aligned fixed-point number in the format q
+// from the buffer at the specified offset in little-endian without
+// modifying the internal offset value. the width of q must be a
+// multiple of 8
+func (b *Buffer) ReadFixedLE(off int64, q QFormat) float64 {
+
+	var raw uint64
+	for i, c := range b.ReadBytes(off, q.bytes()) {
+
+		raw |= uint64(c) << uint64(i*8)
+
+	}
+	return q.Float(raw)
+
+}
+
+// ReadFixedLENext reads a byte-aligned fixed-point number in the
+// format q from the buffer at the current offset in little-endian and
+// moves the offset forward the amount of bytes read
+func (b *Buffer) ReadFixedLENext(q QFormat) (out float64) {
+
+	out = b.ReadFixedLE(b.off, q)
+	b.traced(b.off*8, q.Width)
+	b.SeekByte(q.bytes(), true)
+	return
+
+}
+
+// ReadFixedBE reads a byte-aligned fixed-point number in the format q
+// from the buffer at the specified offset in big-endian without
+// modifying the internal offset value. the width of q must be a
+// multiple of 8
+func (b *Buffer) ReadFixedBE(off int64, q QFormat) float64 {
+
+	var raw uint64
+	for _, c := range b.ReadBytes(off, q.bytes()) {
+
+		raw = (raw << 8) | uint64(c)
+
+	}
+	return q.Float(raw)
+
+}
+
+// ReadFixedBENext reads a byte-aligned fixed-point number in the
+// format q from the buffer at the current offset in big-endian and
+// moves the offset forward the amount of bytes read
+func (b *Buffer) ReadFixedBENext(q QFormat) (out float64) {
+
+	out = b.ReadFixedBE(b.off, q)
+	b.traced(b.off*8, q.Width)
+	b.SeekByte(q.bytes(), true)
+	return
+
+}
+
+// WriteFixedLE writes v as a byte-aligned fixed-point number in the
+// format q to the buffer at the specified offset in little-endian
+// without modifying the internal offset value
+func (b *Buffer) WriteFixedLE(off int64, q QFormat, v float64) {
+
+	var (
+		raw  = q.Raw(v)
+		data = make([]byte, q.bytes())
+	)
+
+	for i := range data {
+
+		data[i] = byte(raw >> uint64(i*8))
+
+	}
+	b.WriteBytes(off, data)
+
+}
+
+// WriteFixedLENext writes v as a byte-aligned fixed-point number in
+// the format q to the buffer at the current offset in little-endian
+// and moves the offset forward the amount of bytes written
+func (b *Buffer) WriteFixedLENext(q QFormat, v float64) {
+
+	b.WriteFixedLE(b.off, q, v)
+	b.traced(b.off*8, q.Width)
+	b.SeekByte(q.bytes(), true)
+
+}
+
+// WriteFixedBE writes v as a byte-aligned fixed-point number in the
+// format q to the buffer at the specified offset in big-endian without
+// modifying the internal offset value
+func (b *Buffer) WriteFixedBE(off int64, q QFormat, v float64) {
+
+	var (
+		raw  = q.Raw(v)
+		data = make([]byte, q.bytes())
+	)
+
+	for i := range data {
+
+		data[len(data)-1-i] = byte(raw >> uint64(i*8))
+
+	}
+	b.WriteBytes(off, data)
+
+}
+
+// WriteFixedBENext writes v as a byte-aligned fixed-point number in
+// the format q to the buffer at the current offset in big-endian and
+// moves the offset forward the amount of bytes written
+func (b *Buffer) WriteFixedBENext(q QFormat, v float64) {
+
+	b.WriteFixedBE(b.off, q, v)
+	b.traced(b.off*8, q.Width)
+	b.SeekByte(q.bytes(), true)
+
+}
+
+// ReadFixedBits reads a fixed-point number in the format q stored in
+// the next Width bits from the specified bit offset without modifying
+// the internal offset value
+func (b *Buffer) ReadFixedBits(off int64, q QFormat) float64 {
+
+	q.check()
+	return q.Float(b.ReadBits(off, q.Width))
+
+}
+
+// ReadFixedBitsNext reads a fixed-point number in the format q stored
+// in the next Width bits from the current bit offset and moves the bit
+// offset forward the amount of bits read
+func (b *Buffer) ReadFixedBitsNext(q QFormat) (out float64) {
+
+	out = b.ReadFixedBits(b.boff, q)
+	b.traced(b.boff, q.Width)
+	b.SeekBit(q.Width, true)
+	return
+
+}
+
+// SetFixedBits stores v as a fixed-point number in the format q in the
+// next Width bits from the specified bit offset without modifying the
+// internal offset value
+func (b *Buffer) SetFixedBits(off int64, q QFormat, v float64) {
+
+	b.SetBits(off, q.Raw(v), q.Width)
+
+}
+
+// SetFixedBitsNext stores v as a fixed-point number in the format q in
+// the next Width bits from the current bit offset and moves the bit
+// offset forward the amount of bits set
+func (b *Buffer) SetFixedBitsNext(q QFormat, v float64) {
+
+	b.SetFixedBits(b.boff, q, v)
+	b.traced(b.boff, q.Width)
+	b.SeekBit(q.Width, true)
+
+}
@@ -0,0 +1,247 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+tests
+
+*/
+
+func TestParseQFormat(t *testing.T) {
+
+	for s, expected := range map[string]QFormat{
+		"Q15":     Q15,
+		"Q31":     Q31,
+		"Q16.16":  Q16_16,
+		"UQ16.16": UQ16_16,
+		"UQ8":     {Width: 8, Frac: 8},
+		"Q1.7":    {Width: 8, Frac: 7, Signed: true},
+	} {
+
+		q, err := ParseQFormat(s)
+		if err != nil {
+
+			t.Fatalf("unexpected error parsing %q (%v)", s, err)
+
+		}
+
+		if !cmp.Equal(expected, q) {
+
+			t.Fatalf("expected format does not match the one gotten for %q (got %#v, expected %#v)", s, q, expected)
+
+		}
+
+	}
+
+	for _, s := range []string{"", "Q", "X15", "Q8.", "Q-1.8", "Q65", "UQ32.33"} {
+
+		if _, err := ParseQFormat(s); err != FixedPointInvalidFormatError {
+
+			t.Fatalf("expected %q to be rejected (got %v)", s, err)
+
+		}
+
+	}
+
+}
+
+func TestQFormatConversions(t *testing.T) {
+
+	for _, c := range []struct {
+		q        QFormat
+		raw      uint64
+		expected float64
+	}{
+		{Q15, 0x4000, 0.5},
+		{Q15, 0x8000, -1},
+		{Q15, 0x7FFF, 1 - 1.0/32768},
+		{Q31, 0xC0000000, -0.5},
+		{Q16_16, 0xFFFE8000, -1.5},
+		{UQ16_16, 0xFFFE8000, 65534.5},
+	} {
+
+		if out := c.q.Float(c.raw); out != c.expected {
+
+			t.Fatalf("expected float64 does not match the one gotten for %#x (got %v, expected %v)", c.raw, out, c.expected)
+
+		}
+
+		if out := c.q.Raw(c.expected); out != c.raw {
+
+			t.Fatalf("expected raw value does not match the one gotten for %v (got %#x, expected %#x)", c.expected, out, c.raw)
+
+		}
+
+	}
+
+}
+
+func TestQFormatRounding(t *testing.T) {
+
+	q := QFormat{Width: 8, Frac: 1, Signed: true}
+
+	// 1.25 and -1.25 lie halfway between representable values
+	for rounding, expected := range map[QRounding][2]uint64{
+		QRoundNearest:     {3, 0xFD},
+		QRoundNearestEven: {2, 0xFE},
+		QRoundTowardZero:  {2, 0xFE},
+		QRoundDown:        {2, 0xFD},
+		QRoundUp:          {3, 0xFE},
+	} {
+
+		q.Rounding = rounding
+		if out := [2]uint64{q.Raw(1.25), q.Raw(-1.25)}; out != expected {
+
+			t.Fatalf("expected raw values do not match the ones gotten for rounding %d (got %#v, expected %#v)", rounding, out, expected)
+
+		}
+
+	}
+
+}
+
+func TestQFormatSaturation(t *testing.T) {
+
+	q := Q15
+	q.Saturate = true
+
+	for v, expected := range map[float64]uint64{
+		1:            0x7FFF,
+		-2:           0x8000,
+		math.Inf(1):  0x7FFF,
+		math.Inf(-1): 0x8000,
+		math.NaN():   0,
+	} {
+
+		if out := q.Raw(v); out != expected {
+
+			t.Fatalf("expected raw value does not match the one gotten for %v (got %#x, expected %#x)", v, out, expected)
+
+		}
+
+	}
+
+	u := UQ16_16
+	u.Saturate = true
+	if out := u.Raw(-1); out != 0 {
+
+		t.Fatalf("expected raw value does not match the one gotten (got %#x, expected 0)", out)
+
+	}
+
+	defer panicChecker(t, FixedPointOverflowError)
+	Q15.Raw(1)
+
+}
+
+func TestBufferFixed(t *testing.T) {
+
+	buf := NewBuffer(make([]byte, 8))
+
+	buf.WriteFixedLENext(Q15, -0.25)
+	buf.WriteFixedBENext(Q16_16, 3.75)
+	if !cmp.Equal([]byte{0x00, 0xE0, 0x00, 0x03, 0xC0, 0x00, 0x00, 0x00}, buf.Bytes()) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v)", buf.Bytes())
+
+	}
+
+	buf.SeekByte(0x00, false)
+	if out := [2]float64{buf.ReadFixedLENext(Q15), buf.ReadFixedBENext(Q16_16)}; out != [2]float64{-0.25, 3.75} {
+
+		t.Fatalf("expected float64s do not match the ones gotten (got %v)", out)
+
+	}
+
+	// a 12-bit signed value with 4 fractional bits packed at an
+	// unaligned bit offset
+	q := QFormat{Width: 12, Frac: 4, Signed: true}
+	buf.SeekBit(0x03, false)
+	buf.SetFixedBitsNext(q, -7.5625)
+
+	if buf.BitOffset() != 0x0F {
+
+		t.Fatalf("expected bit offset does not match the one gotten (got %d, expected %d)", buf.BitOffset(), 0x0F)
+
+	}
+
+	if out := buf.ReadFixedBits(0x03, q); out != -7.5625 {
+
+		t.Fatalf("expected float64 does not match the one gotten (got %v, expected %v)", out, -7.5625)
+
+	}
+
+	defer panicChecker(t, FixedPointInvalidFormatError)
+	buf.ReadFixedLE(0x00, q)
+
+}
+
+func TestCursorFixed(t *testing.T) {
+
+	buf := NewBuffer(make([]byte, 8))
+
+	c := buf.Cursor(0x00)
+	c.WriteFixedLENext(Q15, -0.25)
+	c.WriteFixedBENext(Q16_16, 3.75)
+	if c.ByteOffset() != 0x06 || !cmp.Equal([]byte{0x00, 0xE0, 0x00, 0x03, 0xC0, 0x00, 0x00, 0x00}, buf.Bytes()) {
+
+		t.Fatalf("unexpected cursor state (at %d, holding %#v)", c.ByteOffset(), buf.Bytes())
+
+	}
+
+	c.SeekByte(0x00, false)
+	if out := [2]float64{c.ReadFixedLENext(Q15), c.ReadFixedBENext(Q16_16)}; out != [2]float64{-0.25, 3.75} {
+
+		t.Fatalf("expected float64s do not match the ones gotten (got %v)", out)
+
+	}
+
+	q := QFormat{Width: 12, Frac: 4, Signed: true}
+	c.SeekBit(0x03, false)
+	c.SetFixedBitsNext(q, -7.5625)
+	c.SeekBit(0x03, false)
+	if out := c.ReadFixedBitsNext(q); out != -7.5625 || c.BitOffset() != 0x0F {
+
+		t.Fatalf("expected float64 does not match the one gotten (got %v at %d, expected %v)", out, c.BitOffset(), -7.5625)
+
+	}
+
+}
+
+func TestReaderBufferFixed(t *testing.T) {
+
+	buf := NewReaderBuffer(iotest.OneByteReader(bytes.NewReader([]byte{0x00, 0xE0, 0x00, 0x03, 0xC0, 0x00, 0x12, 0x30})))
+
+	if out := [2]float64{buf.ReadFixedLENext(Q15), buf.ReadFixedBENext(Q16_16)}; out != [2]float64{-0.25, 3.75} {
+
+		t.Fatalf("expected float64s do not match the ones gotten (got %v)", out)
+
+	}
+
+	buf.AlignBit()
+	if out := buf.ReadFixedBitsNext(QFormat{Width: 12, Frac: 4}); out != 0x123/16.0 {
+
+		t.Fatalf("expected float64 does not match the one gotten (got %v, expected %v)", out, 0x123/16.0)
+
+	}
+
+}
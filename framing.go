@@ -0,0 +1,505 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import "bytes"
+
+// Framing implements a method of delimiting packets on a serial link
+type Framing interface {
+	// AppendFrame grows the buffer and writes data to the end of it
+	// as a single frame without modifying the internal offset values
+	AppendFrame(b *Buffer, data []byte)
+
+	// ReadFrameNext decodes the next complete frame located at the
+	// buffer's current offset and moves the offset past it. ok is
+	// false and the offset is left untouched if the buffer does not
+	// contain a complete frame yet
+	ReadFrameNext(b *Buffer) (frame []byte, ok bool, err error)
+}
+
+var (
+	// COBSFraming implements consistent overhead byte stuffing with
+	// a trailing zero delimiter
+	COBSFraming Framing = cobsFraming{}
+
+	// SLIPFraming implements the serial line internet protocol
+	// framing described in rfc 1055
+	SLIPFraming Framing = slipFraming{}
+
+	// HDLCFraming implements hdlc bit stuffing, where a 0 bit is
+	// inserted after every five consecutive 1 bits and frames are
+	// delimited by 0x7E flags. octets are sent least significant bit
+	// first and the last byte of a frame is padded with idle 1 bits
+	HDLCFraming Framing = hdlcFraming{}
+)
+
+/* cobs */
+
+type cobsFraming struct{}
+
+// EncodeCOBS returns data encoded with consistent overhead byte
+// stuffing, including the trailing zero delimiter
+func EncodeCOBS(data []byte) []byte {
+
+	var (
+		out  = make([]byte, 1, len(data)+len(data)/254+2)
+		code = byte(1)
+		last = 0
+	)
+
+	for i, c := range data {
+
+		if c == 0x00 {
+
+			out[last] = code
+			last = len(out)
+			out = append(out, 0x00)
+			code = 1
+			continue
+
+		}
+
+		out = append(out, c)
+		code++
+
+		if code == 0xFF && i+1 < len(data) {
+
+			out[last] = code
+			last = len(out)
+			out = append(out, 0x00)
+			code = 1
+
+		}
+
+	}
+
+	out[last] = code
+	return append(out, 0x00)
+
+}
+
+// DecodeCOBS returns the data held in a COBS frame. the frame must
+// not include the trailing zero delimiter
+func DecodeCOBS(frame []byte) (out []byte, err error) {
+
+	out = make([]byte, 0, len(frame))
+
+	i := 0
+	for i < len(frame) {
+
+		code := int(frame[i])
+		if code == 0x00 || i+code > len(frame) {
+
+			return nil, FramingInvalidCOBSError
+
+		}
+
+		block := frame[i+1 : i+code]
+		if bytes.IndexByte(block, 0x00) != -1 {
+
+			return nil, FramingInvalidCOBSError
+
+		}
+
+		out = append(out, block...)
+		i += code
+
+		if code < 0xFF && i < len(frame) {
+
+			out = append(out, 0x00)
+
+		}
+
+	}
+
+	return
+
+}
+
+func (cobsFraming) AppendFrame(b *Buffer, data []byte) {
+
+	b.appendBytes(EncodeCOBS(data))
+
+}
+
+func (cobsFraming) ReadFrameNext(b *Buffer) (frame []byte, ok bool, err error) {
+
+	return readDelimitedNext(b, 0x00, DecodeCOBS)
+
+}
+
+/* slip */
+
+const (
+	slipEnd    = 0xC0
+	slipEsc    = 0xDB
+	slipEscEnd = 0xDC
+	slipEscEsc = 0xDD
+)
+
+type slipFraming struct{}
+
+// EncodeSLIP returns data escaped as a SLIP frame, including the
+// trailing END delimiter
+func EncodeSLIP(data []byte) []byte {
+
+	out := make([]byte, 0, len(data)+len(data)/8+1)
+
+	for _, c := range data {
+
+		switch c {
+
+		case slipEnd:
+			out = append(out, slipEsc, slipEscEnd)
+
+		case slipEsc:
+			out = append(out, slipEsc, slipEscEsc)
+
+		default:
+			out = append(out, c)
+
+		}
+
+	}
+
+	return append(out, slipEnd)
+
+}
+
+// DecodeSLIP returns the data held in a SLIP frame. the frame must
+// not include the trailing END delimiter
+func DecodeSLIP(frame []byte) (out []byte, err error) {
+
+	out = make([]byte, 0, len(frame))
+
+	for i := 0; i < len(frame); i++ {
+
+		if frame[i] != slipEsc {
+
+			out = append(out, frame[i])
+			continue
+
+		}
+
+		i++
+		if i == len(frame) {
+
+			return nil, FramingInvalidSLIPEscapeError
+
+		}
+
+		switch frame[i] {
+
+		case slipEscEnd:
+			out = append(out, slipEnd)
+
+		case slipEscEsc:
+			out = append(out, slipEsc)
+
+		default:
+			return nil, FramingInvalidSLIPEscapeError
+
+		}
+
+	}
+
+	return
+
+}
+
+func (slipFraming) AppendFrame(b *Buffer, data []byte) {
+
+	b.appendBytes(EncodeSLIP(data))
+
+}
+
+func (slipFraming) ReadFrameNext(b *Buffer) (frame []byte, ok bool, err error) {
+
+	return readDelimitedNext(b, slipEnd, DecodeSLIP)
+
+}
+
+// readDelimitedNext decodes the next non-empty frame terminated by
+// delim at the buffer's current offset using decode
+func readDelimitedNext(b *Buffer, delim byte, decode func([]byte) ([]byte, error)) (frame []byte, ok bool, err error) {
+
+	off := b.off
+	for off < b.cap {
+
+		i := bytes.IndexByte(b.buf[off:b.cap], delim)
+		if i == -1 {
+
+			return nil, false, nil
+
+		}
+
+		raw := b.buf[off : off+int64(i)]
+		off += int64(i) + 1
+		if len(raw) == 0 {
+
+			continue
+
+		}
+
+		b.SeekByte(off, false)
+		b.AlignBit()
+
+		frame, err = decode(raw)
+		return frame, true, err
+
+	}
+
+	return nil, false, nil
+
+}
+
+/* hdlc */
+
+const hdlcFlag = 0x7E
+
+type hdlcFraming struct{}
+
+func (hdlcFraming) AppendFrame(b *Buffer, data []byte) {
+
+	var (
+		start = b.cap
+		nbits = int64(len(data)) * 8
+		off   = start * 8
+		ones  = 0
+	)
+
+	// two flags plus at most one stuffed bit for every five data bits
+	b.Grow((16 + nbits + nbits/5 + 7) / 8)
+
+	b.SetBits(off, hdlcFlag, 8)
+	off += 8
+
+	for _, c := range data {
+
+		for j := uint(0); j < 8; j++ {
+
+			if (c>>j)&1 == 1 {
+
+				b.SetBit(off)
+				ones++
+
+			} else {
+
+				b.ClearBit(off)
+				ones = 0
+
+			}
+			off++
+
+			if ones == 5 {
+
+				b.ClearBit(off)
+				off++
+				ones = 0
+
+			}
+
+		}
+
+	}
+
+	b.SetBits(off, hdlcFlag, 8)
+	off += 8
+
+	for off%8 != 0 {
+
+		b.SetBit(off)
+		off++
+
+	}
+
+	b.TruncateRight(b.cap - off/8)
+
+}
+
+func (hdlcFraming) ReadFrameNext(b *Buffer) (frame []byte, ok bool, err error) {
+
+	off := b.boff
+
+	// hunt for the opening flag, which may sit at any bit offset
+	for {
+
+		if off+8 > b.bcap {
+
+			return nil, false, nil
+
+		}
+
+		if b.ReadBits(off, 8) == hdlcFlag {
+
+			break
+
+		}
+		off++
+
+	}
+	off += 8
+
+	var (
+		bits []byte
+		ones = 0
+	)
+	for off < b.bcap {
+
+		bit := b.ReadBit(off)
+		off++
+
+		if bit == 0 {
+
+			// a 0 after five 1s was stuffed by the sender
+			if ones != 5 {
+
+				bits = append(bits, 0)
+
+			}
+			ones = 0
+			continue
+
+		}
+
+		ones++
+		if ones < 6 {
+
+			bits = append(bits, 1)
+			continue
+
+		}
+
+		if off == b.bcap {
+
+			break
+
+		}
+
+		if b.ReadBit(off) == 1 {
+
+			b.SeekBit(off+1, false)
+			b.AlignByte()
+			return nil, true, FramingHDLCAbortError
+
+		}
+		off++
+		ones = 0
+
+		// the leading 0 and first five 1s of the flag were collected
+		// as data before the flag could be recognized
+		if len(bits) < 6 {
+
+			bits = bits[:0]
+
+		} else {
+
+			bits = bits[:len(bits)-6]
+
+		}
+
+		// back-to-back flags delimit an empty frame, so the closing
+		// flag is treated as the opening flag of the next one
+		if len(bits) == 0 {
+
+			continue
+
+		}
+
+		// idle 1s can never begin a flag, so the padding up to the
+		// next byte boundary is consumed along with the frame
+		for off%8 != 0 && off < b.bcap && b.ReadBit(off) == 1 {
+
+			off++
+
+		}
+
+		b.SeekBit(off, false)
+		b.AlignByte()
+
+		if len(bits)%8 != 0 {
+
+			return nil, true, FramingHDLCMisalignedError
+
+		}
+
+		frame = make([]byte, len(bits)/8)
+		for i, bit := range bits {
+
+			frame[i/8] |= bit << uint(i%8)
+
+		}
+		return frame, true, nil
+
+	}
+
+	return nil, false, nil
+
+}
+
+/* streaming */
+
+// FrameScanner implements a growing buffer that complete frames can
+// be pulled out of as data arrives from a stream
+type FrameScanner struct {
+	buf     *Buffer
+	framing Framing
+}
+
+// NewFrameScanner initializes a new FrameScanner that decodes frames
+// using the provided framing
+func NewFrameScanner(framing Framing) *FrameScanner {
+
+	return &FrameScanner{
+		buf:     NewBuffer(),
+		framing: framing,
+	}
+
+}
+
+// Write appends data received from the stream to the scanner. it
+// never returns an error
+func (s *FrameScanner) Write(data []byte) (int, error) {
+
+	s.buf.appendBytes(data)
+	return len(data), nil
+
+}
+
+// Next returns the next complete frame held by the scanner and
+// discards the bytes it occupied. ok is false if no complete frame
+// has been received yet. a malformed frame is discarded and its
+// error returned with ok set to true
+func (s *FrameScanner) Next() (frame []byte, ok bool, err error) {
+
+	frame, ok, err = s.framing.ReadFrameNext(s.buf)
+	if !ok {
+
+		return
+
+	}
+
+	n := s.buf.off
+	s.buf.TruncateLeft(n)
+	s.buf.SeekByte(-n, true)
+	s.buf.SeekBit(-n*8, true)
+	return
+
+}
+
+// Buffered returns the amount of bytes held by the scanner that have
+// not been returned as part of a frame
+func (s *FrameScanner) Buffered() int64 {
+
+	return s.buf.cap
+
+}
@@ -0,0 +1,257 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+utilities
+
+*/
+
+func byteRange(start, end int) (out []byte) {
+
+	for i := start; i <= end; i++ {
+
+		out = append(out, byte(i))
+
+	}
+	return
+
+}
+
+/*
+
+tests
+
+*/
+
+func TestEncodeCOBS(t *testing.T) {
+
+	var cases = []struct {
+		in, expected []byte
+	}{
+		{[]byte{0x00}, []byte{0x01, 0x01, 0x00}},
+		{[]byte{0x00, 0x00}, []byte{0x01, 0x01, 0x01, 0x00}},
+		{[]byte{0x11, 0x22, 0x00, 0x33}, []byte{0x03, 0x11, 0x22, 0x02, 0x33, 0x00}},
+		{[]byte{0x11, 0x22, 0x33, 0x44}, []byte{0x05, 0x11, 0x22, 0x33, 0x44, 0x00}},
+		{byteRange(0x01, 0xFE), append(append([]byte{0xFF}, byteRange(0x01, 0xFE)...), 0x00)},
+		{byteRange(0x00, 0xFE), append(append([]byte{0x01, 0xFF}, byteRange(0x01, 0xFE)...), 0x00)},
+		{byteRange(0x01, 0xFF), append(append([]byte{0xFF}, byteRange(0x01, 0xFE)...), 0x02, 0xFF, 0x00)},
+	}
+
+	for _, c := range cases {
+
+		out := EncodeCOBS(c.in)
+		if !cmp.Equal(c.expected, out) {
+
+			t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", out, c.expected)
+
+		}
+
+		decoded, err := DecodeCOBS(out[:len(out)-1])
+		if err != nil {
+
+			t.Fatalf("unexpected error while decoding (%s)", err)
+
+		}
+
+		if !cmp.Equal(c.in, decoded) {
+
+			t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", decoded, c.in)
+
+		}
+
+	}
+
+}
+
+func TestDecodeCOBSInvalid(t *testing.T) {
+
+	for _, in := range [][]byte{{0x05, 0x11}, {0x03, 0x00, 0x11}} {
+
+		_, err := DecodeCOBS(in)
+		if err != FramingInvalidCOBSError {
+
+			t.Fatalf("expected error does not match the one gotten (got %v, expected %v)", err, FramingInvalidCOBSError)
+
+		}
+
+	}
+
+}
+
+func TestEncodeSLIP(t *testing.T) {
+
+	var (
+		in       = []byte{0x01, 0xC0, 0x02, 0xDB, 0x03}
+		expected = []byte{0x01, 0xDB, 0xDC, 0x02, 0xDB, 0xDD, 0x03, 0xC0}
+	)
+
+	out := EncodeSLIP(in)
+	if !cmp.Equal(expected, out) {
+
+		t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", out, expected)
+
+	}
+
+	decoded, err := DecodeSLIP(out[:len(out)-1])
+	if err != nil {
+
+		t.Fatalf("unexpected error while decoding (%s)", err)
+
+	}
+
+	if !cmp.Equal(in, decoded) {
+
+		t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", decoded, in)
+
+	}
+
+	_, err = DecodeSLIP([]byte{0x01, 0xDB, 0x02})
+	if err != FramingInvalidSLIPEscapeError {
+
+		t.Fatalf("expected error does not match the one gotten (got %v, expected %v)", err, FramingInvalidSLIPEscapeError)
+
+	}
+
+}
+
+func TestHDLCFramingAppendFrame(t *testing.T) {
+
+	// 0xFF is sent as eight 1 bits, so a 0 is stuffed after the fifth
+	// one, and the trailing 7 bits are padded with idle 1s
+	expected := []byte{0x7E, 0xFB, 0xBF, 0x7F}
+
+	buf := NewBuffer()
+	HDLCFraming.AppendFrame(buf, []byte{0xFF})
+
+	if !cmp.Equal(expected, buf.Bytes()) {
+
+		t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", buf.Bytes(), expected)
+
+	}
+
+}
+
+func TestFramingRoundTrip(t *testing.T) {
+
+	frames := [][]byte{
+		{0x7E, 0x7E, 0x7E},
+		{0x00, 0xC0, 0xDB, 0xFF, 0xFF, 0x01},
+		byteRange(0x00, 0xFF),
+	}
+
+	for _, framing := range []Framing{COBSFraming, SLIPFraming, HDLCFraming} {
+
+		buf := NewBuffer()
+		for _, frame := range frames {
+
+			framing.AppendFrame(buf, frame)
+
+		}
+
+		for _, expected := range frames {
+
+			out, ok, err := framing.ReadFrameNext(buf)
+			if !ok || err != nil {
+
+				t.Fatalf("expected a complete frame (got ok %t, err %v)", ok, err)
+
+			}
+
+			if !cmp.Equal(expected, out) {
+
+				t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", out, expected)
+
+			}
+
+		}
+
+		_, ok, _ := framing.ReadFrameNext(buf)
+		if ok {
+
+			t.Fatalf("expected no frame to be left in the buffer")
+
+		}
+
+	}
+
+}
+
+func TestHDLCFramingAbort(t *testing.T) {
+
+	buf := NewBuffer([]byte{0x7E, 0x00, 0xFF, 0xFF})
+
+	_, ok, err := HDLCFraming.ReadFrameNext(buf)
+	if !ok || err != FramingHDLCAbortError {
+
+		t.Fatalf("expected error does not match the one gotten (got %v, expected %v)", err, FramingHDLCAbortError)
+
+	}
+
+}
+
+func TestFrameScanner(t *testing.T) {
+
+	for _, framing := range []Framing{COBSFraming, SLIPFraming, HDLCFraming} {
+
+		var (
+			encoded = NewBuffer()
+			scanner = NewFrameScanner(framing)
+		)
+
+		framing.AppendFrame(encoded, []byte{0x01, 0x02, 0x03})
+		framing.AppendFrame(encoded, []byte{0x00, 0xC0, 0x7E})
+
+		// feed the stream in one byte at a time
+		var out [][]byte
+		for _, c := range encoded.Bytes() {
+
+			_, _ = scanner.Write([]byte{c})
+
+			frame, ok, err := scanner.Next()
+			if err != nil {
+
+				t.Fatalf("unexpected error while scanning (%s)", err)
+
+			}
+
+			if ok {
+
+				out = append(out, frame)
+
+			}
+
+		}
+
+		expected := [][]byte{{0x01, 0x02, 0x03}, {0x00, 0xC0, 0x7E}}
+		if !cmp.Equal(expected, out) {
+
+			t.Fatalf("expected frames do not match the ones gotten (got %#v, expected %#v)", out, expected)
+
+		}
+
+		if scanner.Buffered() != 0 {
+
+			t.Fatalf("expected scanner to be empty (got %d bytes)", scanner.Buffered())
+
+		}
+
+	}
+
+}
@@ -360,22 +360,7 @@ func (b *MiniBuffer) WriteBytesNext(data []byte) {
 // offset value
 func (b *MiniBuffer) WriteU16LE(off int64, data []uint16) {
 
-	var (
-		i = 0
-		n = len(data)
-	)
-	{
-	write_loop:
-		b.buf[off+int64(i*2)] = byte(data[i])
-		b.buf[off+int64(1+(i*2))] = byte(data[i] >> 8)
-
-		i++
-		if i < n {
-
-			goto write_loop
-
-		}
-	}
+	bulkWriteU16(b.buf[off:off+int64(len(data))*2], data, false)
 
 }
 
@@ -394,22 +379,7 @@ func (b *MiniBuffer) WriteU16LENext(data []uint16) {
 // offset value
 func (b *MiniBuffer) WriteU16BE(off int64, data []uint16) {
 
-	var (
-		i = 0
-		n = len(data)
-	)
-	{
-	write_loop:
-		b.buf[off+int64(i*2)] = byte(data[i] >> 8)
-		b.buf[off+int64(1+(i*2))] = byte(data[i])
-
-		i++
-		if i < n {
-
-			goto write_loop
-
-		}
-	}
+	bulkWriteU16(b.buf[off:off+int64(len(data))*2], data, true)
 
 }
 
@@ -428,24 +398,7 @@ func (b *MiniBuffer) WriteU16BENext(data []uint16) {
 // offset value
 func (b *MiniBuffer) WriteU32LE(off int64, data []uint32) {
 
-	var (
-		i = 0
-		n = len(data)
-	)
-	{
-	write_loop:
-		b.buf[off+int64(i*4)] = byte(data[i])
-		b.buf[off+int64(1+(i*4))] = byte(data[i] >> 8)
-		b.buf[off+int64(2+(i*4))] = byte(data[i] >> 16)
-		b.buf[off+int64(3+(i*4))] = byte(data[i] >> 24)
-
-		i++
-		if i < n {
-
-			goto write_loop
-
-		}
-	}
+	bulkWriteU32(b.buf[off:off+int64(len(data))*4], data, false)
 
 }
 
@@ -464,24 +417,7 @@ func (b *MiniBuffer) WriteU32LENext(data []uint32) {
 // offset value
 func (b *MiniBuffer) WriteU32BE(off int64, data []uint32) {
 
-	var (
-		i = 0
-		n = len(data)
-	)
-	{
-	write_loop:
-		b.buf[off+int64(i*4)] = byte(data[i] >> 24)
-		b.buf[off+int64(1+(i*4))] = byte(data[i] >> 16)
-		b.buf[off+int64(2+(i*4))] = byte(data[i] >> 8)
-		b.buf[off+int64(3+(i*4))] = byte(data[i])
-
-		i++
-		if i < n {
-
-			goto write_loop
-
-		}
-	}
+	bulkWriteU32(b.buf[off:off+int64(len(data))*4], data, true)
 
 }
 
@@ -499,28 +435,7 @@ func (b *MiniBuffer) WriteU32BENext(data []uint32) {
 // offset in little-endian without modifying the internal offset value
 func (b *MiniBuffer) WriteU64LE(off int64, data []uint64) {
 
-	var (
-		i = 0
-		n = len(data)
-	)
-	{
-	write_loop:
-		b.buf[off+int64(i*8)] = byte(data[i])
-		b.buf[off+int64(1+(i*8))] = byte(data[i] >> 8)
-		b.buf[off+int64(2+(i*8))] = byte(data[i] >> 16)
-		b.buf[off+int64(3+(i*8))] = byte(data[i] >> 24)
-		b.buf[off+int64(4+(i*8))] = byte(data[i] >> 32)
-		b.buf[off+int64(5+(i*8))] = byte(data[i] >> 40)
-		b.buf[off+int64(6+(i*8))] = byte(data[i] >> 48)
-		b.buf[off+int64(7+(i*8))] = byte(data[i] >> 56)
-
-		i++
-		if i < n {
-
-			goto write_loop
-
-		}
-	}
+	bulkWriteU64(b.buf[off:off+int64(len(data))*8], data, false)
 
 }
 
@@ -539,28 +454,7 @@ func (b *MiniBuffer) WriteU64LENext(data []uint64) {
 // offset value
 func (b *MiniBuffer) WriteU64BE(off int64, data []uint64) {
 
-	var (
-		i = 0
-		n = len(data)
-	)
-	{
-	write_loop:
-		b.buf[off+int64(i*8)] = byte(data[i] >> 56)
-		b.buf[off+int64(1+(i*8))] = byte(data[i] >> 48)
-		b.buf[off+int64(2+(i*8))] = byte(data[i] >> 40)
-		b.buf[off+int64(3+(i*8))] = byte(data[i] >> 32)
-		b.buf[off+int64(4+(i*8))] = byte(data[i] >> 24)
-		b.buf[off+int64(5+(i*8))] = byte(data[i] >> 16)
-		b.buf[off+int64(6+(i*8))] = byte(data[i] >> 8)
-		b.buf[off+int64(7+(i*8))] = byte(data[i])
-
-		i++
-		if i < n {
-
-			goto write_loop
-
-		}
-	}
+	bulkWriteU64(b.buf[off:off+int64(len(data))*8], data, true)
 
 }
 
@@ -596,19 +490,7 @@ func (b *MiniBuffer) ReadBytesNext(out *[]byte, n int64) {
 // offset value
 func (b *MiniBuffer) ReadU16LE(out *[]uint16, off, n int64) {
 
-	i := int64(0)
-	{
-	read_loop:
-		(*out)[i] = uint16(b.buf[off+(i*2)]) |
-			uint16(b.buf[off+(1+(i*2))])<<8
-
-		i++
-		if i < n {
-
-			goto read_loop
-
-		}
-	}
+	bulkReadU16((*out)[:n], b.buf[off:off+n*2], false)
 
 }
 
@@ -627,19 +509,7 @@ func (b *MiniBuffer) ReadU16LENext(out *[]uint16, n int64) {
 // offset value
 func (b *MiniBuffer) ReadU16BE(out *[]uint16, off, n int64) {
 
-	i := int64(0)
-	{
-	read_loop:
-		(*out)[i] = uint16(b.buf[off+(1+(i*2))]) |
-			uint16(b.buf[off+(i*2)])<<8
-
-		i++
-		if i < n {
-
-			goto read_loop
-
-		}
-	}
+	bulkReadU16((*out)[:n], b.buf[off:off+n*2], true)
 
 }
 
@@ -658,21 +528,7 @@ func (b *MiniBuffer) ReadU16BENext(out *[]uint16, n int64) {
 // offset value
 func (b *MiniBuffer) ReadU32LE(out *[]uint32, off, n int64) {
 
-	i := int64(0)
-	{
-	read_loop:
-		(*out)[i] = uint32(b.buf[off+(i*4)]) |
-			uint32(b.buf[off+(1+(i*4))])<<8 |
-			uint32(b.buf[off+(2+(i*4))])<<16 |
-			uint32(b.buf[off+(3+(i*4))])<<24
-
-		i++
-		if i < n {
-
-			goto read_loop
-
-		}
-	}
+	bulkReadU32((*out)[:n], b.buf[off:off+n*4], false)
 
 }
 
@@ -691,21 +547,7 @@ func (b *MiniBuffer) ReadU32LENext(out *[]uint32, n int64) {
 // offset value
 func (b *MiniBuffer) ReadU32BE(out *[]uint32, off, n int64) {
 
-	i := int64(0)
-	{
-	read_loop:
-		(*out)[i] = uint32(b.buf[off+(3+(i*4))]) |
-			uint32(b.buf[off+(2+(i*4))])<<8 |
-			uint32(b.buf[off+(1+(i*4))])<<16 |
-			uint32(b.buf[off+(i*4)])<<24
-
-		i++
-		if i < n {
-
-			goto read_loop
-
-		}
-	}
+	bulkReadU32((*out)[:n], b.buf[off:off+n*4], true)
 
 }
 
@@ -724,25 +566,7 @@ func (b *MiniBuffer) ReadU32BENext(out *[]uint32, n int64) {
 // offset value
 func (b *MiniBuffer) ReadU64LE(out *[]uint64, off, n int64) {
 
-	i := int64(0)
-	{
-	read_loop:
-		(*out)[i] = uint64(b.buf[off+(i*8)]) |
-			uint64(b.buf[off+(1+(i*8))])<<8 |
-			uint64(b.buf[off+(2+(i*8))])<<16 |
-			uint64(b.buf[off+(3+(i*8))])<<24 |
-			uint64(b.buf[off+(4+(i*8))])<<32 |
-			uint64(b.buf[off+(5+(i*8))])<<40 |
-			uint64(b.buf[off+(6+(i*8))])<<48 |
-			uint64(b.buf[off+(7+(i*8))])<<56
-
-		i++
-		if i < n {
-
-			goto read_loop
-
-		}
-	}
+	bulkReadU64((*out)[:n], b.buf[off:off+n*8], false)
 
 }
 
@@ -761,25 +585,7 @@ func (b *MiniBuffer) ReadU64LENext(out *[]uint64, n int64) {
 // offset value
 func (b *MiniBuffer) ReadU64BE(out *[]uint64, off, n int64) {
 
-	i := int64(0)
-	{
-	read_loop:
-		(*out)[i] = uint64(b.buf[off+(7+(i*8))]) |
-			uint64(b.buf[off+(6+(i*8))])<<8 |
-			uint64(b.buf[off+(5+(i*8))])<<16 |
-			uint64(b.buf[off+(4+(i*8))])<<24 |
-			uint64(b.buf[off+(3+(i*8))])<<32 |
-			uint64(b.buf[off+(2+(i*8))])<<40 |
-			uint64(b.buf[off+(1+(i*8))])<<48 |
-			uint64(b.buf[off+(i*8)])<<56
-
-		i++
-		if i < n {
-
-			goto read_loop
-
-		}
-	}
+	bulkReadU64((*out)[:n], b.buf[off:off+n*8], true)
 
 }
 
@@ -0,0 +1,25 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+// MapMode represents the way a file is mapped into memory
+type MapMode int
+
+const (
+	// MapReadOnly maps a file without ever writing to it. writes made
+	// to the buffer are kept private to the process
+	MapReadOnly MapMode = iota
+
+	// MapReadWrite maps a file so that writes made to the buffer are
+	// carried through to it
+	MapReadWrite
+)
@@ -0,0 +1,222 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// MappedBuffer implements a Buffer backed by a memory-mapped file.
+// truncating or resetting it only narrows the view of the mapping,
+// while growing it widens the view again, extending the file and
+// remapping it once the view runs past the end of the file
+type MappedBuffer struct {
+	*Buffer
+
+	file *os.File
+	mode MapMode
+	data []byte
+}
+
+// NewMappedBuffer initializes a new MappedBuffer that maps the entire
+// file located at path into memory
+func NewMappedBuffer(path string, mode MapMode) (m *MappedBuffer, err error) {
+
+	flag := os.O_RDWR
+	if mode == MapReadOnly {
+
+		flag = os.O_RDONLY
+
+	}
+
+	file, err := os.OpenFile(path, flag, 0)
+	if err != nil {
+
+		return
+
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+
+		_ = file.Close()
+		return
+
+	}
+
+	m = &MappedBuffer{
+		Buffer: NewBuffer(),
+		file:   file,
+		mode:   mode,
+	}
+	m.Buffer.grow = m.growFile
+
+	if err = m.mapFile(0, info.Size(), info.Size()); err != nil {
+
+		_ = file.Close()
+		return nil, err
+
+	}
+
+	return
+
+}
+
+// mapFile replaces the current mapping with one covering size bytes
+// of the file, with the buffer viewing the mapping from start to end
+func (m *MappedBuffer) mapFile(start, end, size int64) (err error) {
+
+	if m.data != nil {
+
+		if err = syscall.Munmap(m.data); err != nil {
+
+			return
+
+		}
+		m.data = nil
+
+	}
+
+	if size == 0 {
+
+		m.Buffer.buf = []byte{}
+		m.Buffer.Refresh()
+		return
+
+	}
+
+	// read-only mappings are private and writable so that stray writes
+	// land in copy-on-write pages instead of faulting
+	var (
+		prot  = syscall.PROT_READ | syscall.PROT_WRITE
+		flags = syscall.MAP_SHARED
+	)
+	if m.mode == MapReadOnly {
+
+		flags = syscall.MAP_PRIVATE
+
+	}
+
+	m.data, err = syscall.Mmap(int(m.file.Fd()), 0, int(size), prot, flags)
+	if err != nil {
+
+		return
+
+	}
+
+	m.Buffer.buf = m.data[start:end]
+	m.Buffer.Refresh()
+	return
+
+}
+
+// growFile widens the view of the mapping by n bytes, extending the
+// file and remapping it when the view would run past its end
+func (m *MappedBuffer) growFile(n int64) {
+
+	if m.mode == MapReadOnly {
+
+		panic(MappedBufferReadOnlyError)
+
+	}
+
+	// the view may have been narrowed by truncating or resetting the
+	// buffer, so it is widened from where it ends now. the file itself
+	// is never cut down, which leaves the data past the view intact
+	var (
+		start = int64(cap(m.data) - cap(m.Buffer.buf))
+		end   = start + m.Buffer.cap + n
+	)
+
+	info, err := m.file.Stat()
+	if err != nil {
+
+		panic(err)
+
+	}
+
+	size := info.Size()
+	if end > size {
+
+		if err = m.file.Truncate(end); err != nil {
+
+			panic(err)
+
+		}
+		size = end
+
+	}
+
+	if err = m.mapFile(start, end, size); err != nil {
+
+		panic(err)
+
+	}
+
+}
+
+// Sync flushes changes made to the mapping back to the file. it does
+// nothing for read-only mappings
+func (m *MappedBuffer) Sync() error {
+
+	if m.mode == MapReadOnly || len(m.data) == 0 {
+
+		return nil
+
+	}
+
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_MSYNC,
+		uintptr(unsafe.Pointer(&m.data[0])),
+		uintptr(len(m.data)),
+		syscall.MS_SYNC,
+	)
+	if errno != 0 {
+
+		return errno
+
+	}
+	return nil
+
+}
+
+// Close flushes and unmaps the file before closing it. the buffer is
+// empty afterwards
+func (m *MappedBuffer) Close() (err error) {
+
+	err = m.Sync()
+
+	if m.data != nil {
+
+		if uerr := syscall.Munmap(m.data); uerr != nil && err == nil {
+
+			err = uerr
+
+		}
+		m.data = nil
+
+	}
+
+	if cerr := m.file.Close(); cerr != nil && err == nil {
+
+		err = cerr
+
+	}
+
+	m.Buffer.buf = []byte{}
+	m.Buffer.grow = nil
+	m.Buffer.Refresh()
+	return
+
+}
@@ -0,0 +1,260 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+utilities
+
+*/
+
+func tempFile(t *testing.T, data []byte) (path string, cleanup func()) {
+
+	dir, err := ioutil.TempDir("", "crunch")
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	path = filepath.Join(dir, "mapped")
+	if err = ioutil.WriteFile(path, data, 0644); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	return path, func() { _ = os.RemoveAll(dir) }
+
+}
+
+/*
+
+tests
+
+*/
+
+func TestMappedBufferReadWrite(t *testing.T) {
+
+	expected := []byte{0x01, 0xBE, 0xEF, 0x04, 0x05, 0x06, 0x00, 0x00, 0xCA, 0xFE}
+
+	path, cleanup := tempFile(t, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06})
+	defer cleanup()
+
+	buf, err := NewMappedBuffer(path, MapReadWrite)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if buf.ReadU32BE(0x00, 1)[0] != 0x01020304 {
+
+		t.Fatalf("expected mapped data does not match the one gotten (got %#v)", buf.Bytes())
+
+	}
+
+	buf.WriteU16BE(0x01, []uint16{0xBEEF})
+
+	// growing remaps the file, so the write has to go through the new
+	// mapping
+	buf.Grow(4)
+	buf.SeekByte(0x08, false)
+	buf.WriteBytesNext([]byte{0xCA, 0xFE})
+
+	if err = buf.Close(); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if !cmp.Equal(expected, out) {
+
+		t.Fatalf("expected file contents do not match the ones gotten (got %#v, expected %#v)", out, expected)
+
+	}
+
+}
+
+func TestMappedBufferGrowTruncated(t *testing.T) {
+
+	path, cleanup := tempFile(t, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08})
+	defer cleanup()
+
+	buf, err := NewMappedBuffer(path, MapReadWrite)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	// the view is widened from where it was truncated, without
+	// touching the file
+	buf.TruncateRight(4)
+	buf.Grow(1)
+	buf.TruncateLeft(2)
+	buf.Grow(1)
+
+	if expected := []byte{0x03, 0x04, 0x05, 0x06}; !cmp.Equal(expected, buf.Bytes()) {
+
+		t.Fatalf("expected mapped data does not match the one gotten (got %#v, expected %#v)", buf.Bytes(), expected)
+
+	}
+
+	// growing past the end of the file extends it
+	buf.Grow(3)
+	buf.WriteByte(0x06, 0xFF)
+
+	// a reset buffer keeps the data of the file
+	buf.Reset()
+	buf.Grow(2)
+
+	if expected := []byte{0x03, 0x04}; !cmp.Equal(expected, buf.Bytes()) {
+
+		t.Fatalf("expected mapped data does not match the one gotten (got %#v, expected %#v)", buf.Bytes(), expected)
+
+	}
+
+	if err = buf.Close(); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if expected := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0xFF}; !cmp.Equal(expected, out) {
+
+		t.Fatalf("expected file contents do not match the ones gotten (got %#v, expected %#v)", out, expected)
+
+	}
+
+}
+
+func TestMappedBufferReadOnly(t *testing.T) {
+
+	expected := []byte{0x01, 0x02, 0x03, 0x04}
+
+	path, cleanup := tempFile(t, expected)
+	defer cleanup()
+
+	buf, err := NewMappedBuffer(path, MapReadOnly)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	buf.WriteByte(0x00, 0xFF)
+	if buf.ReadByte(0x00) != 0xFF {
+
+		t.Fatalf("expected private write to be visible through the buffer")
+
+	}
+
+	if err = buf.Close(); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if !cmp.Equal(expected, out) {
+
+		t.Fatalf("expected file contents do not match the ones gotten (got %#v, expected %#v)", out, expected)
+
+	}
+
+}
+
+func TestMappedBufferEmpty(t *testing.T) {
+
+	path, cleanup := tempFile(t, nil)
+	defer cleanup()
+
+	buf, err := NewMappedBuffer(path, MapReadWrite)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+	defer func() { _ = buf.Close() }()
+
+	buf.Grow(2)
+	buf.WriteU16LE(0x00, []uint16{0x1234})
+
+	if err = buf.Sync(); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if !cmp.Equal([]byte{0x34, 0x12}, out) {
+
+		t.Fatalf("expected file contents do not match the ones gotten (got %#v, expected %#v)", out, []byte{0x34, 0x12})
+
+	}
+
+}
+
+func TestMappedBufferGrowPanic(t *testing.T) {
+
+	path, cleanup := tempFile(t, []byte{0x00})
+	defer cleanup()
+
+	buf, err := NewMappedBuffer(path, MapReadOnly)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+	defer func() { _ = buf.Close() }()
+
+	defer panicChecker(t, MappedBufferReadOnlyError)
+
+	buf.Grow(1)
+
+}
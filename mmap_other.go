@@ -0,0 +1,42 @@
+//go:build !linux
+// +build !linux
+
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+// MappedBuffer implements a Buffer backed by a memory-mapped file. it
+// is only supported on linux
+type MappedBuffer struct {
+	*Buffer
+}
+
+// NewMappedBuffer always fails on platforms other than linux
+func NewMappedBuffer(path string, mode MapMode) (*MappedBuffer, error) {
+
+	return nil, MappedBufferUnsupportedError
+
+}
+
+// Sync does nothing on platforms other than linux
+func (m *MappedBuffer) Sync() error {
+
+	return nil
+
+}
+
+// Close does nothing on platforms other than linux
+func (m *MappedBuffer) Close() error {
+
+	return nil
+
+}
@@ -0,0 +1,326 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import "math"
+
+// PackedLayout represents how the values of a packed integer array
+// are laid out in memory
+type PackedLayout int
+
+const (
+	// PackedMSB stores values back to back, spanning byte boundaries,
+	// starting from the most significant bit of each byte. this is the
+	// bit order ReadBits uses
+	PackedMSB PackedLayout = iota
+
+	// PackedLSB stores values back to back, spanning byte boundaries,
+	// starting from the least significant bit of each byte, as 12-bit
+	// fat entries and deflate streams do
+	PackedLSB
+
+	// PackedWordsMSB stores values in big endian 64-bit words, starting
+	// from the most significant bit of each word. values never span
+	// two words and the bits left over at the end of a word are unused
+	PackedWordsMSB
+
+	// PackedWordsLSB stores values in big endian 64-bit words, starting
+	// from the least significant bit of each word. values never span
+	// two words, as in the block states of minecraft chunks
+	PackedWordsLSB
+)
+
+// PackedSize returns the amount of bytes taken up by count values of
+// the specified width in bits stored with layout
+func PackedSize(width, count int64, layout PackedLayout) int64 {
+
+	if width < 1 || width > 64 || count < 0 {
+
+		panic(BufferInvalidBitCountError)
+
+	}
+
+	// counts whose size does not fit an int64 cannot be stored
+	if layout == PackedWordsMSB || layout == PackedWordsLSB {
+
+		per := 64 / width
+		words := count / per
+		if count%per != 0 {
+
+			words++
+
+		}
+
+		if words > math.MaxInt64/8 {
+
+			panic(BufferInvalidByteCountError)
+
+		}
+		return words * 8
+
+	}
+
+	if count > (math.MaxInt64-7)/width {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+	return (width*count + 7) / 8
+
+}
+
+/* internal use methods */
+
+// packedCheck panics if count values of the specified width stored
+// with layout at off do not fit in the buffer, using over and under
+// as the errors to panic with
+func (b *Buffer) packedCheck(off, width, count int64, layout PackedLayout, over, under Error) {
+
+	size := PackedSize(width, count, layout)
+	if (off + size) > b.cap {
+
+		panic(over.at(off, size, b.cap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(under.at(off, size, b.cap))
+
+	}
+
+}
+
+// packedBit returns the bit offset relative to off of the value at
+// index i. for the word layouts, which are stored in big endian, the
+// offset counts from the most significant bit of the first word
+func packedBit(width, i int64, layout PackedLayout) int64 {
+
+	switch layout {
+
+	case PackedWordsMSB:
+		per := 64 / width
+		return i/per*64 + i%per*width
+
+	case PackedWordsLSB:
+		per := 64 / width
+		return i/per*64 + 64 - (i%per+1)*width
+
+	}
+	return i * width
+
+}
+
+// packedGet returns the value of the specified width located at the
+// bit offset p relative to off
+func (b *Buffer) packedGet(off, p, width int64, layout PackedLayout) (v uint64) {
+
+	if layout == PackedLSB {
+
+		shift := uint64(0)
+		for width > 0 {
+
+			var (
+				c    = uint64(b.buf[off+p/8])
+				skip = p % 8
+				take = 8 - skip
+			)
+
+			if take > width {
+
+				take = width
+
+			}
+
+			v |= (c >> uint64(skip)) & (0xFF >> uint64(8-take)) << shift
+			shift += uint64(take)
+			p += take
+			width -= take
+
+		}
+		return
+
+	}
+
+	// the word layouts are stored in big endian, so the value is laid
+	// out in the same order as PackedMSB
+	for width > 0 {
+
+		var (
+			c     = uint64(b.buf[off+p/8])
+			avail = 8 - p%8
+			take  = avail
+		)
+
+		if take > width {
+
+			take = width
+
+		}
+
+		v = (v << uint64(take)) | (c>>uint64(avail-take))&(0xFF>>uint64(8-take))
+		p += take
+		width -= take
+
+	}
+	return
+
+}
+
+// packedSet stores the low width bits of v at the bit offset p
+// relative to off
+func (b *Buffer) packedSet(off, p, width int64, v uint64, layout PackedLayout) {
+
+	if layout == PackedLSB {
+
+		for width > 0 {
+
+			var (
+				i    = off + p/8
+				skip = p % 8
+				take = 8 - skip
+			)
+
+			if take > width {
+
+				take = width
+
+			}
+
+			mask := byte(0xFF>>uint64(8-take)) << uint64(skip)
+			b.buf[i] = (b.buf[i] &^ mask) | (byte(v)<<uint64(skip))&mask
+			v >>= uint64(take)
+			p += take
+			width -= take
+
+		}
+		return
+
+	}
+
+	for width > 0 {
+
+		var (
+			i     = off + p/8
+			avail = 8 - p%8
+			take  = avail
+		)
+
+		if take > width {
+
+			take = width
+
+		}
+
+		var (
+			shift = uint64(avail - take)
+			mask  = byte(0xFF>>uint64(8-take)) << shift
+		)
+
+		width -= take
+		b.buf[i] = (b.buf[i] &^ mask) | byte(v>>uint64(width))<<shift&mask
+		p += take
+
+	}
+
+}
+
+/* packed integer methods */
+
+// ReadPackedUints reads count unsigned integers of the specified width
+// in bits, stored with layout at the specified offset, without
+// modifying the internal offset value. width must be between 1 and 64
+func (b *Buffer) ReadPackedUints(off, width, count int64, layout PackedLayout) (out []uint64) {
+
+	b.packedCheck(off, width, count, layout, BufferOverreadError, BufferUnderreadError)
+
+	out = make([]uint64, count)
+	for i := range out {
+
+		out[i] = b.packedGet(off, packedBit(width, int64(i), layout), width, layout)
+
+	}
+	return
+
+}
+
+// ReadPackedUintsNext reads count unsigned integers of the specified
+// width in bits stored with layout at the current offset and moves the
+// offset forward the amount of bytes they take up
+func (b *Buffer) ReadPackedUintsNext(width, count int64, layout PackedLayout) (out []uint64) {
+
+	out = b.ReadPackedUints(b.off, width, count, layout)
+	b.traced(b.off*8, PackedSize(width, count, layout)*8)
+	b.SeekByte(PackedSize(width, count, layout), true)
+	return
+
+}
+
+// ReadPackedUint reads the unsigned integer at index i of an array of
+// values of the specified width in bits stored with layout at the
+// specified offset, without decoding the rest of the array
+func (b *Buffer) ReadPackedUint(off, width, i int64, layout PackedLayout) uint64 {
+
+	if i < 0 {
+
+		panic(BufferUnderreadError.at(off, 0, b.cap))
+
+	}
+
+	b.packedCheck(off, width, i+1, layout, BufferOverreadError, BufferUnderreadError)
+	return b.packedGet(off, packedBit(width, i, layout), width, layout)
+
+}
+
+// WritePackedUints writes data as unsigned integers of the specified
+// width in bits, stored with layout at the specified offset, without
+// modifying the internal offset value. only the low width bits of each
+// value are written, and unused bits are left untouched
+func (b *Buffer) WritePackedUints(off, width int64, data []uint64, layout PackedLayout) {
+
+	b.packedCheck(off, width, int64(len(data)), layout, BufferOverwriteError, BufferUnderwriteError)
+
+	for i, v := range data {
+
+		b.packedSet(off, packedBit(width, int64(i), layout), width, v, layout)
+
+	}
+
+}
+
+// WritePackedUintsNext writes data as unsigned integers of the
+// specified width in bits stored with layout at the current offset and
+// moves the offset forward the amount of bytes they take up
+func (b *Buffer) WritePackedUintsNext(width int64, data []uint64, layout PackedLayout) {
+
+	size := PackedSize(width, int64(len(data)), layout)
+	b.WritePackedUints(b.off, width, data, layout)
+	b.traced(b.off*8, size*8)
+	b.SeekByte(size, true)
+
+}
+
+// WritePackedUint writes v as the unsigned integer at index i of an
+// array of values of the specified width in bits stored with layout at
+// the specified offset, leaving the other values untouched
+func (b *Buffer) WritePackedUint(off, width, i int64, v uint64, layout PackedLayout) {
+
+	if i < 0 {
+
+		panic(BufferUnderwriteError.at(off, 0, b.cap))
+
+	}
+
+	b.packedCheck(off, width, i+1, layout, BufferOverwriteError, BufferUnderwriteError)
+	b.packedSet(off, packedBit(width, i, layout), width, v, layout)
+
+}
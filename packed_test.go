@@ -0,0 +1,224 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+tests
+
+*/
+
+func TestBufferPackedFAT12(t *testing.T) {
+
+	buf := NewBuffer([]byte{0xFF, 0x23, 0x61, 0x45})
+
+	if out := buf.ReadPackedUints(0x01, 12, 2, PackedLSB); !cmp.Equal([]uint64{0x123, 0x456}, out) {
+
+		t.Fatalf("expected fat entries do not match the ones gotten (got %#v, expected %#v)", out, []uint64{0x123, 0x456})
+
+	}
+
+	buf.WritePackedUint(0x01, 12, 1, 0xABC, PackedLSB)
+	if !cmp.Equal([]byte{0xFF, 0x23, 0xC1, 0xAB}, buf.Bytes()) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v)", buf.Bytes())
+
+	}
+
+}
+
+func TestBufferPackedMSB(t *testing.T) {
+
+	// 2-bit nucleotides, as in dna sequence encodings
+	buf := NewBuffer([]byte{0x1B, 0xE4})
+
+	if out := buf.ReadPackedUints(0x00, 2, 8, PackedMSB); !cmp.Equal([]uint64{0, 1, 2, 3, 3, 2, 1, 0}, out) {
+
+		t.Fatalf("expected uint64 slice does not match the one gotten (got %#v)", out)
+
+	}
+
+	for i := int64(0); i < 3; i++ {
+
+		if out, expected := buf.ReadPackedUint(0x00, 5, i, PackedMSB), buf.ReadBits(i*5, 5); out != expected {
+
+			t.Fatalf("expected value does not match the one gotten at index %d (got %d, expected %d)", i, out, expected)
+
+		}
+
+	}
+
+}
+
+func TestBufferPackedWords(t *testing.T) {
+
+	data := make([]uint64, 13)
+	for i := range data {
+
+		data[i] = uint64(i + 1)
+
+	}
+
+	buf := NewBuffer(make([]byte, 16))
+	buf.WritePackedUints(0x00, 5, data, PackedWordsLSB)
+
+	// twelve 5-bit values fit in a word, so the thirteenth starts the
+	// second one
+	var word uint64
+	for i := uint64(0); i < 12; i++ {
+
+		word |= (i + 1) << (i * 5)
+
+	}
+
+	if out := buf.ReadU64BE(0x00, 2); !cmp.Equal([]uint64{word, 13}, out) {
+
+		t.Fatalf("expected words do not match the ones gotten (got %#v, expected %#v)", out, []uint64{word, 13})
+
+	}
+
+	buf = NewBuffer(make([]byte, 16))
+	buf.WritePackedUints(0x00, 5, data, PackedWordsMSB)
+	if out := buf.ReadU64BE(0x08, 1)[0]; out != 13<<59 {
+
+		t.Fatalf("expected word does not match the one gotten (got %#x, expected %#x)", out, uint64(13<<59))
+
+	}
+
+	if out := buf.ReadPackedUint(0x00, 5, 11, PackedWordsMSB); out != 12 {
+
+		t.Fatalf("expected value does not match the one gotten (got %d, expected %d)", out, 12)
+
+	}
+
+}
+
+func TestBufferPackedRoundTrip(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+	for _, layout := range []PackedLayout{PackedMSB, PackedLSB, PackedWordsMSB, PackedWordsLSB} {
+
+		for width := int64(1); width <= 64; width++ {
+
+			data := make([]uint64, 37)
+			for i := range data {
+
+				data[i] = r.Uint64() >> uint64(64-width)
+
+			}
+
+			size := PackedSize(width, int64(len(data)), layout)
+			buf := NewBuffer(make([]byte, size+1))
+			buf.SeekByte(0x01, false)
+			buf.WritePackedUintsNext(width, data, layout)
+
+			if buf.ByteOffset() != size+1 {
+
+				t.Fatalf("expected offset does not match the one gotten (got %d, expected %d)", buf.ByteOffset(), size+1)
+
+			}
+
+			if out := buf.ReadPackedUints(0x01, width, int64(len(data)), layout); !cmp.Equal(data, out) {
+
+				t.Fatalf("expected values do not match the ones gotten for layout %d and width %d (got %#v, expected %#v)", layout, width, out, data)
+
+			}
+
+		}
+
+	}
+
+}
+
+func TestCursorPackedUints(t *testing.T) {
+
+	buf := NewBuffer([]byte{0xFF, 0x00, 0x00, 0x00})
+
+	c := buf.Cursor(0x01)
+	c.WritePackedUintsNext(12, []uint64{0x123, 0x456}, PackedLSB)
+	if c.ByteOffset() != 0x04 || !cmp.Equal([]byte{0xFF, 0x23, 0x61, 0x45}, buf.Bytes()) {
+
+		t.Fatalf("unexpected cursor state (at %d, holding %#v)", c.ByteOffset(), buf.Bytes())
+
+	}
+
+	c.SeekByte(0x01, false)
+	if out := c.ReadPackedUintsNext(12, 2, PackedLSB); !cmp.Equal([]uint64{0x123, 0x456}, out) || c.ByteOffset() != 0x04 {
+
+		t.Fatalf("expected fat entries do not match the ones gotten (got %#v at %d)", out, c.ByteOffset())
+
+	}
+
+}
+
+func TestReaderBufferPackedUints(t *testing.T) {
+
+	buf := NewReaderBuffer(iotest.OneByteReader(bytes.NewReader([]byte{0x23, 0x61, 0x45})))
+
+	if out := buf.ReadPackedUintsNext(12, 2, PackedLSB); !cmp.Equal([]uint64{0x123, 0x456}, out) {
+
+		t.Fatalf("expected fat entries do not match the ones gotten (got %#v, expected %#v)", out, []uint64{0x123, 0x456})
+
+	}
+
+}
+
+func TestBufferPackedOutOfBounds(t *testing.T) {
+
+	buf := NewBuffer(make([]byte, 3))
+
+	func() {
+
+		defer panicChecker(t, BufferOverreadError)
+		buf.ReadPackedUints(0x00, 12, 3, PackedLSB)
+
+	}()
+
+	func() {
+
+		defer panicChecker(t, BufferOverwriteError)
+		buf.WritePackedUint(0x00, 4, 0, 1, PackedWordsLSB)
+
+	}()
+
+	func() {
+
+		defer panicChecker(t, BufferInvalidBitCountError)
+		buf.ReadPackedUint(0x00, 65, 0, PackedMSB)
+
+	}()
+
+	func() {
+
+		defer panicChecker(t, BufferInvalidByteCountError)
+		buf.ReadPackedUints(0x00, 64, 1<<60, PackedMSB)
+
+	}()
+
+	func() {
+
+		defer panicChecker(t, BufferInvalidByteCountError)
+		PackedSize(33, math.MaxInt64, PackedWordsLSB)
+
+	}()
+
+}
@@ -0,0 +1,739 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import "bytes"
+
+const (
+	// ipsMaxOffset is one past the largest offset an ips record can
+	// start at
+	ipsMaxOffset = 1 << 24
+
+	// ipsEOF is the offset that cannot start a record since it reads
+	// as the end of file marker
+	ipsEOF = 0x454F46
+
+	// bpsMinSourceRead is the shortest run of unchanged bytes that is
+	// worth a separate bps action
+	bpsMinSourceRead = 4
+
+	// patchMaxExpansion is the amount of result bytes a single byte of
+	// an ups or bps patch may produce by default, which bounds the size
+	// of the result before it is allocated
+	patchMaxExpansion = 64
+)
+
+// bps actions
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+// patchReader reads the fields of a patch, recording the first read
+// that runs past its end
+type patchReader struct {
+	data []byte
+	off  int
+	err  error
+}
+
+// bytes returns the next n bytes of the patch
+func (r *patchReader) bytes(n int) []byte {
+
+	if r.err != nil || n < 0 || n > len(r.data)-r.off {
+
+		r.err = PatchInvalidError
+		return nil
+
+	}
+
+	r.off += n
+	return r.data[r.off-n : r.off]
+
+}
+
+// uint reads an n byte unsigned integer, in big endian if big is set
+func (r *patchReader) uint(n int, big bool) (v uint64) {
+
+	data := r.bytes(n)
+	for i := range data {
+
+		if big {
+
+			v = (v << 8) | uint64(data[i])
+
+		} else {
+
+			v |= uint64(data[i]) << uint64(8*i)
+
+		}
+
+	}
+	return
+
+}
+
+// vlq reads a variable-length integer in the encoding shared by ups
+// and bps
+func (r *patchReader) vlq() (v uint64) {
+
+	shift := uint64(1)
+	for i := 0; i < 10; i++ {
+
+		c := r.bytes(1)
+		if r.err != nil {
+
+			return
+
+		}
+
+		v += uint64(c[0]&0x7F) * shift
+		if c[0]&0x80 != 0 {
+
+			return
+
+		}
+
+		shift <<= 7
+		v += shift
+
+	}
+
+	r.err = PatchInvalidError
+	return
+
+}
+
+// appendPatchVLQ appends v to dst in the variable-length integer
+// encoding shared by ups and bps
+func appendPatchVLQ(dst []byte, v uint64) []byte {
+
+	for {
+
+		c := byte(v & 0x7F)
+		v >>= 7
+		if v == 0 {
+
+			return append(dst, c|0x80)
+
+		}
+
+		dst = append(dst, c)
+		v--
+
+	}
+
+}
+
+// appendPatchCRC appends the crc32 of data to dst in little endian
+func appendPatchCRC(dst, data []byte) []byte {
+
+	sum := crcEngine(CRC32).Checksum(data)
+	return append(dst, byte(sum), byte(sum>>8), byte(sum>>16), byte(sum>>24))
+
+}
+
+// patchLimit returns the default limit on the size of the result of
+// applying patch to source
+func patchLimit(source, patch *Buffer) int64 {
+
+	return source.cap + patch.cap*patchMaxExpansion
+
+}
+
+// checkPatchTargetSize verifies that the result size read from an ups
+// or bps patch does not exceed limit
+func checkPatchTargetSize(size uint64, limit int64) error {
+
+	if limit < 0 || size > uint64(limit) {
+
+		return PatchTargetTooLargeError
+
+	}
+	return nil
+
+}
+
+// checkPatchCRCs verifies the checksums that end ups and bps patches
+// given the source and target data
+func checkPatchCRCs(patch, source, target []byte) error {
+
+	var (
+		c = crcEngine(CRC32)
+		r = &patchReader{data: patch, off: len(patch) - 12}
+	)
+
+	if r.uint(4, false) != c.Checksum(source) {
+
+		return PatchSourceMismatchError
+
+	}
+
+	if r.uint(4, false) != c.Checksum(target) {
+
+		return PatchTargetMismatchError
+
+	}
+	return nil
+
+}
+
+// checkPatchSelfCRC verifies the checksum of an ups or bps patch
+// covering everything but itself
+func checkPatchSelfCRC(patch []byte) error {
+
+	r := &patchReader{data: patch, off: len(patch) - 4}
+	if r.uint(4, false) != crcEngine(CRC32).Checksum(patch[:len(patch)-4]) {
+
+		return PatchChecksumError
+
+	}
+	return nil
+
+}
+
+/* ips */
+
+// CreateIPS creates an ips patch that turns source into target. ips
+// patches carry no checksums and cannot describe changes located past
+// the first 16 mebibytes
+func CreateIPS(source, target *Buffer) (*Buffer, error) {
+
+	var (
+		src = source.buf
+		dst = target.buf
+		out = []byte("PATCH")
+	)
+
+	for off := 0; off < len(dst); off++ {
+
+		if off < len(src) && src[off] == dst[off] {
+
+			continue
+
+		}
+
+		start := off
+		if start == ipsEOF {
+
+			start--
+
+		}
+
+		if start >= ipsMaxOffset {
+
+			return nil, PatchTooLargeError
+
+		}
+
+		// runs of a single value are stored once
+		run := 1
+		for start+run < len(dst) && run < 0xFFFF && dst[start+run] == dst[start] {
+
+			run++
+
+		}
+
+		if run > 8 {
+
+			out = append(out, byte(start>>16), byte(start>>8), byte(start), 0x00, 0x00, byte(run>>8), byte(run), dst[start])
+			off = start + run - 1
+			continue
+
+		}
+
+		end := off + 1
+		for end < len(dst) && end-start < 0xFFFF && (end >= len(src) || src[end] != dst[end]) {
+
+			end++
+
+		}
+
+		out = append(out, byte(start>>16), byte(start>>8), byte(start), byte((end-start)>>8), byte(end-start))
+		out = append(out, dst[start:end]...)
+		off = end - 1
+
+	}
+
+	out = append(out, "EOF"...)
+	if len(dst) < len(src) {
+
+		if len(dst) >= ipsMaxOffset {
+
+			return nil, PatchTooLargeError
+
+		}
+		out = append(out, byte(len(dst)>>16), byte(len(dst)>>8), byte(len(dst)))
+
+	}
+
+	return NewBuffer(out), nil
+
+}
+
+// ApplyIPS applies an ips patch to source and returns the result
+func ApplyIPS(source, patch *Buffer) (*Buffer, error) {
+
+	r := &patchReader{data: patch.buf}
+	if !bytes.Equal(r.bytes(5), []byte("PATCH")) {
+
+		return nil, PatchInvalidError
+
+	}
+
+	out := append([]byte{}, source.buf...)
+	for {
+
+		off := int(r.uint(3, true))
+		if r.err != nil {
+
+			return nil, r.err
+
+		}
+
+		if off == ipsEOF {
+
+			break
+
+		}
+
+		var data []byte
+		if n := int(r.uint(2, true)); n != 0 {
+
+			data = r.bytes(n)
+
+		} else {
+
+			n = int(r.uint(2, true))
+			data = bytes.Repeat(r.bytes(1), n)
+
+		}
+
+		if r.err != nil {
+
+			return nil, r.err
+
+		}
+
+		if end := off + len(data); end > len(out) {
+
+			out = append(out, make([]byte, end-len(out))...)
+
+		}
+		copy(out[off:], data)
+
+	}
+
+	switch len(r.data) - r.off {
+
+	case 0:
+
+	case 3:
+		if n := int(r.uint(3, true)); n < len(out) {
+
+			out = out[:n]
+
+		}
+
+	default:
+		return nil, PatchInvalidError
+
+	}
+
+	return NewBuffer(out), nil
+
+}
+
+/* ups */
+
+// CreateUPS creates an ups patch that turns source into target
+func CreateUPS(source, target *Buffer) (*Buffer, error) {
+
+	var (
+		src = source.buf
+		dst = target.buf
+		out = []byte("UPS1")
+	)
+
+	out = appendPatchVLQ(out, uint64(len(src)))
+	out = appendPatchVLQ(out, uint64(len(dst)))
+
+	xor := func(off int) byte {
+
+		if off < len(src) {
+
+			return src[off] ^ dst[off]
+
+		}
+		return dst[off]
+
+	}
+
+	relative := uint64(0)
+	for off := 0; off < len(dst); {
+
+		x := xor(off)
+		off++
+		if x == 0 {
+
+			relative++
+			continue
+
+		}
+
+		out = appendPatchVLQ(out, relative)
+		out = append(out, x)
+		relative = 0
+
+		// the block ends with a zero, which also stands for the first
+		// unchanged byte after it
+		for {
+
+			if off >= len(dst) {
+
+				out = append(out, 0x00)
+				break
+
+			}
+
+			x = xor(off)
+			off++
+			out = append(out, x)
+			if x == 0 {
+
+				break
+
+			}
+
+		}
+
+	}
+
+	out = appendPatchCRC(out, src)
+	out = appendPatchCRC(out, dst)
+	out = appendPatchCRC(out, out)
+
+	return NewBuffer(out), nil
+
+}
+
+// ApplyUPS applies an ups patch to source and returns the result,
+// verifying the checksums of the patch, the source and the result. the
+// result may be at most 64 times as large as the patch on top of the
+// size of source, which rules out hostile sizes; ApplyUPSLimit allows
+// patches that grow data further
+func ApplyUPS(source, patch *Buffer) (*Buffer, error) {
+
+	return ApplyUPSLimit(source, patch, patchLimit(source, patch))
+
+}
+
+// ApplyUPSLimit applies an ups patch to source like ApplyUPS, but
+// allows results of up to limit bytes. patches recording a larger
+// result are rejected with PatchTargetTooLargeError before anything
+// is allocated for it
+func ApplyUPSLimit(source, patch *Buffer, limit int64) (*Buffer, error) {
+
+	data := patch.buf
+	if len(data) < 16 || !bytes.Equal(data[:4], []byte("UPS1")) {
+
+		return nil, PatchInvalidError
+
+	}
+
+	if err := checkPatchSelfCRC(data); err != nil {
+
+		return nil, err
+
+	}
+
+	r := &patchReader{data: data[:len(data)-12], off: 4}
+
+	var (
+		srcLen = r.vlq()
+		dstLen = r.vlq()
+	)
+
+	if r.err != nil {
+
+		return nil, r.err
+
+	}
+
+	if srcLen != uint64(len(source.buf)) {
+
+		return nil, PatchSourceMismatchError
+
+	}
+
+	if err := checkPatchTargetSize(dstLen, limit); err != nil {
+
+		return nil, err
+
+	}
+
+	out := make([]byte, dstLen)
+	copy(out, source.buf)
+
+	for off := uint64(0); r.off < len(r.data); {
+
+		off += r.vlq()
+		for {
+
+			x := r.bytes(1)
+			if r.err != nil {
+
+				return nil, r.err
+
+			}
+
+			if off < dstLen {
+
+				out[off] ^= x[0]
+
+			}
+
+			off++
+			if x[0] == 0 {
+
+				break
+
+			}
+
+		}
+
+	}
+
+	if err := checkPatchCRCs(data, source.buf, out); err != nil {
+
+		return nil, err
+
+	}
+	return NewBuffer(out), nil
+
+}
+
+/* bps */
+
+// CreateBPS creates a bps patch that turns source into target. the
+// patch is built in a single linear pass, so it only reuses source
+// data located at the same offset in both buffers
+func CreateBPS(source, target *Buffer) (*Buffer, error) {
+
+	var (
+		src = source.buf
+		dst = target.buf
+		out = []byte("BPS1")
+	)
+
+	out = appendPatchVLQ(out, uint64(len(src)))
+	out = appendPatchVLQ(out, uint64(len(dst)))
+	out = appendPatchVLQ(out, 0)
+
+	literal := 0
+	flush := func(off int) {
+
+		if literal > 0 {
+
+			out = appendPatchVLQ(out, uint64(literal-1)<<2|bpsTargetRead)
+			out = append(out, dst[off-literal:off]...)
+			literal = 0
+
+		}
+
+	}
+
+	for off := 0; off < len(dst); {
+
+		same := 0
+		for off+same < len(src) && off+same < len(dst) && src[off+same] == dst[off+same] {
+
+			same++
+
+		}
+
+		if same < bpsMinSourceRead {
+
+			literal++
+			off++
+			continue
+
+		}
+
+		flush(off)
+		out = appendPatchVLQ(out, uint64(same-1)<<2|bpsSourceRead)
+		off += same
+
+	}
+	flush(len(dst))
+
+	out = appendPatchCRC(out, src)
+	out = appendPatchCRC(out, dst)
+	out = appendPatchCRC(out, out)
+
+	return NewBuffer(out), nil
+
+}
+
+// ApplyBPS applies a bps patch to source and returns the result,
+// verifying the checksums of the patch, the source and the result. the
+// result may be at most 64 times as large as the patch on top of the
+// size of source, which rules out hostile sizes; ApplyBPSLimit allows
+// patches that grow data further
+func ApplyBPS(source, patch *Buffer) (*Buffer, error) {
+
+	return ApplyBPSLimit(source, patch, patchLimit(source, patch))
+
+}
+
+// ApplyBPSLimit applies a bps patch to source like ApplyBPS, but
+// allows results of up to limit bytes. patches recording a larger
+// result are rejected with PatchTargetTooLargeError before anything
+// is allocated for it
+func ApplyBPSLimit(source, patch *Buffer, limit int64) (*Buffer, error) {
+
+	data := patch.buf
+	if len(data) < 16 || !bytes.Equal(data[:4], []byte("BPS1")) {
+
+		return nil, PatchInvalidError
+
+	}
+
+	if err := checkPatchSelfCRC(data); err != nil {
+
+		return nil, err
+
+	}
+
+	r := &patchReader{data: data[:len(data)-12], off: 4}
+
+	var (
+		src    = source.buf
+		srcLen = r.vlq()
+		dstLen = r.vlq()
+	)
+	r.bytes(int(r.vlq()))
+
+	if r.err != nil {
+
+		return nil, r.err
+
+	}
+
+	if srcLen != uint64(len(src)) {
+
+		return nil, PatchSourceMismatchError
+
+	}
+
+	if err := checkPatchTargetSize(dstLen, limit); err != nil {
+
+		return nil, err
+
+	}
+
+	var (
+		out            = make([]byte, dstLen)
+		off            = 0
+		srcRel, dstRel = 0, 0
+	)
+
+	// relative reads the signed offset used by the copy actions
+	relative := func() int {
+
+		v := r.vlq()
+		if v&1 != 0 {
+
+			return -int(v >> 1)
+
+		}
+		return int(v >> 1)
+
+	}
+
+	for r.off < len(r.data) {
+
+		action := r.vlq()
+		n := int(action>>2) + 1
+		if r.err != nil || n <= 0 || n > len(out)-off {
+
+			return nil, PatchInvalidError
+
+		}
+
+		switch action & 3 {
+
+		case bpsSourceRead:
+			if off+n > len(src) {
+
+				return nil, PatchInvalidError
+
+			}
+			copy(out[off:], src[off:off+n])
+
+		case bpsTargetRead:
+			copy(out[off:], r.bytes(n))
+
+		case bpsSourceCopy:
+			srcRel += relative()
+			if srcRel < 0 || srcRel+n > len(src) {
+
+				return nil, PatchInvalidError
+
+			}
+
+			copy(out[off:], src[srcRel:srcRel+n])
+			srcRel += n
+
+		case bpsTargetCopy:
+			dstRel += relative()
+			if dstRel < 0 || dstRel >= off {
+
+				return nil, PatchInvalidError
+
+			}
+
+			// the copy may overlap the data it produces
+			for i := 0; i < n; i++ {
+
+				out[off+i] = out[dstRel]
+				dstRel++
+
+			}
+
+		}
+
+		if r.err != nil {
+
+			return nil, r.err
+
+		}
+		off += n
+
+	}
+
+	if off != len(out) {
+
+		return nil, PatchInvalidError
+
+	}
+
+	if err := checkPatchCRCs(data, src, out); err != nil {
+
+		return nil, err
+
+	}
+	return NewBuffer(out), nil
+
+}
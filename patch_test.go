@@ -0,0 +1,358 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+utilities
+
+*/
+
+// patchFormats lists the patch formats along with whether they carry
+// checksums
+var patchFormats = []struct {
+	name      string
+	create    func(source, target *Buffer) (*Buffer, error)
+	apply     func(source, patch *Buffer) (*Buffer, error)
+	checksums bool
+}{
+	{"ips", CreateIPS, ApplyIPS, false},
+	{"ups", CreateUPS, ApplyUPS, true},
+	{"bps", CreateBPS, ApplyBPS, true},
+}
+
+/*
+
+tests
+
+*/
+
+func TestPatchRoundTrip(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+
+	source := make([]byte, 4096)
+	r.Read(source)
+
+	edited := append([]byte{}, source...)
+	for i := 0; i < 32; i++ {
+
+		edited[r.Intn(len(edited))] ^= byte(r.Intn(255) + 1)
+
+	}
+
+	// a long run of a single value
+	for i := 1000; i < 1100; i++ {
+
+		edited[i] = 0xAA
+
+	}
+
+	pairs := [][2][]byte{
+		{source, edited},
+		{source, append(edited, 0x00, 0x00, 0x01)},
+		{source, edited[:3000]},
+		{source, source},
+		{[]byte{}, []byte("crunch")},
+		{[]byte("crunch"), []byte{}},
+	}
+
+	for _, format := range patchFormats {
+
+		for i, pair := range pairs {
+
+			var (
+				source = NewBuffer(append([]byte{}, pair[0]...))
+				target = NewBuffer(append([]byte{}, pair[1]...))
+			)
+
+			patch, err := format.create(source, target)
+			if err != nil {
+
+				t.Fatalf("failed to create %s patch %d: %v", format.name, i, err)
+
+			}
+
+			out, err := format.apply(source, patch)
+			if err != nil {
+
+				t.Fatalf("failed to apply %s patch %d: %v", format.name, i, err)
+
+			}
+
+			if diff := target.DiffString(out); diff != "" {
+
+				t.Fatalf("%s patch %d produced a different result:\n%s", format.name, i, diff)
+
+			}
+
+		}
+
+	}
+
+}
+
+func TestPatchChecksums(t *testing.T) {
+
+	var (
+		source = NewBuffer([]byte("crunch - utilities for taking bytes out of things"))
+		target = NewBuffer([]byte("crunch - utilities for putting bytes into things"))
+		other  = NewBuffer([]byte("Crunch - utilities for taking bytes out of things"))
+	)
+
+	for _, format := range patchFormats {
+
+		if !format.checksums {
+
+			continue
+
+		}
+
+		patch, _ := format.create(source, target)
+		if _, err := format.apply(other, patch); err != PatchSourceMismatchError {
+
+			t.Fatalf("expected %s patch to reject the wrong source (got %v)", format.name, err)
+
+		}
+
+		patch.buf[10] ^= 0x01
+		if _, err := format.apply(source, patch); err != PatchChecksumError {
+
+			t.Fatalf("expected %s patch to fail its own checksum (got %v)", format.name, err)
+
+		}
+
+	}
+
+}
+
+func TestApplyIPS(t *testing.T) {
+
+	expected := []byte{0x00, 0xDE, 0xAD, 0x03, 0x07, 0x07, 0x07}
+
+	patch := NewBuffer([]byte("PATCH"),
+		[]byte{0x00, 0x00, 0x01, 0x00, 0x02, 0xDE, 0xAD},
+		[]byte{0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x03, 0x07},
+		[]byte("EOF"),
+		[]byte{0x00, 0x00, 0x07},
+	)
+
+	out, err := ApplyIPS(NewBuffer([]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}), patch)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if !cmp.Equal(expected, out.Bytes()) {
+
+		t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", out.Bytes(), expected)
+
+	}
+
+}
+
+func TestCreateIPSEOFOffset(t *testing.T) {
+
+	var (
+		source = NewBuffer(make([]byte, ipsEOF+2))
+		target = NewBuffer(make([]byte, ipsEOF+2))
+	)
+	target.buf[ipsEOF] = 0x01
+
+	patch, err := CreateIPS(source, target)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if out := patch.ReadBytes(5, 3); !cmp.Equal([]byte{0x45, 0x4F, 0x45}, out) {
+
+		t.Fatalf("expected the record to start before the eof marker offset (got %#v)", out)
+
+	}
+
+	out, err := ApplyIPS(source, patch)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if diff := target.DiffString(out); diff != "" {
+
+		t.Fatalf("patch produced a different result:\n%s", diff)
+
+	}
+
+}
+
+func TestApplyBPSCopies(t *testing.T) {
+
+	var (
+		source = []byte("abcdef")
+		target = []byte("defabcabcabc")
+		patch  = []byte("BPS1")
+	)
+
+	patch = appendPatchVLQ(patch, uint64(len(source)))
+	patch = appendPatchVLQ(patch, uint64(len(target)))
+	patch = appendPatchVLQ(patch, 0)
+
+	// source copies of "def" and "abc" followed by an overlapping
+	// target copy of "abcabc"
+	patch = appendPatchVLQ(patch, 2<<2|bpsSourceCopy)
+	patch = appendPatchVLQ(patch, 3<<1)
+	patch = appendPatchVLQ(patch, 2<<2|bpsSourceCopy)
+	patch = appendPatchVLQ(patch, 6<<1|1)
+	patch = appendPatchVLQ(patch, 5<<2|bpsTargetCopy)
+	patch = appendPatchVLQ(patch, 3<<1)
+
+	patch = appendPatchCRC(patch, source)
+	patch = appendPatchCRC(patch, target)
+	patch = appendPatchCRC(patch, patch)
+
+	out, err := ApplyBPS(NewBuffer(source), NewBuffer(patch))
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if string(out.Bytes()) != string(target) {
+
+		t.Fatalf("expected result does not match the one gotten (got %q, expected %q)", out.Bytes(), target)
+
+	}
+
+}
+
+func TestPatchTargetSize(t *testing.T) {
+
+	for _, test := range []struct {
+		magic  string
+		create func(source, target *Buffer) (*Buffer, error)
+		apply  func(source, patch *Buffer) (*Buffer, error)
+		limit  func(source, patch *Buffer, limit int64) (*Buffer, error)
+	}{
+		{"UPS1", CreateUPS, ApplyUPS, ApplyUPSLimit},
+		{"BPS1", CreateBPS, ApplyBPS, ApplyBPSLimit},
+	} {
+
+		// an empty source claiming to turn into an enormous result
+		patch := []byte(test.magic)
+		patch = appendPatchVLQ(patch, 0)
+		patch = appendPatchVLQ(patch, 1<<62)
+		if test.magic == "BPS1" {
+
+			patch = appendPatchVLQ(patch, 0)
+
+		}
+
+		patch = appendPatchCRC(patch, nil)
+		patch = appendPatchCRC(patch, nil)
+		patch = appendPatchCRC(patch, patch)
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+
+		if _, err := test.apply(NewBuffer([]byte{}), NewBuffer(patch)); err != PatchTargetTooLargeError {
+
+			t.Fatalf("expected %s patch to be rejected (got %v)", test.magic, err)
+
+		}
+
+		if _, err := test.limit(NewBuffer([]byte{}), NewBuffer(patch), 1<<20); err != PatchTargetTooLargeError {
+
+			t.Fatalf("expected %s patch to be rejected (got %v)", test.magic, err)
+
+		}
+
+		runtime.ReadMemStats(&after)
+		if after.TotalAlloc-before.TotalAlloc > 1<<16 {
+
+			t.Fatalf("expected %s patch to be rejected before allocating its result (allocated %d bytes)", test.magic, after.TotalAlloc-before.TotalAlloc)
+
+		}
+
+		// results larger than the default limit are allowed up to the
+		// one passed in
+		var (
+			source = NewBuffer([]byte("crunch"))
+			target = NewBuffer([]byte("crunch"), make([]byte, 1<<16))
+		)
+
+		p, err := test.create(source, target)
+		if err != nil {
+
+			t.Fatal(err)
+
+		}
+
+		if _, err = test.limit(source, p, target.ByteCapacity()-1); err != PatchTargetTooLargeError {
+
+			t.Fatalf("expected %s patch to exceed the limit (got %v)", test.magic, err)
+
+		}
+
+		out, err := test.limit(source, p, target.ByteCapacity())
+		if err != nil || !cmp.Equal(target.Bytes(), out.Bytes()) {
+
+			t.Fatalf("expected %s patch to apply within the limit (got %v)", test.magic, err)
+
+		}
+
+	}
+
+}
+
+func TestPatchVLQ(t *testing.T) {
+
+	tests := []struct {
+		value    uint64
+		expected []byte
+	}{
+		{0, []byte{0x80}},
+		{127, []byte{0xFF}},
+		{128, []byte{0x00, 0x80}},
+		{16511, []byte{0x7F, 0xFF}},
+		{16512, []byte{0x00, 0x00, 0x80}},
+	}
+
+	for _, test := range tests {
+
+		out := appendPatchVLQ(nil, test.value)
+		if !cmp.Equal(test.expected, out) {
+
+			t.Fatalf("expected encoding of %d does not match the one gotten (got %#v, expected %#v)", test.value, out, test.expected)
+
+		}
+
+		r := &patchReader{data: out}
+		if v := r.vlq(); v != test.value || r.err != nil {
+
+			t.Fatalf("expected value does not match the one gotten (got %d, expected %d)", v, test.value)
+
+		}
+
+	}
+
+}
@@ -0,0 +1,140 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultBufferPool is a BufferPool with size classes from 64 bytes
+// to 64 kibibytes that retains buffers of up to 1 mebibyte
+var DefaultBufferPool = NewBufferPool(1<<20,
+	1<<6, 1<<8, 1<<10, 1<<12, 1<<14, 1<<16,
+)
+
+// BufferPool implements a pool of reusable Buffers split into size
+// classes. buffers whose capacity grew past the retention limit are
+// dropped instead of being pooled so that a single large message does
+// not pin memory forever
+type BufferPool struct {
+	classes []int64
+	pools   []sync.Pool
+	max     int64
+}
+
+// NewBufferPool initializes a new BufferPool that retains buffers with
+// a capacity of up to max bytes, using the provided size classes
+func NewBufferPool(max int64, classes ...int64) (p *BufferPool) {
+
+	if len(classes) == 0 {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+
+	p = &BufferPool{
+		classes: append([]int64{}, classes...),
+		pools:   make([]sync.Pool, len(classes)),
+		max:     max,
+	}
+
+	sort.Slice(p.classes, func(i, j int) bool {
+
+		return p.classes[i] < p.classes[j]
+
+	})
+
+	if p.classes[0] <= 0 {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+
+	return
+
+}
+
+// Get returns a zeroed Buffer holding size bytes. buffers larger than
+// the largest size class are allocated directly
+func (p *BufferPool) Get(size int64) (b *Buffer) {
+
+	if size < 0 {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+
+	i := sort.Search(len(p.classes), func(i int) bool {
+
+		return p.classes[i] >= size
+
+	})
+
+	if i == len(p.classes) {
+
+		b = NewBuffer(make([]byte, size))
+		b.pool = p
+		return
+
+	}
+
+	if v := p.pools[i].Get(); v != nil {
+
+		b = v.(*Buffer)
+		b.buf = b.buf[:size]
+		for j := range b.buf {
+
+			b.buf[j] = 0x00
+
+		}
+
+	} else {
+
+		b = &Buffer{
+			buf:  make([]byte, size, p.classes[i]),
+			pool: p,
+		}
+
+	}
+
+	b.off = 0x00
+	b.boff = 0x00
+	b.Refresh()
+	return
+
+}
+
+// Put returns b to the pool. b must not be used afterwards
+func (p *BufferPool) Put(b *Buffer) {
+
+	// buffers whose storage is not managed by the go runtime can
+	// never be reused
+	c := int64(cap(b.buf))
+	if b.grow != nil || c > p.max || c < p.classes[0] {
+
+		return
+
+	}
+
+	// the buffer goes to the largest class it can satisfy
+	i := sort.Search(len(p.classes), func(i int) bool {
+
+		return p.classes[i] > c
+
+	}) - 1
+
+	b.pool = p
+	b.tracer = nil
+	p.pools[i].Put(b)
+
+}
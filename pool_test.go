@@ -0,0 +1,144 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import "testing"
+
+/*
+
+tests
+
+*/
+
+func TestBufferPoolGet(t *testing.T) {
+
+	p := NewBufferPool(1024, 256, 16, 64)
+
+	for _, size := range []int64{0, 1, 16, 17, 200, 256, 1000} {
+
+		buf := p.Get(size)
+		if buf.ByteCapacity() != size || buf.ByteOffset() != 0 || buf.BitOffset() != 0 {
+
+			t.Fatalf("unexpected buffer from pool (got %d bytes at offset %d)", buf.ByteCapacity(), buf.ByteOffset())
+
+		}
+
+		for _, c := range buf.Bytes() {
+
+			if c != 0x00 {
+
+				t.Fatalf("expected buffer from pool to be zeroed (got %#v)", buf.Bytes())
+
+			}
+
+		}
+
+		if size > 0 {
+
+			buf.SetAllBits()
+			buf.SeekByte(0x01, false)
+
+		}
+		buf.Release()
+
+	}
+
+}
+
+func TestBufferPoolRetention(t *testing.T) {
+
+	p := NewBufferPool(64, 16, 64)
+
+	buf := p.Get(16)
+	buf.Grow(256)
+	buf.Reset()
+
+	if cap(buf.buf) != 0 {
+
+		t.Fatalf("expected reset to drop the oversized backing array (capacity %d)", cap(buf.buf))
+
+	}
+
+	buf = p.Get(16)
+	buf.Reset()
+
+	if cap(buf.buf) != 16 {
+
+		t.Fatalf("expected reset to keep the backing array (capacity %d)", cap(buf.buf))
+
+	}
+
+}
+
+func TestBufferRelease(t *testing.T) {
+
+	buf := NewBuffer([]byte{0x01, 0x02})
+	buf.SeekByte(0x01, false)
+	buf.Release()
+
+	if buf.ByteCapacity() != 0 || buf.ByteOffset() != 0 {
+
+		t.Fatalf("expected released buffer to be empty (got %d bytes at offset %d)", buf.ByteCapacity(), buf.ByteOffset())
+
+	}
+
+}
+
+func TestNewBufferPoolPanic(t *testing.T) {
+
+	defer panicChecker(t, BufferInvalidByteCountError)
+
+	_ = NewBufferPool(1024)
+
+}
+
+/*
+
+benchmarks
+
+*/
+
+func BenchmarkBufferPoolParallel(b *testing.B) {
+
+	b.ReportAllocs()
+
+	b.RunParallel(func(pb *testing.PB) {
+
+		for pb.Next() {
+
+			buf := DefaultBufferPool.Get(512)
+			buf.WriteU32LE(0x00, []uint32{0x01, 0x02})
+			buf.Release()
+
+		}
+
+	})
+
+}
+
+func BenchmarkBufferNoPoolParallel(b *testing.B) {
+
+	b.ReportAllocs()
+
+	b.RunParallel(func(pb *testing.PB) {
+
+		for pb.Next() {
+
+			buf := NewBuffer(make([]byte, 512))
+			buf.WriteU32LE(0x00, []uint32{0x01, 0x02})
+			buf.Release()
+
+		}
+
+	})
+
+}
@@ -0,0 +1,431 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"io"
+	"time"
+)
+
+const (
+	// readerBufferChunk is the minimum amount of bytes requested from
+	// the source each time more data is needed
+	readerBufferChunk = 4096
+
+	// readerBufferEmptyReads is the amount of reads in a row that may
+	// return no data before the source is considered stuck
+	readerBufferEmptyReads = 100
+)
+
+// ReaderBuffer implements a Buffer that pulls data from an io.Reader
+// on demand. its *Next read methods load as much data as they need
+// before reading and panic with ReaderBufferEOFError once the source
+// runs out. bytes consumed by both offsets are discarded whenever more
+// data is loaded, so offsets are relative to the data that is still
+// held
+type ReaderBuffer struct {
+	*Buffer
+
+	r         io.Reader
+	err       error
+	discarded int64
+}
+
+// NewReaderBuffer initializes a new ReaderBuffer that reads from r
+func NewReaderBuffer(r io.Reader) *ReaderBuffer {
+
+	return &ReaderBuffer{
+		Buffer: NewBuffer(),
+		r:      r,
+	}
+
+}
+
+/* internal use methods */
+
+// load reads from the source until the buffer holds at least end
+// bytes. start is the offset the caller intends to read from
+func (b *ReaderBuffer) load(start, end int64) error {
+
+	for empty := 0; end > b.cap; {
+
+		if b.err != nil {
+
+			if b.err == io.EOF && b.cap > start {
+
+				return io.ErrUnexpectedEOF
+
+			}
+			return b.err
+
+		}
+
+		var (
+			off  = b.cap
+			want = end - off
+		)
+		if want < readerBufferChunk {
+
+			want = readerBufferChunk
+
+		}
+
+		b.Grow(want)
+		n, err := b.r.Read(b.buf[off:b.cap])
+		if n < 0 {
+
+			panic(BytesBufNegativeReadError)
+
+		}
+		b.TruncateRight(want - int64(n))
+		b.err = err
+
+		if n > 0 || err != nil {
+
+			empty = 0
+			continue
+
+		}
+
+		empty++
+		if empty >= readerBufferEmptyReads {
+
+			b.err = io.ErrNoProgress
+
+		}
+
+	}
+
+	return nil
+
+}
+
+// fillBits loads data until at least n bits are held after the
+// current bit offset
+func (b *ReaderBuffer) fillBits(n int64) error {
+
+	if b.boff+n <= b.bcap {
+
+		return nil
+
+	}
+
+	b.Compact()
+	return b.load(b.boff/8, (b.boff+n+7)/8)
+
+}
+
+// must panics if err is not nil, translating the end of the source
+// into the errors used by ReaderBuffer
+func (b *ReaderBuffer) must(err error) {
+
+	switch err {
+
+	case nil:
+		return
+
+	case io.EOF:
+		panic(ReaderBufferEOFError)
+
+	case io.ErrUnexpectedEOF:
+		panic(ReaderBufferUnexpectedEOFError)
+
+	default:
+		panic(err)
+
+	}
+
+}
+
+/* generic methods */
+
+// Fill loads data from the source until at least n bytes are held
+// after the current offset. it returns io.EOF if the source ran out
+// with no bytes left after the offset, io.ErrUnexpectedEOF if it ran
+// out with fewer than n, io.ErrNoProgress if the source keeps
+// returning no data or the error returned by the source
+func (b *ReaderBuffer) Fill(n int64) error {
+
+	if b.off+n <= b.cap {
+
+		return nil
+
+	}
+
+	b.Compact()
+	return b.load(b.off, b.off+n)
+
+}
+
+// Compact discards the bytes located before both the byte and the
+// bit offsets, moving the offsets back along with them. data still
+// reachable from the lagging offset is kept, so readers that only use
+// one of the offsets should keep the other one in step with AlignBit
+// or AlignByte
+func (b *ReaderBuffer) Compact() {
+
+	n := b.off
+	if b.boff/8 < n {
+
+		n = b.boff / 8
+
+	}
+
+	if n <= 0 || n > b.cap {
+
+		return
+
+	}
+
+	b.TruncateLeft(n)
+	b.SeekByte(-n, true)
+	b.SeekBit(-n*8, true)
+	b.discarded += n
+
+}
+
+// Discarded returns the amount of bytes that have been discarded from
+// the start of the buffer, which turns offsets into source positions
+func (b *ReaderBuffer) Discarded() int64 {
+
+	return b.discarded
+
+}
+
+/* bitfield methods */
+
+// ReadBitNext returns the next bit from the current offset and moves
+// the offset forward a bit
+func (b *ReaderBuffer) ReadBitNext() byte {
+
+	b.must(b.fillBits(1))
+	return b.Buffer.ReadBitNext()
+
+}
+
+// ReadBitsNext returns the next n bits from the current offset and
+// moves the offset forward the amount of bits read
+func (b *ReaderBuffer) ReadBitsNext(n int64) uint64 {
+
+	b.must(b.fillBits(n))
+	return b.Buffer.ReadBitsNext(n)
+
+}
+
+// ReadBitsSignedNext returns the next n bits from the current offset
+// as a two's complement signed integer and moves the offset forward
+// the amount of bits read
+func (b *ReaderBuffer) ReadBitsSignedNext(n int64) int64 {
+
+	b.must(b.fillBits(n))
+	return b.Buffer.ReadBitsSignedNext(n)
+
+}
+
+// ReadBitsBytesNext returns the next n bits from the current offset
+// packed into bytes and moves the offset forward the amount of bits
+// read
+func (b *ReaderBuffer) ReadBitsBytesNext(n int64) []byte {
+
+	b.must(b.fillBits(n))
+	return b.Buffer.ReadBitsBytesNext(n)
+
+}
+
+/* byte buffer methods */
+
+// ReadBytesNext returns the next n bytes from the current offset
+// and moves the offset forward the amount of bytes read
+func (b *ReaderBuffer) ReadBytesNext(n int64) []byte {
+
+	b.must(b.Fill(n))
+	return b.Buffer.ReadBytesNext(n)
+
+}
+
+// ReadByteNext returns the next byte from the current offset and
+// moves the offset forward a byte
+func (b *ReaderBuffer) ReadByteNext() byte {
+
+	b.must(b.Fill(1))
+	return b.Buffer.ReadByteNext()
+
+}
+
+// ReadU16LENext reads a slice of uint16s from the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes read
+func (b *ReaderBuffer) ReadU16LENext(n int64) []uint16 {
+
+	b.must(b.Fill(n * 2))
+	return b.Buffer.ReadU16LENext(n)
+
+}
+
+// ReadU16BENext reads a slice of uint16s from the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes read
+func (b *ReaderBuffer) ReadU16BENext(n int64) []uint16 {
+
+	b.must(b.Fill(n * 2))
+	return b.Buffer.ReadU16BENext(n)
+
+}
+
+// ReadU32LENext reads a slice of uint32s from the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes read
+func (b *ReaderBuffer) ReadU32LENext(n int64) []uint32 {
+
+	b.must(b.Fill(n * 4))
+	return b.Buffer.ReadU32LENext(n)
+
+}
+
+// ReadU32BENext reads a slice of uint32s from the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes read
+func (b *ReaderBuffer) ReadU32BENext(n int64) []uint32 {
+
+	b.must(b.Fill(n * 4))
+	return b.Buffer.ReadU32BENext(n)
+
+}
+
+// ReadU64LENext reads a slice of uint64s from the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes read
+func (b *ReaderBuffer) ReadU64LENext(n int64) []uint64 {
+
+	b.must(b.Fill(n * 8))
+	return b.Buffer.ReadU64LENext(n)
+
+}
+
+// ReadU64BENext reads a slice of uint64s from the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes read
+func (b *ReaderBuffer) ReadU64BENext(n int64) []uint64 {
+
+	b.must(b.Fill(n * 8))
+	return b.Buffer.ReadU64BENext(n)
+
+}
+
+/* packed integer methods */
+
+// ReadPackedUintsNext reads count unsigned integers of the specified
+// width in bits stored with layout at the current offset and moves the
+// offset forward the amount of bytes they take up
+func (b *ReaderBuffer) ReadPackedUintsNext(width, count int64, layout PackedLayout) []uint64 {
+
+	b.must(b.Fill(PackedSize(width, count, layout)))
+	return b.Buffer.ReadPackedUintsNext(width, count, layout)
+
+}
+
+/* fixed-point methods */
+
+// ReadFixedLENext reads a byte-aligned fixed-point number in the
+// format q from the buffer at the current offset in little-endian and
+// moves the offset forward the amount of bytes read
+func (b *ReaderBuffer) ReadFixedLENext(q QFormat) float64 {
+
+	b.must(b.Fill(q.bytes()))
+	return b.Buffer.ReadFixedLENext(q)
+
+}
+
+// ReadFixedBENext reads a byte-aligned fixed-point number in the
+// format q from the buffer at the current offset in big-endian and
+// moves the offset forward the amount of bytes read
+func (b *ReaderBuffer) ReadFixedBENext(q QFormat) float64 {
+
+	b.must(b.Fill(q.bytes()))
+	return b.Buffer.ReadFixedBENext(q)
+
+}
+
+// ReadFixedBitsNext reads a fixed-point number in the format q stored
+// in the next Width bits from the current bit offset and moves the bit
+// offset forward the amount of bits read
+func (b *ReaderBuffer) ReadFixedBitsNext(q QFormat) float64 {
+
+	q.check()
+	b.must(b.fillBits(q.Width))
+	return b.Buffer.ReadFixedBitsNext(q)
+
+}
+
+/* bcd methods */
+
+// ReadBCDNext reads n bytes of packed bcd from the buffer at the
+// current offset and moves the offset forward the amount of bytes
+// read if no error occurs
+func (b *ReaderBuffer) ReadBCDNext(n int64) (uint64, error) {
+
+	b.must(b.Fill(n))
+	return b.Buffer.ReadBCDNext(n)
+
+}
+
+// ReadPackedDecimalNext reads an n byte signed packed decimal number
+// from the buffer at the current offset and moves the offset forward
+// the amount of bytes read if no error occurs
+func (b *ReaderBuffer) ReadPackedDecimalNext(n int64) (int64, error) {
+
+	b.must(b.Fill(n))
+	return b.Buffer.ReadPackedDecimalNext(n)
+
+}
+
+/* time methods */
+
+// ReadTimeLENext reads a timestamp of the specified kind from the
+// buffer at the current offset in little-endian and moves the offset
+// forward the amount of bytes read if no error occurs
+func (b *ReaderBuffer) ReadTimeLENext(kind TimeKind) (time.Time, error) {
+
+	b.must(b.Fill(kind.Size()))
+	return b.Buffer.ReadTimeLENext(kind)
+
+}
+
+// ReadTimeBENext reads a timestamp of the specified kind from the
+// buffer at the current offset in big-endian and moves the offset
+// forward the amount of bytes read if no error occurs
+func (b *ReaderBuffer) ReadTimeBENext(kind TimeKind) (time.Time, error) {
+
+	b.must(b.Fill(kind.Size()))
+	return b.Buffer.ReadTimeBENext(kind)
+
+}
+
+/* checksum methods */
+
+// CRCNext returns the crc of the next n bytes from the current offset
+// and moves the offset forward the amount of bytes read
+func (b *ReaderBuffer) CRCNext(n int64, params CRCParams) uint64 {
+
+	b.must(b.Fill(n))
+	return b.Buffer.CRCNext(n, params)
+
+}
+
+// CRCBitsNext returns the crc of the next n bits from the current bit
+// offset and moves the bit offset forward the amount of bits read
+func (b *ReaderBuffer) CRCBitsNext(n int64, params CRCParams) uint64 {
+
+	b.must(b.fillBits(n))
+	return b.Buffer.CRCBitsNext(n, params)
+
+}
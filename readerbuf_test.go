@@ -0,0 +1,250 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+utilities
+
+*/
+
+type negativeReader struct{}
+
+func (negativeReader) Read(p []byte) (int, error) {
+
+	return -1, nil
+
+}
+
+type emptyReader struct{}
+
+func (emptyReader) Read(p []byte) (int, error) {
+
+	return 0, nil
+
+}
+
+/*
+
+tests
+
+*/
+
+func TestReaderBufferReadNext(t *testing.T) {
+
+	data := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F}
+
+	buf := NewReaderBuffer(iotest.OneByteReader(bytes.NewReader(data)))
+
+	if out := buf.ReadByteNext(); out != 0x01 {
+
+		t.Fatalf("expected byte does not match the one gotten (got %#x, expected %#x)", out, 0x01)
+
+	}
+
+	if out := buf.ReadU16BENext(1); !cmp.Equal([]uint16{0x0203}, out) {
+
+		t.Fatalf("expected uint16 slice does not match the one gotten (got %#v, expected %#v)", out, []uint16{0x0203})
+
+	}
+
+	if out := buf.ReadU32LENext(1); !cmp.Equal([]uint32{0x07060504}, out) {
+
+		t.Fatalf("expected uint32 slice does not match the one gotten (got %#v, expected %#v)", out, []uint32{0x07060504})
+
+	}
+
+	if out := buf.ReadU64BENext(1); !cmp.Equal([]uint64{0x08090A0B0C0D0E0F}, out) {
+
+		t.Fatalf("expected uint64 slice does not match the one gotten (got %#v, expected %#v)", out, []uint64{0x08090A0B0C0D0E0F})
+
+	}
+
+	if buf.Discarded()+buf.ByteOffset() != int64(len(data)) {
+
+		t.Fatalf("expected source position does not match the one gotten (got %d, expected %d)", buf.Discarded()+buf.ByteOffset(), len(data))
+
+	}
+
+	if err := buf.Fill(1); err != io.EOF {
+
+		t.Fatalf("expected error does not match the one gotten (got %v, expected %v)", err, io.EOF)
+
+	}
+
+}
+
+func TestReaderBufferReadBitsNext(t *testing.T) {
+
+	buf := NewReaderBuffer(iotest.OneByteReader(bytes.NewReader([]byte{0xA5, 0x0F, 0xF0})))
+
+	if out := buf.ReadBitNext(); out != 1 {
+
+		t.Fatalf("expected bit does not match the one gotten (got %d, expected %d)", out, 1)
+
+	}
+
+	if out := buf.ReadBitsNext(15); out != 0x250F {
+
+		t.Fatalf("expected uint64 does not match the one gotten (got %#x, expected %#x)", out, 0x250F)
+
+	}
+
+	if out := buf.ReadBitsNext(8); out != 0xF0 {
+
+		t.Fatalf("expected uint64 does not match the one gotten (got %#x, expected %#x)", out, 0xF0)
+
+	}
+
+}
+
+func TestReaderBufferReadBitsSignedNext(t *testing.T) {
+
+	buf := NewReaderBuffer(iotest.OneByteReader(bytes.NewReader([]byte{0xAB, 0xC0})))
+
+	if out := buf.ReadBitsSignedNext(4); out != -6 {
+
+		t.Fatalf("expected int64 does not match the one gotten (got %d, expected %d)", out, -6)
+
+	}
+
+	if out := buf.ReadBitsBytesNext(10); !cmp.Equal([]byte{0xBC, 0x00}, out) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v, expected %#v)", out, []byte{0xBC, 0x00})
+
+	}
+
+}
+
+func TestReaderBufferCompact(t *testing.T) {
+
+	data := make([]byte, readerBufferChunk*4)
+	for i := range data {
+
+		data[i] = byte(i)
+
+	}
+
+	buf := NewReaderBuffer(bytes.NewReader(data))
+
+	for i := 0; i < len(data); i++ {
+
+		if out := buf.ReadByteNext(); out != byte(i) {
+
+			t.Fatalf("expected byte does not match the one gotten (got %#x, expected %#x)", out, byte(i))
+
+		}
+		buf.AlignBit()
+
+	}
+
+	if buf.ByteCapacity() > readerBufferChunk {
+
+		t.Fatalf("expected consumed bytes to be discarded (holding %d bytes)", buf.ByteCapacity())
+
+	}
+
+}
+
+func TestReaderBufferCompactLagging(t *testing.T) {
+
+	data := make([]byte, readerBufferChunk*2)
+	for i := range data {
+
+		data[i] = byte(i)
+
+	}
+
+	buf := NewReaderBuffer(bytes.NewReader(data))
+	buf.SeekBit(8, false)
+
+	// the byte offset runs ahead of the bit offset, which must still
+	// see the data it points at
+	_ = buf.ReadBytesNext(readerBufferChunk + 1)
+
+	if out := buf.ReadBitsNext(8); out != 0x01 {
+
+		t.Fatalf("expected uint64 does not match the one gotten (got %#x, expected %#x)", out, 0x01)
+
+	}
+
+	if buf.Discarded()+buf.ByteOffset() != readerBufferChunk+1 {
+
+		t.Fatalf("unexpected offsets (discarded %d, offset %d)", buf.Discarded(), buf.ByteOffset())
+
+	}
+
+}
+
+func TestReaderBufferCRCNext(t *testing.T) {
+
+	buf := NewReaderBuffer(iotest.OneByteReader(bytes.NewReader([]byte("123456789"))))
+
+	if out := buf.CRCNext(9, CRC32); out != CRC32.Check {
+
+		t.Fatalf("expected crc does not match the one gotten (got %#x, expected %#x)", out, CRC32.Check)
+
+	}
+
+}
+
+func TestReaderBufferNoProgress(t *testing.T) {
+
+	buf := NewReaderBuffer(emptyReader{})
+
+	if err := buf.Fill(1); err != io.ErrNoProgress {
+
+		t.Fatalf("expected error does not match the one gotten (got %v, expected %v)", err, io.ErrNoProgress)
+
+	}
+
+}
+
+func TestReaderBufferEOFPanic(t *testing.T) {
+
+	defer panicChecker(t, ReaderBufferEOFError)
+
+	buf := NewReaderBuffer(bytes.NewReader([]byte{0x01, 0x02}))
+
+	_ = buf.ReadU16LENext(1)
+	_ = buf.ReadByteNext()
+
+}
+
+func TestReaderBufferUnexpectedEOFPanic(t *testing.T) {
+
+	defer panicChecker(t, ReaderBufferUnexpectedEOFError)
+
+	buf := NewReaderBuffer(bytes.NewReader([]byte{0x01, 0x02}))
+
+	_ = buf.ReadU32BENext(1)
+
+}
+
+func TestReaderBufferNegativeReadPanic(t *testing.T) {
+
+	defer panicChecker(t, BytesBufNegativeReadError)
+
+	buf := NewReaderBuffer(negativeReader{})
+
+	_ = buf.ReadByteNext()
+
+}
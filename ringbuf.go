@@ -0,0 +1,484 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import "sync"
+
+// OverflowPolicy represents what a RingBuffer does when a write does
+// not fit in its free space
+type OverflowPolicy int
+
+const (
+	// RingOverflowError makes writes that do not fit panic with
+	// RingBufferOverflowError without writing anything
+	RingOverflowError OverflowPolicy = iota
+
+	// RingOverflowOverwrite makes writes that do not fit discard the
+	// oldest bytes held by the buffer
+	RingOverflowOverwrite
+
+	// RingOverflowBlock makes writes that do not fit wait for reads
+	// to free space, and reads wait for writes to provide data
+	RingOverflowBlock
+)
+
+// RingBuffer implements a fixed-capacity circular buffer with the
+// typed *Next methods of Buffer. reads and writes wrap around the end
+// of the buffer transparently and it is safe for concurrent use
+type RingBuffer struct {
+	buf    []byte
+	cap    int64
+	roff   int64
+	n      int64
+	policy OverflowPolicy
+	closed bool
+
+	mu   sync.Mutex
+	cond *sync.Cond
+}
+
+// NewRingBuffer initializes a new RingBuffer holding up to size bytes
+// that handles overflow according to policy
+func NewRingBuffer(size int64, policy OverflowPolicy) (r *RingBuffer) {
+
+	if size <= 0 {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+
+	r = &RingBuffer{
+		buf:    make([]byte, size),
+		cap:    size,
+		policy: policy,
+	}
+	r.cond = sync.NewCond(&r.mu)
+	return
+
+}
+
+/* internal use methods */
+
+// put copies data into the free space after the held bytes. the
+// caller must hold the lock and ensure that data fits
+func (r *RingBuffer) put(data []byte) {
+
+	woff := (r.roff + r.n) % r.cap
+	k := copy(r.buf[woff:], data)
+	copy(r.buf, data[k:])
+	r.n += int64(len(data))
+
+}
+
+// take moves held bytes into out. the caller must hold the lock and
+// ensure that enough bytes are held
+func (r *RingBuffer) take(out []byte) {
+
+	k := copy(out, r.buf[r.roff:])
+	copy(out[k:], r.buf)
+	r.roff = (r.roff + int64(len(out))) % r.cap
+	r.n -= int64(len(out))
+
+}
+
+// write writes data according to the overflow policy
+func (r *RingBuffer) write(data []byte) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+
+		panic(RingBufferClosedError)
+
+	}
+
+	switch r.policy {
+
+	case RingOverflowOverwrite:
+		if int64(len(data)) > r.cap {
+
+			data = data[int64(len(data))-r.cap:]
+
+		}
+
+		if excess := r.n + int64(len(data)) - r.cap; excess > 0 {
+
+			r.roff = (r.roff + excess) % r.cap
+			r.n -= excess
+
+		}
+		r.put(data)
+
+	case RingOverflowBlock:
+		for len(data) > 0 {
+
+			for r.n == r.cap && !r.closed {
+
+				r.cond.Wait()
+
+			}
+
+			if r.closed {
+
+				panic(RingBufferClosedError)
+
+			}
+
+			k := r.cap - r.n
+			if k > int64(len(data)) {
+
+				k = int64(len(data))
+
+			}
+			r.put(data[:k])
+			data = data[k:]
+			r.cond.Broadcast()
+
+		}
+
+	default:
+		if int64(len(data)) > r.cap-r.n {
+
+			panic(RingBufferOverflowError)
+
+		}
+		r.put(data)
+
+	}
+
+	r.cond.Broadcast()
+
+}
+
+// read reads n bytes according to the overflow policy
+func (r *RingBuffer) read(n int64) (out []byte) {
+
+	if n < 0 {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out = make([]byte, n)
+
+	if r.policy != RingOverflowBlock {
+
+		if n > r.n {
+
+			panic(RingBufferUnderflowError)
+
+		}
+		r.take(out)
+		return
+
+	}
+
+	rest := out
+	for len(rest) > 0 {
+
+		for r.n == 0 && !r.closed {
+
+			r.cond.Wait()
+
+		}
+
+		if r.n == 0 {
+
+			panic(RingBufferClosedError)
+
+		}
+
+		k := r.n
+		if k > int64(len(rest)) {
+
+			k = int64(len(rest))
+
+		}
+		r.take(rest[:k])
+		rest = rest[k:]
+		r.cond.Broadcast()
+
+	}
+
+	return
+
+}
+
+/* byte buffer methods */
+
+// WriteBytesNext writes bytes to the end of the buffer
+func (r *RingBuffer) WriteBytesNext(data []byte) {
+
+	r.write(data)
+
+}
+
+// WriteByteNext writes a byte to the end of the buffer
+func (r *RingBuffer) WriteByteNext(data byte) {
+
+	r.write([]byte{data})
+
+}
+
+// WriteU16LENext writes a slice of uint16s to the end of the buffer
+// in little-endian
+func (r *RingBuffer) WriteU16LENext(data []uint16) {
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	tmp := NewBuffer(make([]byte, len(data)*2))
+	tmp.WriteU16LE(0x00, data)
+	r.write(tmp.buf)
+
+}
+
+// WriteU16BENext writes a slice of uint16s to the end of the buffer
+// in big-endian
+func (r *RingBuffer) WriteU16BENext(data []uint16) {
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	tmp := NewBuffer(make([]byte, len(data)*2))
+	tmp.WriteU16BE(0x00, data)
+	r.write(tmp.buf)
+
+}
+
+// WriteU32LENext writes a slice of uint32s to the end of the buffer
+// in little-endian
+func (r *RingBuffer) WriteU32LENext(data []uint32) {
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	tmp := NewBuffer(make([]byte, len(data)*4))
+	tmp.WriteU32LE(0x00, data)
+	r.write(tmp.buf)
+
+}
+
+// WriteU32BENext writes a slice of uint32s to the end of the buffer
+// in big-endian
+func (r *RingBuffer) WriteU32BENext(data []uint32) {
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	tmp := NewBuffer(make([]byte, len(data)*4))
+	tmp.WriteU32BE(0x00, data)
+	r.write(tmp.buf)
+
+}
+
+// WriteU64LENext writes a slice of uint64s to the end of the buffer
+// in little-endian
+func (r *RingBuffer) WriteU64LENext(data []uint64) {
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	tmp := NewBuffer(make([]byte, len(data)*8))
+	tmp.WriteU64LE(0x00, data)
+	r.write(tmp.buf)
+
+}
+
+// WriteU64BENext writes a slice of uint64s to the end of the buffer
+// in big-endian
+func (r *RingBuffer) WriteU64BENext(data []uint64) {
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	tmp := NewBuffer(make([]byte, len(data)*8))
+	tmp.WriteU64BE(0x00, data)
+	r.write(tmp.buf)
+
+}
+
+// ReadBytesNext returns the next n bytes from the start of the buffer
+// and frees the space they occupied
+func (r *RingBuffer) ReadBytesNext(n int64) []byte {
+
+	return r.read(n)
+
+}
+
+// ReadByteNext returns the next byte from the start of the buffer and
+// frees the space it occupied
+func (r *RingBuffer) ReadByteNext() byte {
+
+	return r.read(1)[0]
+
+}
+
+// ReadU16LENext reads a slice of uint16s from the start of the buffer
+// in little-endian and frees the space they occupied
+func (r *RingBuffer) ReadU16LENext(n int64) []uint16 {
+
+	if n == 0 {
+
+		return []uint16{}
+
+	}
+
+	return NewBuffer(r.read(n*2)).ReadU16LE(0x00, n)
+
+}
+
+// ReadU16BENext reads a slice of uint16s from the start of the buffer
+// in big-endian and frees the space they occupied
+func (r *RingBuffer) ReadU16BENext(n int64) []uint16 {
+
+	if n == 0 {
+
+		return []uint16{}
+
+	}
+
+	return NewBuffer(r.read(n*2)).ReadU16BE(0x00, n)
+
+}
+
+// ReadU32LENext reads a slice of uint32s from the start of the buffer
+// in little-endian and frees the space they occupied
+func (r *RingBuffer) ReadU32LENext(n int64) []uint32 {
+
+	if n == 0 {
+
+		return []uint32{}
+
+	}
+
+	return NewBuffer(r.read(n*4)).ReadU32LE(0x00, n)
+
+}
+
+// ReadU32BENext reads a slice of uint32s from the start of the buffer
+// in big-endian and frees the space they occupied
+func (r *RingBuffer) ReadU32BENext(n int64) []uint32 {
+
+	if n == 0 {
+
+		return []uint32{}
+
+	}
+
+	return NewBuffer(r.read(n*4)).ReadU32BE(0x00, n)
+
+}
+
+// ReadU64LENext reads a slice of uint64s from the start of the buffer
+// in little-endian and frees the space they occupied
+func (r *RingBuffer) ReadU64LENext(n int64) []uint64 {
+
+	if n == 0 {
+
+		return []uint64{}
+
+	}
+
+	return NewBuffer(r.read(n*8)).ReadU64LE(0x00, n)
+
+}
+
+// ReadU64BENext reads a slice of uint64s from the start of the buffer
+// in big-endian and frees the space they occupied
+func (r *RingBuffer) ReadU64BENext(n int64) []uint64 {
+
+	if n == 0 {
+
+		return []uint64{}
+
+	}
+
+	return NewBuffer(r.read(n*8)).ReadU64BE(0x00, n)
+
+}
+
+/* generic methods */
+
+// Close wakes up any blocked reads and writes. writes panic with
+// RingBufferClosedError afterwards, while reads may still drain the
+// bytes held by the buffer
+func (r *RingBuffer) Close() {
+
+	r.mu.Lock()
+	r.closed = true
+	r.cond.Broadcast()
+	r.mu.Unlock()
+
+}
+
+// Reset discards every byte held by the buffer
+func (r *RingBuffer) Reset() {
+
+	r.mu.Lock()
+	r.roff = 0
+	r.n = 0
+	r.cond.Broadcast()
+	r.mu.Unlock()
+
+}
+
+/* value retrieval */
+
+// Available returns the amount of bytes that can be read from the
+// buffer
+func (r *RingBuffer) Available() int64 {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.n
+
+}
+
+// Free returns the amount of bytes that can be written to the buffer
+// without overflowing it
+func (r *RingBuffer) Free() int64 {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cap - r.n
+
+}
+
+// ByteCapacity returns the capacity of the buffer
+func (r *RingBuffer) ByteCapacity() int64 {
+
+	return r.cap
+
+}
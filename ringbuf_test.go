@@ -0,0 +1,209 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+tests
+
+*/
+
+func TestRingBufferWrapAround(t *testing.T) {
+
+	r := NewRingBuffer(6, RingOverflowError)
+
+	r.WriteBytesNext([]byte{0x01, 0x02, 0x03, 0x04})
+	_ = r.ReadBytesNext(3)
+
+	// the write and the read both straddle the end of the buffer
+	r.WriteU32BENext([]uint32{0xDEADBEEF})
+
+	if r.Available() != 5 || r.Free() != 1 {
+
+		t.Fatalf("expected accounting does not match the one gotten (got %d available, %d free)", r.Available(), r.Free())
+
+	}
+
+	if out := r.ReadByteNext(); out != 0x04 {
+
+		t.Fatalf("expected byte does not match the one gotten (got %#x, expected %#x)", out, 0x04)
+
+	}
+
+	if out := r.ReadU16BENext(2); !cmp.Equal([]uint16{0xDEAD, 0xBEEF}, out) {
+
+		t.Fatalf("expected uint16 slice does not match the one gotten (got %#v, expected %#v)", out, []uint16{0xDEAD, 0xBEEF})
+
+	}
+
+}
+
+func TestRingBufferTypedRoundTrip(t *testing.T) {
+
+	r := NewRingBuffer(9, RingOverflowError)
+
+	for i := 0; i < 5; i++ {
+
+		r.WriteU16LENext([]uint16{0x0102})
+		r.WriteU32LENext([]uint32{0x03040506})
+
+		if out := r.ReadU16LENext(1); out[0] != 0x0102 {
+
+			t.Fatalf("expected uint16 does not match the one gotten (got %#x, expected %#x)", out[0], 0x0102)
+
+		}
+
+		if out := r.ReadU32LENext(1); out[0] != 0x03040506 {
+
+			t.Fatalf("expected uint32 does not match the one gotten (got %#x, expected %#x)", out[0], 0x03040506)
+
+		}
+
+	}
+
+	r.WriteU64BENext([]uint64{0x0102030405060708})
+	r.Reset()
+
+	r.WriteU64LENext([]uint64{})
+	if r.Available() != 0 {
+
+		t.Fatalf("expected ring buffer to be empty (got %d bytes)", r.Available())
+
+	}
+
+}
+
+func TestRingBufferOverwrite(t *testing.T) {
+
+	expected := []byte{0x03, 0x04, 0x05, 0x06}
+
+	r := NewRingBuffer(4, RingOverflowOverwrite)
+
+	r.WriteBytesNext([]byte{0x01, 0x02, 0x03})
+	r.WriteBytesNext([]byte{0x04, 0x05, 0x06})
+
+	if out := r.ReadBytesNext(4); !cmp.Equal(expected, out) {
+
+		t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", out, expected)
+
+	}
+
+	r.WriteBytesNext([]byte{0x00, 0x00, 0x03, 0x04, 0x05, 0x06})
+	if out := r.ReadBytesNext(4); !cmp.Equal(expected, out) {
+
+		t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", out, expected)
+
+	}
+
+}
+
+func TestRingBufferBlock(t *testing.T) {
+
+	var (
+		r    = NewRingBuffer(3, RingOverflowBlock)
+		data = make([]uint64, 64)
+		done = make(chan []uint64)
+	)
+
+	for i := range data {
+
+		data[i] = uint64(i) * 0x0101010101010101
+
+	}
+
+	go func() {
+
+		done <- r.ReadU64BENext(int64(len(data)))
+
+	}()
+
+	r.WriteU64BENext(data)
+
+	if out := <-done; !cmp.Equal(data, out) {
+
+		t.Fatalf("expected uint64 slice does not match the one gotten (got %#v, expected %#v)", out, data)
+
+	}
+
+}
+
+func TestRingBufferClose(t *testing.T) {
+
+	r := NewRingBuffer(4, RingOverflowBlock)
+	r.WriteBytesNext([]byte{0x01})
+
+	done := make(chan interface{})
+	go func() {
+
+		defer func() { done <- recover() }()
+		_ = r.ReadBytesNext(2)
+
+	}()
+
+	r.Close()
+
+	if out := <-done; out != RingBufferClosedError {
+
+		t.Fatalf("expected panic does not match the one gotten (got %v, expected %v)", out, RingBufferClosedError)
+
+	}
+
+}
+
+func TestRingBufferOverflowPanic(t *testing.T) {
+
+	defer panicChecker(t, RingBufferOverflowError)
+
+	r := NewRingBuffer(4, RingOverflowError)
+
+	r.WriteU32LENext([]uint32{0x00})
+	r.WriteByteNext(0x00)
+
+}
+
+func TestRingBufferUnderflowPanic(t *testing.T) {
+
+	defer panicChecker(t, RingBufferUnderflowError)
+
+	r := NewRingBuffer(4, RingOverflowOverwrite)
+
+	r.WriteByteNext(0x00)
+	_ = r.ReadU16BENext(1)
+
+}
+
+/*
+
+benchmarks
+
+*/
+
+func BenchmarkRingBufferWriteReadU32LE(b *testing.B) {
+
+	b.ReportAllocs()
+
+	r := NewRingBuffer(7, RingOverflowError)
+
+	for n := 0; n < b.N; n++ {
+
+		r.WriteU32LENext([]uint32{0x01})
+		_ = r.ReadU32LENext(1)
+
+	}
+
+}
@@ -0,0 +1,1149 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Schema implements an interpreter for declarative descriptions of
+// binary formats, loosely modeled after kaitai struct. a schema is a
+// json object of the form
+//
+//	{
+//	  "endian": "le",
+//	  "seq": [
+//	    {"id": "magic", "type": "u4be"},
+//	    {"id": "count", "type": "u2"},
+//	    {"id": "flags", "type": "b3"},
+//	    {"id": "entries", "type": "entry", "repeat": "expr", "repeat-expr": "count"},
+//	    {"id": "extra", "type": "u1", "if": "flags & 1 == 1"},
+//	    {"id": "body", "size": "_io.size - _io.pos", "type": {
+//	      "switch-on": "magic",
+//	      "cases": {"0xCAFEBABE": "class", "_": "raw"}
+//	    }}
+//	  ],
+//	  "instances": {
+//	    "footer": {"pos": "0x10", "type": "u4"}
+//	  },
+//	  "types": {
+//	    "entry": {"seq": [...]}
+//	  }
+//	}
+//
+// the primitive types are u1, u2, u4 and u8 for unsigned integers,
+// s1, s2, s4 and s8 for signed ones, f4 and f8 for floats, all of
+// which take an optional le or be suffix, b1 to b64 for unaligned
+// bitfields, str and strz for strings and bytes, the default, for raw
+// byte arrays. sizes, counts, conditions, positions and switches take
+// expressions that may refer to previously parsed fields
+type Schema struct {
+	root  *schemaType
+	types map[string]*schemaType
+}
+
+// schemaType is a compiled user type
+type schemaType struct {
+	name      string
+	endian    string
+	seq       []*schemaField
+	instances []*schemaField
+}
+
+// schemaField is a compiled field or instance
+type schemaField struct {
+	id  string
+	typ string
+
+	switchOn schemaExpr
+	cases    []schemaCase
+
+	size    schemaExpr
+	sizeEOS bool
+
+	repeat      string
+	repeatExpr  schemaExpr
+	repeatUntil schemaExpr
+
+	cond  schemaExpr
+	pos   schemaExpr
+	value schemaExpr
+}
+
+// schemaCase is a case of a switch. a nil match marks the default
+type schemaCase struct {
+	match schemaExpr
+	typ   string
+}
+
+// schemaPrimitive describes a primitive type. kind is one of 'u', 's',
+// 'f', 'b' for bitfields, 'z' for strz, 't' for str and 0 for bytes
+type schemaPrimitive struct {
+	kind   byte
+	width  int64
+	endian string
+}
+
+// schemaSource holds the source of an expression, which may be given
+// as a json string, number or boolean
+type schemaSource string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *schemaSource) UnmarshalJSON(data []byte) error {
+
+	if len(data) > 0 && data[0] == '"' {
+
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+
+			return err
+
+		}
+
+		*s = schemaSource(str)
+		return nil
+
+	}
+
+	*s = schemaSource(data)
+	return nil
+
+}
+
+// schemaTypeJSON is the json representation of a type
+type schemaTypeJSON struct {
+	Endian    string                     `json:"endian"`
+	Seq       []schemaFieldJSON          `json:"seq"`
+	Instances json.RawMessage            `json:"instances"`
+	Types     map[string]json.RawMessage `json:"types"`
+}
+
+// schemaFieldJSON is the json representation of a field
+type schemaFieldJSON struct {
+	ID          string          `json:"id"`
+	Type        json.RawMessage `json:"type"`
+	Size        schemaSource    `json:"size"`
+	SizeEOS     bool            `json:"size-eos"`
+	Repeat      string          `json:"repeat"`
+	RepeatExpr  schemaSource    `json:"repeat-expr"`
+	RepeatUntil schemaSource    `json:"repeat-until"`
+	If          schemaSource    `json:"if"`
+	Pos         schemaSource    `json:"pos"`
+	Value       schemaSource    `json:"value"`
+}
+
+// schemaSwitchJSON is the json representation of a switch
+type schemaSwitchJSON struct {
+	SwitchOn schemaSource      `json:"switch-on"`
+	Cases    map[string]string `json:"cases"`
+}
+
+// ParseSchema compiles the json schema held in data. errors found in
+// a field carry the path to that field
+func ParseSchema(data []byte) (s *Schema, err error) {
+
+	var root schemaTypeJSON
+	if err = json.Unmarshal(data, &root); err != nil {
+
+		return
+
+	}
+
+	s = &Schema{types: make(map[string]*schemaType)}
+
+	// types are registered before being compiled so that they can
+	// refer to each other
+	raw := make(map[string]*schemaTypeJSON)
+	for name, data := range root.Types {
+
+		t := &schemaTypeJSON{}
+		if err = json.Unmarshal(data, t); err != nil {
+
+			return nil, err
+
+		}
+
+		if _, ok := parseSchemaPrimitive(name); ok || name == "" {
+
+			return nil, SchemaInvalidError.WithPath(name)
+
+		}
+
+		raw[name] = t
+		s.types[name] = &schemaType{name: name}
+
+	}
+
+	if s.root, err = s.compileType("", &root, root.Endian); err != nil {
+
+		return nil, err
+
+	}
+
+	for name, t := range raw {
+
+		compiled, err := s.compileType(name, t, root.Endian)
+		if err != nil {
+
+			return nil, err
+
+		}
+		*s.types[name] = *compiled
+
+	}
+
+	return
+
+}
+
+// compileType compiles the json representation of a type
+func (s *Schema) compileType(name string, t *schemaTypeJSON, endian string) (c *schemaType, err error) {
+
+	c = &schemaType{name: name, endian: endian}
+	if t.Endian != "" {
+
+		c.endian = t.Endian
+
+	}
+
+	switch c.endian {
+
+	case "":
+		c.endian = "le"
+
+	case "le", "be":
+
+	default:
+		return nil, SchemaInvalidError.WithPath(name)
+
+	}
+
+	for i := range t.Seq {
+
+		f, err := s.compileField(&t.Seq[i], false)
+		if err != nil {
+
+			return nil, err.(Error).WithPath(name)
+
+		}
+		c.seq = append(c.seq, f)
+
+	}
+
+	if len(t.Instances) == 0 {
+
+		return
+
+	}
+
+	// instances are kept in the order they are declared in so that
+	// they can refer to each other
+	dec := json.NewDecoder(bytes.NewReader(t.Instances))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+
+		return nil, SchemaInvalidError.WithPath(name)
+
+	}
+
+	for dec.More() {
+
+		tok, err := dec.Token()
+		if err != nil {
+
+			return nil, SchemaInvalidError.WithPath(name)
+
+		}
+
+		var fj schemaFieldJSON
+		if err = dec.Decode(&fj); err != nil {
+
+			return nil, SchemaInvalidError.WithPath(name)
+
+		}
+		fj.ID = tok.(string)
+
+		f, err := s.compileField(&fj, true)
+		if err != nil {
+
+			return nil, err.(Error).WithPath(name)
+
+		}
+		c.instances = append(c.instances, f)
+
+	}
+
+	return
+
+}
+
+// compileField compiles the json representation of a field
+func (s *Schema) compileField(fj *schemaFieldJSON, instance bool) (f *schemaField, err error) {
+
+	f = &schemaField{
+		id:      fj.ID,
+		repeat:  fj.Repeat,
+		sizeEOS: fj.SizeEOS,
+	}
+
+	fail := func(e Error) (*schemaField, error) {
+
+		return nil, e.WithPath(fj.ID)
+
+	}
+
+	if fj.ID == "" || strings.Trim(fj.ID, "_abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") != "" {
+
+		return fail(SchemaInvalidError)
+
+	}
+
+	exprs := []struct {
+		src schemaSource
+		dst *schemaExpr
+	}{
+		{fj.Size, &f.size},
+		{fj.RepeatExpr, &f.repeatExpr},
+		{fj.RepeatUntil, &f.repeatUntil},
+		{fj.If, &f.cond},
+		{fj.Pos, &f.pos},
+		{fj.Value, &f.value},
+	}
+
+	for _, e := range exprs {
+
+		if e.src == "" {
+
+			continue
+
+		}
+
+		if *e.dst, err = compileSchemaExpr(string(e.src)); err != nil {
+
+			return fail(err.(Error))
+
+		}
+
+	}
+
+	if !instance && (f.pos != nil || f.value != nil) {
+
+		return fail(SchemaInvalidError)
+
+	}
+
+	switch f.repeat {
+
+	case "", "eos":
+
+	case "expr":
+		if f.repeatExpr == nil {
+
+			return fail(SchemaInvalidError)
+
+		}
+
+	case "until":
+		if f.repeatUntil == nil {
+
+			return fail(SchemaInvalidError)
+
+		}
+
+	default:
+		return fail(SchemaInvalidError)
+
+	}
+
+	sized := f.size != nil || f.sizeEOS
+	if f.value != nil {
+
+		if len(fj.Type) != 0 || sized || f.repeat != "" {
+
+			return fail(SchemaInvalidError)
+
+		}
+		return
+
+	}
+
+	var types []string
+	if len(fj.Type) > 0 && fj.Type[0] == '{' {
+
+		var sw schemaSwitchJSON
+		if err = json.Unmarshal(fj.Type, &sw); err != nil || sw.SwitchOn == "" {
+
+			return fail(SchemaInvalidError)
+
+		}
+
+		if f.switchOn, err = compileSchemaExpr(string(sw.SwitchOn)); err != nil {
+
+			return fail(err.(Error))
+
+		}
+
+		// without a default, sized fields fall back to raw bytes
+		if sized {
+
+			types = append(types, "")
+
+		}
+
+		for key, typ := range sw.Cases {
+
+			c := schemaCase{typ: typ}
+			if key != "_" {
+
+				if c.match, err = compileSchemaExpr(key); err != nil {
+
+					return fail(err.(Error))
+
+				}
+
+			}
+
+			f.cases = append(f.cases, c)
+			types = append(types, typ)
+
+		}
+
+	} else if len(fj.Type) > 0 {
+
+		if err = json.Unmarshal(fj.Type, &f.typ); err != nil {
+
+			return fail(SchemaInvalidError)
+
+		}
+		types = append(types, f.typ)
+
+	} else {
+
+		types = append(types, "")
+
+	}
+
+	for _, typ := range types {
+
+		if _, ok := s.types[typ]; ok {
+
+			continue
+
+		}
+
+		p, ok := parseSchemaPrimitive(typ)
+		if !ok {
+
+			return fail(SchemaUnknownTypeError)
+
+		}
+
+		// only strings and byte arrays can be sized, and only the ones
+		// without a terminator must be
+		if (p.kind == 0 || p.kind == 't') && !sized {
+
+			return fail(SchemaInvalidError)
+
+		}
+
+		if p.kind != 0 && p.kind != 't' && p.kind != 'z' && sized {
+
+			return fail(SchemaInvalidError)
+
+		}
+
+	}
+
+	return
+
+}
+
+// parseSchemaPrimitive parses the name of a primitive type
+func parseSchemaPrimitive(name string) (p schemaPrimitive, ok bool) {
+
+	switch name {
+
+	case "":
+		return schemaPrimitive{}, true
+
+	case "str":
+		return schemaPrimitive{kind: 't'}, true
+
+	case "strz":
+		return schemaPrimitive{kind: 'z'}, true
+
+	}
+
+	if name[0] == 'b' {
+
+		n, err := strconv.ParseInt(name[1:], 10, 64)
+		if err != nil || n < 1 || n > 64 {
+
+			return
+
+		}
+		return schemaPrimitive{kind: 'b', width: n}, true
+
+	}
+
+	if strings.HasSuffix(name, "le") || strings.HasSuffix(name, "be") {
+
+		p.endian = name[len(name)-2:]
+		name = name[:len(name)-2]
+
+	}
+
+	if len(name) != 2 {
+
+		return
+
+	}
+
+	p.kind = name[0]
+	p.width = int64(name[1] - '0')
+	switch p.kind {
+
+	case 'u', 's':
+		ok = p.width == 1 || p.width == 2 || p.width == 4 || p.width == 8
+
+	case 'f':
+		ok = p.width == 4 || p.width == 8
+
+	}
+
+	return
+
+}
+
+// Parse interprets the data located at the specified offset of b
+// using the schema. the buffer's offsets are not modified
+func (s *Schema) Parse(b *Buffer, off int64) (root *SchemaNode, err error) {
+
+	if off < 0 || off > b.ByteCapacity() {
+
+		return nil, BufferOverreadError.at(off, 0, b.ByteCapacity())
+
+	}
+
+	p := &schemaParser{
+		b:   b,
+		bit: off * 8,
+		end: b.ByteCapacity(),
+	}
+
+	root = &SchemaNode{}
+	p.root = root
+	if err = p.structure(s, s.root, root, nil, ""); err != nil {
+
+		return nil, err
+
+	}
+	return
+
+}
+
+// schemaParser holds the state of a single run of a schema
+type schemaParser struct {
+	b    *Buffer
+	root *SchemaNode
+
+	// bit is the current position in bits and end is the offset of
+	// the byte the current type must end before
+	bit int64
+	end int64
+}
+
+// structure parses the fields and instances of t into node
+func (p *schemaParser) structure(s *Schema, t *schemaType, node *SchemaNode, parent *schemaScope, path string) error {
+
+	sc := &schemaScope{
+		parser: p,
+		node:   node,
+		parent: parent,
+	}
+
+	node.BitOffset = p.bit
+	for _, f := range t.seq {
+
+		if err := p.field(s, t, f, sc, path); err != nil {
+
+			return err
+
+		}
+
+	}
+	node.BitSize = p.bit - node.BitOffset
+
+	for _, f := range t.instances {
+
+		if err := p.field(s, t, f, sc, path); err != nil {
+
+			return err
+
+		}
+
+	}
+
+	return nil
+
+}
+
+// field parses f and appends the resulting node to the node of sc
+func (p *schemaParser) field(s *Schema, t *schemaType, f *schemaField, sc *schemaScope, path string) (err error) {
+
+	if path != "" {
+
+		path += "."
+
+	}
+	path += f.id
+
+	defer func() {
+
+		if e, ok := err.(Error); ok && e.Path == "" {
+
+			err = e.WithPath(path)
+
+		}
+
+	}()
+
+	if f.cond != nil {
+
+		if ok, err := schemaBool(f.cond, sc); err != nil || !ok {
+
+			return err
+
+		}
+
+	}
+
+	if f.value != nil {
+
+		v, err := f.value(sc)
+		if err != nil {
+
+			return err
+
+		}
+
+		if n, ok := v.(*SchemaNode); ok {
+
+			v = n.Value
+
+		}
+
+		sc.node.Children = append(sc.node.Children, &SchemaNode{
+			Name:  f.id,
+			Value: v,
+		})
+		return nil
+
+	}
+
+	if f.pos != nil {
+
+		pos, err := schemaInt(f.pos, sc)
+		if err != nil {
+
+			return err
+
+		}
+
+		defer func(bit int64) {
+
+			p.bit = bit
+
+		}(p.bit)
+		p.bit = pos * 8
+
+	}
+
+	if f.repeat == "" {
+
+		n, err := p.single(s, t, f, sc, path)
+		if err != nil {
+
+			return err
+
+		}
+
+		n.Name = f.id
+		sc.node.Children = append(sc.node.Children, n)
+		return nil
+
+	}
+
+	array := &SchemaNode{
+		Name:      f.id,
+		Array:     true,
+		BitOffset: p.bit,
+	}
+
+	var count int64
+	if f.repeat == "expr" {
+
+		if count, err = schemaInt(f.repeatExpr, sc); err != nil {
+
+			return err
+
+		}
+
+		// every element takes up at least a bit, so a count larger
+		// than the remaining bits can never be satisfied
+		if count > 0 {
+
+			if err = p.need(p.bit, count); err != nil {
+
+				return err
+
+			}
+
+		}
+
+	}
+
+	for i := int64(0); ; i++ {
+
+		if (f.repeat == "expr" && i >= count) || (f.repeat == "eos" && p.bit >= p.end*8) {
+
+			break
+
+		}
+
+		sc.index = i
+		bit := p.bit
+		n, err := p.single(s, t, f, sc, path+"."+strconv.FormatInt(i, 10))
+		if err != nil {
+
+			return err
+
+		}
+
+		// an element that takes up no space would repeat forever
+		if p.bit == bit {
+
+			return SchemaInvalidError
+
+		}
+
+		n.Name = strconv.FormatInt(i, 10)
+		array.Children = append(array.Children, n)
+
+		if f.repeat == "until" {
+
+			sc.current = n
+			done, err := schemaBool(f.repeatUntil, sc)
+			sc.current = nil
+			if err != nil {
+
+				return err
+
+			}
+
+			if done {
+
+				break
+
+			}
+
+		}
+
+	}
+
+	sc.index = 0
+	array.BitSize = p.bit - array.BitOffset
+	sc.node.Children = append(sc.node.Children, array)
+	return nil
+
+}
+
+// single parses a single value of f
+func (p *schemaParser) single(s *Schema, t *schemaType, f *schemaField, sc *schemaScope, path string) (n *SchemaNode, err error) {
+
+	typ := f.typ
+	if f.switchOn != nil {
+
+		if typ, err = p.match(f, sc); err != nil {
+
+			return
+
+		}
+
+	}
+
+	size := int64(-1)
+	if f.size != nil || f.sizeEOS {
+
+		p.align()
+		size = p.end - p.bit/8
+		if f.size != nil {
+
+			if size, err = schemaInt(f.size, sc); err != nil {
+
+				return
+
+			}
+
+		}
+
+		if err = p.need(p.bit, size*8); err != nil {
+
+			return
+
+		}
+
+	}
+
+	if user, ok := s.types[typ]; ok {
+
+		n = &SchemaNode{}
+		if size < 0 {
+
+			err = p.structure(s, user, n, sc, path)
+			return
+
+		}
+
+		end, limit := p.end, p.bit/8+size
+		p.end = limit
+		err = p.structure(s, user, n, sc, path)
+		p.end = end
+
+		n.BitSize = size * 8
+		p.bit = limit * 8
+		return
+
+	}
+
+	prim, _ := parseSchemaPrimitive(typ)
+	if prim.endian == "" {
+
+		prim.endian = t.endian
+
+	}
+
+	if prim.kind != 'b' {
+
+		p.align()
+
+	}
+
+	n = &SchemaNode{BitOffset: p.bit}
+	n.Value, err = p.primitive(prim, size)
+	n.BitSize = p.bit - n.BitOffset
+	return
+
+}
+
+// match returns the type selected by the switch of f
+func (p *schemaParser) match(f *schemaField, sc *schemaScope) (string, error) {
+
+	on, err := f.switchOn(sc)
+	if err != nil {
+
+		return "", err
+
+	}
+	on = schemaNormalize(on)
+
+	def, found := "", false
+	for _, c := range f.cases {
+
+		if c.match == nil {
+
+			def, found = c.typ, true
+			continue
+
+		}
+
+		v, err := c.match(sc)
+		if err != nil {
+
+			return "", err
+
+		}
+
+		if eq, err := schemaEqual(on, schemaNormalize(v)); err == nil && eq {
+
+			return c.typ, nil
+
+		}
+
+	}
+
+	if !found && f.size == nil && !f.sizeEOS {
+
+		return "", SchemaNoMatchingCaseError
+
+	}
+	return def, nil
+
+}
+
+// align moves the position to the next byte boundary
+func (p *schemaParser) align() {
+
+	p.bit = (p.bit + 7) &^ 7
+
+}
+
+// need returns an error if n bits located at off cannot be read
+func (p *schemaParser) need(off, n int64) error {
+
+	if off < 0 || n < 0 {
+
+		return BufferUnderreadError.atBit(off, n, p.end*8)
+
+	}
+
+	if off+n > p.end*8 {
+
+		return BufferOverreadError.atBit(off, n, p.end*8)
+
+	}
+	return nil
+
+}
+
+// primitive reads a value of a primitive type. size is the size of
+// strings and byte arrays
+func (p *schemaParser) primitive(prim schemaPrimitive, size int64) (interface{}, error) {
+
+	off := p.bit / 8
+	switch prim.kind {
+
+	case 'b':
+		if err := p.need(p.bit, prim.width); err != nil {
+
+			return nil, err
+
+		}
+
+		v := p.b.ReadBits(p.bit, prim.width)
+		p.bit += prim.width
+		return v, nil
+
+	case 0, 't':
+		data := append([]byte{}, p.b.ReadBytes(off, size)...)
+		p.bit += size * 8
+		if prim.kind == 't' {
+
+			return string(data), nil
+
+		}
+		return data, nil
+
+	case 'z':
+		if err := p.need(p.bit, 0); err != nil {
+
+			return nil, err
+
+		}
+
+		end := p.end
+		if size >= 0 {
+
+			end = off + size
+
+		}
+
+		i := bytes.IndexByte(p.b.ReadBytes(off, end-off), 0x00)
+		if i < 0 {
+
+			return nil, BufferOverreadError.at(off, end-off+1, end)
+
+		}
+
+		p.bit += int64(i+1) * 8
+		if size >= 0 {
+
+			p.bit = end * 8
+
+		}
+		return string(p.b.ReadBytes(off, int64(i))), nil
+
+	}
+
+	if err := p.need(p.bit, prim.width*8); err != nil {
+
+		return nil, err
+
+	}
+	p.bit += prim.width * 8
+
+	var v uint64
+	switch {
+
+	case prim.width == 1:
+		v = uint64(p.b.ReadByte(off))
+
+	case prim.width == 2 && prim.endian == "le":
+		v = uint64(p.b.ReadU16LE(off, 1)[0])
+
+	case prim.width == 2:
+		v = uint64(p.b.ReadU16BE(off, 1)[0])
+
+	case prim.width == 4 && prim.endian == "le":
+		v = uint64(p.b.ReadU32LE(off, 1)[0])
+
+	case prim.width == 4:
+		v = uint64(p.b.ReadU32BE(off, 1)[0])
+
+	case prim.endian == "le":
+		v = p.b.ReadU64LE(off, 1)[0]
+
+	default:
+		v = p.b.ReadU64BE(off, 1)[0]
+
+	}
+
+	switch prim.kind {
+
+	case 's':
+		shift := uint64(64 - prim.width*8)
+		return int64(v<<shift) >> shift, nil
+
+	case 'f':
+		if prim.width == 4 {
+
+			return float64(math.Float32frombits(uint32(v))), nil
+
+		}
+		return math.Float64frombits(v), nil
+
+	}
+	return v, nil
+
+}
+
+// SchemaNode represents a node of the tree produced by a Schema. leaf
+// nodes hold a uint64, an int64, a float64, a string or a []byte in
+// Value, while structures and arrays hold their members in Children
+type SchemaNode struct {
+	Name string
+
+	// BitOffset and BitSize describe the range of bits the node was
+	// parsed from. computed values have neither
+	BitOffset int64
+	BitSize   int64
+
+	Value    interface{}
+	Array    bool
+	Children []*SchemaNode
+}
+
+// child returns the last child of the node with the specified name
+func (n *SchemaNode) child(name string) *SchemaNode {
+
+	for i := len(n.Children) - 1; i >= 0; i-- {
+
+		if n.Children[i].Name == name {
+
+			return n.Children[i]
+
+		}
+
+	}
+	return nil
+
+}
+
+// Lookup returns the node located at the specified dot-separated path
+// relative to n, where array elements are named by their index, or
+// nil if there is none
+func (n *SchemaNode) Lookup(path string) *SchemaNode {
+
+	if path == "" {
+
+		return n
+
+	}
+
+	for _, name := range strings.Split(path, ".") {
+
+		if n = n.child(name); n == nil {
+
+			return nil
+
+		}
+
+	}
+	return n
+
+}
+
+// MarshalJSON encodes the node as a json value. structures become
+// objects with their fields in order, arrays become arrays and byte
+// arrays become hex strings
+func (n *SchemaNode) MarshalJSON() ([]byte, error) {
+
+	if n.Value != nil {
+
+		if data, ok := n.Value.([]byte); ok {
+
+			return json.Marshal(hex.EncodeToString(data))
+
+		}
+
+		if f, ok := n.Value.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+
+			return json.Marshal(strconv.FormatFloat(f, 'g', -1, 64))
+
+		}
+		return json.Marshal(n.Value)
+
+	}
+
+	var out bytes.Buffer
+	start, end := byte('{'), byte('}')
+	if n.Array {
+
+		start, end = '[', ']'
+
+	}
+
+	out.WriteByte(start)
+	for i, c := range n.Children {
+
+		if i > 0 {
+
+			out.WriteByte(',')
+
+		}
+
+		if !n.Array {
+
+			key, _ := json.Marshal(c.Name)
+			out.Write(key)
+			out.WriteByte(':')
+
+		}
+
+		data, err := c.MarshalJSON()
+		if err != nil {
+
+			return nil, err
+
+		}
+		out.Write(data)
+
+	}
+	out.WriteByte(end)
+
+	return out.Bytes(), nil
+
+}
@@ -0,0 +1,272 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+utilities
+
+*/
+
+// testSchema describes a small container made up of a header, a list
+// of tagged records and a trailer located at an absolute offset
+const testSchema = `{
+	"endian": "be",
+	"seq": [
+		{"id": "magic", "type": "str", "size": 4},
+		{"id": "version", "type": "b3"},
+		{"id": "compressed", "type": "b1"},
+		{"id": "reserved", "type": "b4"},
+		{"id": "count", "type": "u2le"},
+		{"id": "records", "type": "record", "repeat": "expr", "repeat-expr": "count"},
+		{"id": "extra", "type": "s2", "if": "version >= 2 and compressed == 1"},
+		{"id": "names", "type": "strz", "repeat": "until", "repeat-until": "_ == \"\""}
+	],
+	"instances": {
+		"trailer_pos": {"value": "_io.size - 4"},
+		"trailer": {"pos": "trailer_pos", "type": "u4"}
+	},
+	"types": {
+		"record": {
+			"seq": [
+				{"id": "tag", "type": "u1"},
+				{"id": "length", "type": "u1"},
+				{"id": "body", "size": "length", "type": {
+					"switch-on": "tag",
+					"cases": {"1": "point", "2": "str"}
+				}}
+			]
+		},
+		"point": {
+			"endian": "le",
+			"seq": [
+				{"id": "x", "type": "u2"},
+				{"id": "y", "type": "u2"}
+			]
+		}
+	}
+}`
+
+// testSchemaData is a valid instance of testSchema
+var testSchemaData = []byte{
+	'C', 'R', 'N', 'C',
+	0x50,
+	0x03, 0x00,
+	0x01, 0x04, 0x01, 0x00, 0x02, 0x00,
+	0x02, 0x02, 'h', 'i',
+	0x03, 0x01, 0xFF,
+	0xFF, 0xFE,
+	'a', 0x00, 0x00,
+	0xDE, 0xAD, 0xBE, 0xEF,
+}
+
+/*
+
+tests
+
+*/
+
+func TestSchemaParse(t *testing.T) {
+
+	s, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	root, err := s.Parse(NewBuffer(testSchemaData), 0x00)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	expected := map[string]interface{}{
+		"magic":            "CRNC",
+		"version":          uint64(2),
+		"compressed":       uint64(1),
+		"count":            uint64(3),
+		"records.0.body.x": uint64(1),
+		"records.0.body.y": uint64(2),
+		"records.1.body":   "hi",
+		"records.2.body":   []byte{0xFF},
+		"extra":            int64(-2),
+		"names.0":          "a",
+		"names.1":          "",
+		"trailer_pos":      int64(25),
+		"trailer":          uint64(0xDEADBEEF),
+	}
+
+	for path, value := range expected {
+
+		n := root.Lookup(path)
+		if n == nil {
+
+			t.Fatalf("expected node %s to exist", path)
+
+		}
+
+		if !cmp.Equal(value, n.Value) {
+
+			t.Fatalf("expected value of %s does not match the one gotten (got %#v, expected %#v)", path, n.Value, value)
+
+		}
+
+	}
+
+	if n := root.Lookup("records.1"); n.BitOffset != 13*8 || n.BitSize != 4*8 {
+
+		t.Fatalf("unexpected range of records.1 (got %d bits at %d)", n.BitSize, n.BitOffset)
+
+	}
+
+	if root.BitSize != 25*8 {
+
+		t.Fatalf("expected the sequence to span %d bits (got %d)", 25*8, root.BitSize)
+
+	}
+
+}
+
+func TestSchemaNodeMarshalJSON(t *testing.T) {
+
+	expected := `{"magic":"CRNC","version":2,"compressed":1,"reserved":0,"count":3,` +
+		`"records":[{"tag":1,"length":4,"body":{"x":1,"y":2}},{"tag":2,"length":2,"body":"hi"},{"tag":3,"length":1,"body":"ff"}],` +
+		`"extra":-2,"names":["a",""],"trailer_pos":25,"trailer":3735928559}`
+
+	s, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	root, err := s.Parse(NewBuffer(testSchemaData), 0x00)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	out, err := json.Marshal(root)
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if string(out) != expected {
+
+		t.Fatalf("expected json does not match the one gotten (got %s, expected %s)", out, expected)
+
+	}
+
+}
+
+func TestSchemaParseErrors(t *testing.T) {
+
+	s, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	// the second record claims to be longer than the data
+	data := append([]byte{}, testSchemaData[:15]...)
+	_, err = s.Parse(NewBuffer(data), 0x00)
+
+	details, ok := err.(Error)
+	if !ok || !details.Is(BufferOverreadError) || details.Path != "records.1.body" {
+
+		t.Fatalf("unexpected error (got %v)", err)
+
+	}
+
+}
+
+func TestSchemaParseInvalidInput(t *testing.T) {
+
+	tests := []struct {
+		schema string
+		data   []byte
+		err    Error
+		path   string
+	}{
+		{`{"seq": [{"id": "a", "type": "empty", "repeat": "eos"}], "types": {"empty": {"seq": []}}}`, []byte{0x00}, SchemaInvalidError, "a"},
+		{`{"seq": [{"id": "a", "type": "skipped", "repeat": "until", "repeat-until": "false"}], "types": {"skipped": {"seq": [{"id": "b", "type": "u1", "if": "false"}]}}}`, []byte{0x00}, SchemaInvalidError, "a"},
+		{`{"seq": [{"id": "n", "type": "u1"}, {"id": "a", "type": "empty", "repeat": "expr", "repeat-expr": "n"}], "types": {"empty": {"seq": []}}}`, []byte{0x02, 0x00}, SchemaInvalidError, "a"},
+		{`{"seq": [{"id": "n", "type": "u4be"}, {"id": "a", "type": "empty", "repeat": "expr", "repeat-expr": "n"}], "types": {"empty": {"seq": []}}}`, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0x00}, BufferOverreadError, "a"},
+		{`{"instances": {"a": {"pos": "10", "type": "strz"}}}`, []byte{0x61, 0x62, 0x00}, BufferOverreadError, "a"},
+		{`{"instances": {"a": {"pos": "-1", "type": "strz"}}}`, []byte{0x61, 0x62, 0x00}, BufferUnderreadError, "a"},
+	}
+
+	for _, test := range tests {
+
+		s, err := ParseSchema([]byte(test.schema))
+		if err != nil {
+
+			t.Fatal(err)
+
+		}
+
+		_, err = s.Parse(NewBuffer(test.data), 0x00)
+
+		details, ok := err.(Error)
+		if !ok || !details.Is(test.err) || details.Path != test.path {
+
+			t.Fatalf("unexpected error for %s (got %v, expected %v at %q)", test.schema, err, test.err, test.path)
+
+		}
+
+	}
+
+}
+
+func TestParseSchemaErrors(t *testing.T) {
+
+	tests := []struct {
+		schema string
+		err    Error
+		path   string
+	}{
+		{`{"seq": [{"id": "a", "type": "nope"}]}`, SchemaUnknownTypeError, "a"},
+		{`{"seq": [{"id": "a"}]}`, SchemaInvalidError, "a"},
+		{`{"seq": [{"id": "a", "type": "u4", "size": 2}]}`, SchemaInvalidError, "a"},
+		{`{"seq": [{"id": "a", "type": "u1", "if": "1 +"}]}`, SchemaInvalidExpressionError, "a"},
+		{`{"seq": [{"id": "a", "type": "u1", "repeat": "expr"}]}`, SchemaInvalidError, "a"},
+		{`{"endian": "me"}`, SchemaInvalidError, ""},
+		{`{"types": {"t": {"seq": [{"id": "b", "type": "b65"}]}}}`, SchemaUnknownTypeError, "t.b"},
+	}
+
+	for _, test := range tests {
+
+		_, err := ParseSchema([]byte(test.schema))
+
+		details, ok := err.(Error)
+		if !ok || !details.Is(test.err) || details.Path != test.path {
+
+			t.Fatalf("unexpected error for %s (got %v, expected %v at %q)", test.schema, err, test.err, test.path)
+
+		}
+
+	}
+
+}
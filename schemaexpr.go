@@ -0,0 +1,929 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// schemaExpr is a compiled schema expression. evaluating one yields an
+// int64, a float64, a bool, a string, a []byte or a *SchemaNode
+type schemaExpr func(sc *schemaScope) (interface{}, error)
+
+// schemaScope holds the state an expression is evaluated in
+type schemaScope struct {
+	parser *schemaParser
+	node   *SchemaNode
+	parent *schemaScope
+
+	// index is the index of the element being parsed by a repeated
+	// field, and current is the last element parsed by one
+	index   int64
+	current interface{}
+}
+
+// schemaIO is the value of the _io identifier
+type schemaIO struct {
+	parser *schemaParser
+}
+
+// schemaToken is a token of a schema expression. kind is one of 'n'
+// for numbers, 'f' for floats, 's' for strings, 'i' for identifiers
+// and 'o' for operators
+type schemaToken struct {
+	kind byte
+	text string
+	num  int64
+	flt  float64
+}
+
+// schemaOperators lists the operators understood by expressions,
+// longest first
+var schemaOperators = []string{
+	"<<", ">>", "<=", ">=", "==", "!=", "&&", "||",
+	"+", "-", "*", "/", "%", "&", "|", "^", "~", "!", "<", ">",
+	"(", ")", "[", "]", ".",
+}
+
+// schemaPrecedence lists the binary operators from the loosest to the
+// tightest binding
+var schemaPrecedence = [][]string{
+	{"||", "or"},
+	{"&&", "and"},
+	{"|"},
+	{"^"},
+	{"&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// tokenizeSchemaExpr splits an expression into tokens
+func tokenizeSchemaExpr(src string) (toks []schemaToken, err error) {
+
+	i := 0
+	for i < len(src) {
+
+		c := src[i]
+		switch {
+
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (isSchemaIdentByte(src[j]) || src[j] == '.') {
+
+				j++
+
+			}
+
+			text := strings.Replace(src[i:j], "_", "", -1)
+			if n, perr := strconv.ParseInt(text, 0, 64); perr == nil {
+
+				toks = append(toks, schemaToken{kind: 'n', text: text, num: n})
+
+			} else if f, perr := strconv.ParseFloat(text, 64); perr == nil {
+
+				toks = append(toks, schemaToken{kind: 'f', text: text, flt: f})
+
+			} else {
+
+				return nil, SchemaInvalidExpressionError
+
+			}
+			i = j
+
+		case isSchemaIdentByte(c):
+			j := i
+			for j < len(src) && isSchemaIdentByte(src[j]) {
+
+				j++
+
+			}
+
+			toks = append(toks, schemaToken{kind: 'i', text: src[i:j]})
+			i = j
+
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && src[j] != c {
+
+				j++
+
+			}
+
+			if j == len(src) {
+
+				return nil, SchemaInvalidExpressionError
+
+			}
+
+			toks = append(toks, schemaToken{kind: 's', text: src[i+1 : j]})
+			i = j + 1
+
+		default:
+			found := false
+			for _, op := range schemaOperators {
+
+				if strings.HasPrefix(src[i:], op) {
+
+					toks = append(toks, schemaToken{kind: 'o', text: op})
+					i += len(op)
+					found = true
+					break
+
+				}
+
+			}
+
+			if !found {
+
+				return nil, SchemaInvalidExpressionError
+
+			}
+
+		}
+
+	}
+
+	return
+
+}
+
+// isSchemaIdentByte reports whether c may appear in an identifier
+func isSchemaIdentByte(c byte) bool {
+
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+
+}
+
+// schemaExprParser implements a recursive descent parser over the
+// tokens of an expression
+type schemaExprParser struct {
+	toks []schemaToken
+	i    int
+}
+
+// compileSchemaExpr compiles the expression held in src
+func compileSchemaExpr(src string) (schemaExpr, error) {
+
+	toks, err := tokenizeSchemaExpr(src)
+	if err != nil {
+
+		return nil, err
+
+	}
+
+	p := &schemaExprParser{toks: toks}
+	e, err := p.binary(0)
+	if err != nil {
+
+		return nil, err
+
+	}
+
+	if p.i != len(p.toks) {
+
+		return nil, SchemaInvalidExpressionError
+
+	}
+	return e, nil
+
+}
+
+// peek returns the next token without consuming it
+func (p *schemaExprParser) peek() schemaToken {
+
+	if p.i < len(p.toks) {
+
+		return p.toks[p.i]
+
+	}
+	return schemaToken{}
+
+}
+
+// accept consumes the next token if it is the operator or keyword op
+func (p *schemaExprParser) accept(op string) bool {
+
+	if t := p.peek(); (t.kind == 'o' || t.kind == 'i') && t.text == op {
+
+		p.i++
+		return true
+
+	}
+	return false
+
+}
+
+// binary parses binary operators of the specified precedence level
+// and tighter
+func (p *schemaExprParser) binary(level int) (schemaExpr, error) {
+
+	if level == len(schemaPrecedence) {
+
+		return p.unary()
+
+	}
+
+	left, err := p.binary(level + 1)
+	if err != nil {
+
+		return nil, err
+
+	}
+
+	for {
+
+		op := ""
+		for _, o := range schemaPrecedence[level] {
+
+			if p.accept(o) {
+
+				op = o
+				break
+
+			}
+
+		}
+
+		if op == "" {
+
+			return left, nil
+
+		}
+
+		right, err := p.binary(level + 1)
+		if err != nil {
+
+			return nil, err
+
+		}
+		left = schemaBinary(op, left, right)
+
+	}
+
+}
+
+// unary parses unary operators
+func (p *schemaExprParser) unary() (schemaExpr, error) {
+
+	for _, op := range []string{"-", "!", "~", "not"} {
+
+		if !p.accept(op) {
+
+			continue
+
+		}
+
+		operand, err := p.unary()
+		if err != nil {
+
+			return nil, err
+
+		}
+
+		return func(sc *schemaScope) (interface{}, error) {
+
+			v, err := operand(sc)
+			if err != nil {
+
+				return nil, err
+
+			}
+
+			switch v := schemaNormalize(v).(type) {
+
+			case int64:
+				if op == "-" {
+
+					return -v, nil
+
+				} else if op == "~" {
+
+					return ^v, nil
+
+				}
+
+			case float64:
+				if op == "-" {
+
+					return -v, nil
+
+				}
+
+			case bool:
+				if op == "!" || op == "not" {
+
+					return !v, nil
+
+				}
+
+			}
+			return nil, SchemaTypeMismatchError
+
+		}, nil
+
+	}
+
+	return p.postfix()
+
+}
+
+// postfix parses a primary expression followed by any amount of
+// member accesses and indexes
+func (p *schemaExprParser) postfix() (schemaExpr, error) {
+
+	e, err := p.primary()
+	if err != nil {
+
+		return nil, err
+
+	}
+
+	for {
+
+		switch {
+
+		case p.accept("."):
+			t := p.peek()
+			if t.kind != 'i' {
+
+				return nil, SchemaInvalidExpressionError
+
+			}
+			p.i++
+
+			e = schemaMember(e, t.text)
+
+		case p.accept("["):
+			index, err := p.binary(0)
+			if err != nil {
+
+				return nil, err
+
+			}
+
+			if !p.accept("]") {
+
+				return nil, SchemaInvalidExpressionError
+
+			}
+
+			e = schemaIndex(e, index)
+
+		default:
+			return e, nil
+
+		}
+
+	}
+
+}
+
+// primary parses literals, identifiers and parenthesized expressions
+func (p *schemaExprParser) primary() (schemaExpr, error) {
+
+	t := p.peek()
+	p.i++
+
+	switch t.kind {
+
+	case 'n':
+		return schemaConst(t.num), nil
+
+	case 'f':
+		return schemaConst(t.flt), nil
+
+	case 's':
+		return schemaConst(t.text), nil
+
+	case 'i':
+		switch t.text {
+
+		case "true":
+			return schemaConst(true), nil
+
+		case "false":
+			return schemaConst(false), nil
+
+		}
+		return schemaIdent(t.text), nil
+
+	case 'o':
+		if t.text == "(" {
+
+			e, err := p.binary(0)
+			if err != nil {
+
+				return nil, err
+
+			}
+
+			if !p.accept(")") {
+
+				return nil, SchemaInvalidExpressionError
+
+			}
+			return e, nil
+
+		}
+
+	}
+	return nil, SchemaInvalidExpressionError
+
+}
+
+/* expression nodes */
+
+// schemaConst returns an expression that evaluates to v
+func schemaConst(v interface{}) schemaExpr {
+
+	return func(*schemaScope) (interface{}, error) {
+
+		return v, nil
+
+	}
+
+}
+
+// schemaIdent returns an expression that resolves name in the scope
+// it is evaluated in
+func schemaIdent(name string) schemaExpr {
+
+	return func(sc *schemaScope) (interface{}, error) {
+
+		switch name {
+
+		case "_root":
+			return sc.parser.root, nil
+
+		case "_parent":
+			if sc.parent == nil {
+
+				return nil, SchemaUnknownIdentifierError
+
+			}
+			return sc.parent.node, nil
+
+		case "_index":
+			return sc.index, nil
+
+		case "_":
+			if sc.current == nil {
+
+				return nil, SchemaUnknownIdentifierError
+
+			}
+			return sc.current, nil
+
+		case "_io":
+			return schemaIO{sc.parser}, nil
+
+		}
+
+		if n := sc.node.child(name); n != nil {
+
+			return n, nil
+
+		}
+		return nil, SchemaUnknownIdentifierError
+
+	}
+
+}
+
+// schemaMember returns an expression that accesses the member name of
+// the value e evaluates to
+func schemaMember(e schemaExpr, name string) schemaExpr {
+
+	return func(sc *schemaScope) (interface{}, error) {
+
+		v, err := e(sc)
+		if err != nil {
+
+			return nil, err
+
+		}
+
+		switch v := v.(type) {
+
+		case *SchemaNode:
+			if n := v.child(name); n != nil && !v.Array {
+
+				return n, nil
+
+			}
+
+			if name == "length" {
+
+				switch value := v.Value.(type) {
+
+				case nil:
+					return int64(len(v.Children)), nil
+
+				case []byte:
+					return int64(len(value)), nil
+
+				case string:
+					return int64(len(value)), nil
+
+				}
+
+			}
+
+		case schemaIO:
+			switch name {
+
+			case "size":
+				return v.parser.end, nil
+
+			case "pos":
+				return (v.parser.bit + 7) / 8, nil
+
+			case "eof":
+				return v.parser.bit >= v.parser.end*8, nil
+
+			}
+
+		}
+		return nil, SchemaUnknownIdentifierError
+
+	}
+
+}
+
+// schemaIndex returns an expression that indexes the array or byte
+// array e evaluates to
+func schemaIndex(e, index schemaExpr) schemaExpr {
+
+	return func(sc *schemaScope) (interface{}, error) {
+
+		v, err := e(sc)
+		if err != nil {
+
+			return nil, err
+
+		}
+
+		i, err := schemaInt(index, sc)
+		if err != nil {
+
+			return nil, err
+
+		}
+
+		if n, ok := v.(*SchemaNode); ok {
+
+			if n.Array && i >= 0 && i < int64(len(n.Children)) {
+
+				return n.Children[i], nil
+
+			}
+			v = n.Value
+
+		}
+
+		if b, ok := v.([]byte); ok && i >= 0 && i < int64(len(b)) {
+
+			return int64(b[i]), nil
+
+		}
+		return nil, SchemaUnknownIdentifierError
+
+	}
+
+}
+
+// schemaBinary returns an expression that applies the binary operator
+// op to the values left and right evaluate to
+func schemaBinary(op string, left, right schemaExpr) schemaExpr {
+
+	return func(sc *schemaScope) (interface{}, error) {
+
+		l, err := left(sc)
+		if err != nil {
+
+			return nil, err
+
+		}
+		l = schemaNormalize(l)
+
+		// logical operators short circuit
+		if op == "&&" || op == "and" || op == "||" || op == "or" {
+
+			lb, ok := l.(bool)
+			if !ok {
+
+				return nil, SchemaTypeMismatchError
+
+			}
+
+			if lb == (op == "||" || op == "or") {
+
+				return lb, nil
+
+			}
+			return schemaBool(right, sc)
+
+		}
+
+		r, err := right(sc)
+		if err != nil {
+
+			return nil, err
+
+		}
+		r = schemaNormalize(r)
+
+		switch op {
+
+		case "==":
+			return schemaEqual(l, r)
+
+		case "!=":
+			eq, err := schemaEqual(l, r)
+			return !eq, err
+
+		}
+
+		switch l := l.(type) {
+
+		case int64:
+			if r, ok := r.(int64); ok {
+
+				return schemaIntOp(op, l, r)
+
+			}
+
+			if r, ok := r.(float64); ok {
+
+				return schemaFloatOp(op, float64(l), r)
+
+			}
+
+		case float64:
+			switch r := r.(type) {
+
+			case int64:
+				return schemaFloatOp(op, l, float64(r))
+
+			case float64:
+				return schemaFloatOp(op, l, r)
+
+			}
+
+		case string:
+			if r, ok := r.(string); ok {
+
+				switch op {
+
+				case "+":
+					return l + r, nil
+
+				case "<":
+					return l < r, nil
+
+				case "<=":
+					return l <= r, nil
+
+				case ">":
+					return l > r, nil
+
+				case ">=":
+					return l >= r, nil
+
+				}
+
+			}
+
+		}
+		return nil, SchemaTypeMismatchError
+
+	}
+
+}
+
+// schemaIntOp applies op to two integers
+func schemaIntOp(op string, l, r int64) (interface{}, error) {
+
+	switch op {
+
+	case "+":
+		return l + r, nil
+
+	case "-":
+		return l - r, nil
+
+	case "*":
+		return l * r, nil
+
+	case "/", "%":
+		if r == 0 {
+
+			return nil, SchemaDivisionByZeroError
+
+		}
+
+		if op == "/" {
+
+			return l / r, nil
+
+		}
+		return l % r, nil
+
+	case "<<":
+		return l << uint64(r), nil
+
+	case ">>":
+		return l >> uint64(r), nil
+
+	case "&":
+		return l & r, nil
+
+	case "|":
+		return l | r, nil
+
+	case "^":
+		return l ^ r, nil
+
+	case "<":
+		return l < r, nil
+
+	case "<=":
+		return l <= r, nil
+
+	case ">":
+		return l > r, nil
+
+	case ">=":
+		return l >= r, nil
+
+	}
+	return nil, SchemaTypeMismatchError
+
+}
+
+// schemaFloatOp applies op to two floats
+func schemaFloatOp(op string, l, r float64) (interface{}, error) {
+
+	switch op {
+
+	case "+":
+		return l + r, nil
+
+	case "-":
+		return l - r, nil
+
+	case "*":
+		return l * r, nil
+
+	case "/":
+		return l / r, nil
+
+	case "<":
+		return l < r, nil
+
+	case "<=":
+		return l <= r, nil
+
+	case ">":
+		return l > r, nil
+
+	case ">=":
+		return l >= r, nil
+
+	}
+	return nil, SchemaTypeMismatchError
+
+}
+
+// schemaEqual reports whether two normalized values are equal
+func schemaEqual(l, r interface{}) (bool, error) {
+
+	switch l := l.(type) {
+
+	case int64:
+		switch r := r.(type) {
+
+		case int64:
+			return l == r, nil
+
+		case float64:
+			return float64(l) == r, nil
+
+		}
+
+	case float64:
+		switch r := r.(type) {
+
+		case int64:
+			return l == float64(r), nil
+
+		case float64:
+			return l == r, nil
+
+		}
+
+	case bool:
+		if r, ok := r.(bool); ok {
+
+			return l == r, nil
+
+		}
+
+	case string:
+		if r, ok := r.(string); ok {
+
+			return l == r, nil
+
+		}
+
+	case []byte:
+		if r, ok := r.([]byte); ok {
+
+			return bytes.Equal(l, r), nil
+
+		}
+
+	}
+	return false, SchemaTypeMismatchError
+
+}
+
+/* value conversion */
+
+// schemaNormalize converts the value of a parsed field to the type
+// used in expressions
+func schemaNormalize(v interface{}) interface{} {
+
+	if n, ok := v.(*SchemaNode); ok && n.Value != nil {
+
+		v = n.Value
+
+	}
+
+	switch v := v.(type) {
+
+	case uint64:
+		return int64(v)
+
+	case float32:
+		return float64(v)
+
+	}
+	return v
+
+}
+
+// schemaInt evaluates e and returns its value as an integer
+func schemaInt(e schemaExpr, sc *schemaScope) (int64, error) {
+
+	v, err := e(sc)
+	if err != nil {
+
+		return 0, err
+
+	}
+
+	if i, ok := schemaNormalize(v).(int64); ok {
+
+		return i, nil
+
+	}
+	return 0, SchemaTypeMismatchError
+
+}
+
+// schemaBool evaluates e and returns its value as a boolean
+func schemaBool(e schemaExpr, sc *schemaScope) (bool, error) {
+
+	v, err := e(sc)
+	if err != nil {
+
+		return false, err
+
+	}
+
+	if b, ok := schemaNormalize(v).(bool); ok {
+
+		return b, nil
+
+	}
+	return false, SchemaTypeMismatchError
+
+}
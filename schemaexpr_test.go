@@ -0,0 +1,105 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+tests
+
+*/
+
+func TestSchemaExprEval(t *testing.T) {
+
+	tests := []struct {
+		src      string
+		expected interface{}
+		err      Error
+	}{
+		{"1 + 2 * 3", int64(7), Error{}},
+		{"(1 + 2) * 3", int64(9), Error{}},
+		{"0x10 >> 2 | 1", int64(5), Error{}},
+		{"-count + ~0", int64(-4), Error{}},
+		{"count * 2 == 6 and not false", true, Error{}},
+		{"count > 5 || name == \"crunch\"", true, Error{}},
+		{"name + '!'", "crunch!", Error{}},
+		{"items[1].size - items[0].size", int64(11), Error{}},
+		{"items.length + data.length + name.length", int64(10), Error{}},
+		{"data[1]", int64(0x0B), Error{}},
+		{"_io.size - _io.pos", int64(14), Error{}},
+		{"_root.count % 2 != 0", true, Error{}},
+		{"1.5 * 2", float64(3), Error{}},
+		{"(1 + 2", nil, SchemaInvalidExpressionError},
+		{"1 $ 2", nil, SchemaInvalidExpressionError},
+		{"'open", nil, SchemaInvalidExpressionError},
+		{"missing + 1", nil, SchemaUnknownIdentifierError},
+		{"_parent", nil, SchemaUnknownIdentifierError},
+		{"name - 1", nil, SchemaTypeMismatchError},
+		{"count && true", nil, SchemaTypeMismatchError},
+		{"count / 0", nil, SchemaDivisionByZeroError},
+	}
+
+	// a few parsed fields, with the parser twelve bits into a
+	// sixteen bit stream
+	node := &SchemaNode{Children: []*SchemaNode{
+		{Name: "count", Value: uint64(3)},
+		{Name: "name", Value: "crunch"},
+		{Name: "data", Value: []byte{0x0A, 0x0B}},
+		{Name: "items", Array: true, Children: []*SchemaNode{
+			{Name: "0", Children: []*SchemaNode{{Name: "size", Value: int64(-4)}}},
+			{Name: "1", Children: []*SchemaNode{{Name: "size", Value: int64(7)}}},
+		}},
+	}}
+	scope := &schemaScope{parser: &schemaParser{root: node, bit: 12, end: 16}, node: node}
+
+	for _, test := range tests {
+
+		var out interface{}
+
+		e, err := compileSchemaExpr(test.src)
+		if err == nil {
+
+			out, err = e(scope)
+
+		}
+
+		if test.err != (Error{}) {
+
+			if e, ok := err.(Error); !ok || !e.Is(test.err) {
+
+				t.Fatalf("expected error for %q does not match the one gotten (got %v, expected %v)", test.src, err, test.err)
+
+			}
+			continue
+
+		}
+
+		if err != nil {
+
+			t.Fatalf("failed to evaluate %q: %v", test.src, err)
+
+		}
+
+		if !cmp.Equal(test.expected, schemaNormalize(out)) {
+
+			t.Fatalf("expected value of %q does not match the one gotten (got %#v, expected %#v)", test.src, out, test.expected)
+
+		}
+
+	}
+
+}
@@ -0,0 +1,565 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import "bytes"
+
+// Pattern implements a byte pattern where individual bits may be
+// ignored, for use in signature scanning
+type Pattern struct {
+	data []byte
+	mask []byte
+
+	// anchor is the offset of the longest run of bytes that have no
+	// ignored bits, which is searched for before checking the rest
+	anchor    int
+	anchorLen int
+}
+
+// NewPattern initializes a new Pattern that matches data where the
+// corresponding bits of mask are set. data and mask must be the same
+// length
+func NewPattern(data, mask []byte) (p *Pattern) {
+
+	if len(data) != len(mask) {
+
+		panic(PatternInvalidError)
+
+	}
+
+	p = &Pattern{
+		data: make([]byte, len(data)),
+		mask: append([]byte{}, mask...),
+	}
+
+	run := 0
+	for i := range data {
+
+		p.data[i] = data[i] & mask[i]
+
+		if mask[i] != 0xFF {
+
+			run = 0
+			continue
+
+		}
+
+		run++
+		if run > p.anchorLen {
+
+			p.anchor = i + 1 - run
+			p.anchorLen = run
+
+		}
+
+	}
+
+	return
+
+}
+
+// ParsePattern parses a pattern written as hex digits, where a ? in
+// place of a digit matches any nibble and whitespace is ignored. for
+// example, "DE ?? BE EF" matches 0xDE followed by any byte and 0xBEEF
+func ParsePattern(s string) (*Pattern, error) {
+
+	var digits []byte
+	for i := 0; i < len(s); i++ {
+
+		switch c := s[i]; c {
+
+		case ' ', '\t', '\n', '\r':
+
+		default:
+			digits = append(digits, c)
+
+		}
+
+	}
+
+	if len(digits)%2 != 0 {
+
+		return nil, PatternInvalidError
+
+	}
+
+	var (
+		data = make([]byte, len(digits)/2)
+		mask = make([]byte, len(digits)/2)
+	)
+
+	for i, c := range digits {
+
+		var v byte
+		switch {
+
+		case c == '?':
+			continue
+
+		case c >= '0' && c <= '9':
+			v = c - '0'
+
+		case c >= 'a' && c <= 'f':
+			v = c - 'a' + 10
+
+		case c >= 'A' && c <= 'F':
+			v = c - 'A' + 10
+
+		default:
+			return nil, PatternInvalidError
+
+		}
+
+		shift := uint(4 * (1 - i%2))
+		data[i/2] |= v << shift
+		mask[i/2] |= 0xF << shift
+
+	}
+
+	return NewPattern(data, mask), nil
+
+}
+
+// Len returns the length of the pattern in bytes
+func (p *Pattern) Len() int {
+
+	return len(p.data)
+
+}
+
+// Match reports whether data begins with the pattern
+func (p *Pattern) Match(data []byte) bool {
+
+	if len(data) < len(p.data) {
+
+		return false
+
+	}
+
+	for i, c := range p.data {
+
+		if data[i]&p.mask[i] != c {
+
+			return false
+
+		}
+
+	}
+	return true
+
+}
+
+// Index returns the offset of the first match of the pattern in data,
+// or -1 if there is none
+func (p *Pattern) Index(data []byte) int {
+
+	last := len(data) - len(p.data)
+	if p.anchorLen == 0 {
+
+		for i := 0; i <= last; i++ {
+
+			if p.Match(data[i:]) {
+
+				return i
+
+			}
+
+		}
+		return -1
+
+	}
+
+	anchor := p.data[p.anchor : p.anchor+p.anchorLen]
+	for i := 0; i <= last; i++ {
+
+		j := bytes.Index(data[i+p.anchor:], anchor)
+		if j < 0 || i+j > last {
+
+			return -1
+
+		}
+
+		i += j
+		if p.Match(data[i:]) {
+
+			return i
+
+		}
+
+	}
+	return -1
+
+}
+
+// LastIndex returns the offset of the last match of the pattern in
+// data, or -1 if there is none
+func (p *Pattern) LastIndex(data []byte) int {
+
+	last := len(data) - len(p.data)
+	if p.anchorLen == 0 {
+
+		for i := last; i >= 0; i-- {
+
+			if p.Match(data[i:]) {
+
+				return i
+
+			}
+
+		}
+		return -1
+
+	}
+
+	anchor := p.data[p.anchor : p.anchor+p.anchorLen]
+	for i := last; i >= 0; i-- {
+
+		i = bytes.LastIndex(data[p.anchor:i+p.anchor+p.anchorLen], anchor)
+		if i < 0 {
+
+			return -1
+
+		}
+
+		if p.Match(data[i:]) {
+
+			return i
+
+		}
+
+	}
+	return -1
+
+}
+
+// Matcher implements multi-pattern search using the aho-corasick
+// algorithm, which finds every occurrence of every pattern in a
+// single pass over the data
+type Matcher struct {
+	lens []int
+
+	// next is the transition table of the automaton and out holds
+	// the patterns that end at each state
+	next [][256]int32
+	out  [][]int32
+}
+
+// Match represents an occurrence of one of the patterns of a Matcher
+type Match struct {
+	// Pattern is the index of the pattern that matched
+	Pattern int
+
+	// Offset is the offset of the first byte of the match
+	Offset int64
+}
+
+// NewMatcher initializes a new Matcher that searches for the specified
+// patterns. empty patterns never match
+func NewMatcher(patterns ...[]byte) (m *Matcher) {
+
+	m = &Matcher{
+		lens: make([]int, len(patterns)),
+		next: make([][256]int32, 1),
+		out:  make([][]int32, 1),
+	}
+
+	// build the trie, with 0 standing for a missing edge since the
+	// root can never be the target of one
+	for i, p := range patterns {
+
+		m.lens[i] = len(p)
+		if len(p) == 0 {
+
+			continue
+
+		}
+
+		s := int32(0)
+		for _, c := range p {
+
+			if m.next[s][c] == 0 {
+
+				m.next = append(m.next, [256]int32{})
+				m.out = append(m.out, nil)
+				m.next[s][c] = int32(len(m.next) - 1)
+
+			}
+			s = m.next[s][c]
+
+		}
+		m.out[s] = append(m.out[s], int32(i))
+
+	}
+
+	// turn the trie into an automaton by filling in the missing edges
+	// with the ones of the longest proper suffix, breadth first
+	var (
+		fail  = make([]int32, len(m.next))
+		queue []int32
+	)
+
+	for c := 0; c < 256; c++ {
+
+		if s := m.next[0][c]; s != 0 {
+
+			queue = append(queue, s)
+
+		}
+
+	}
+
+	for len(queue) > 0 {
+
+		s := queue[0]
+		queue = queue[1:]
+
+		m.out[s] = append(m.out[s], m.out[fail[s]]...)
+		for c := 0; c < 256; c++ {
+
+			t := m.next[s][c]
+			if t == 0 {
+
+				m.next[s][c] = m.next[fail[s]][c]
+				continue
+
+			}
+
+			fail[t] = m.next[fail[s]][c]
+			queue = append(queue, t)
+
+		}
+
+	}
+
+	return
+
+}
+
+// scan calls fn for every match in data, in the order they end in,
+// until fn returns false. base is added to the reported offsets
+func (m *Matcher) scan(data []byte, base int64, fn func(Match) bool) {
+
+	s := int32(0)
+	for i, c := range data {
+
+		s = m.next[s][c]
+		for _, p := range m.out[s] {
+
+			if !fn(Match{int(p), base + int64(i+1-m.lens[p])}) {
+
+				return
+
+			}
+
+		}
+
+	}
+
+}
+
+// FindAll returns every match in data, including overlapping ones, in
+// the order they end in
+func (m *Matcher) FindAll(data []byte) (matches []Match) {
+
+	m.scan(data, 0, func(match Match) bool {
+
+		matches = append(matches, match)
+		return true
+
+	})
+	return
+
+}
+
+/* buffer methods */
+
+// checkSearch panics if off is not a valid offset to search from
+func (b *Buffer) checkSearch(off int64) {
+
+	if off > b.cap {
+
+		panic(BufferOverreadError.at(off, 0, b.cap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderreadError.at(off, 0, b.cap))
+
+	}
+
+}
+
+// Index returns the offset of the first occurrence of pattern in the
+// buffer, or -1 if there is none
+func (b *Buffer) Index(pattern []byte) int64 {
+
+	return int64(bytes.Index(b.buf, pattern))
+
+}
+
+// IndexFrom returns the offset of the first occurrence of pattern in
+// the buffer at or after the specified offset, or -1 if there is none
+func (b *Buffer) IndexFrom(off int64, pattern []byte) int64 {
+
+	b.checkSearch(off)
+
+	i := int64(bytes.Index(b.buf[off:], pattern))
+	if i < 0 {
+
+		return -1
+
+	}
+	return off + i
+
+}
+
+// IndexNext returns the offset of the first occurrence of pattern at
+// or after the current offset and moves the offset past it, so that
+// calling it again finds the next non-overlapping occurrence. if there
+// is none, -1 is returned and the offset is left untouched
+func (b *Buffer) IndexNext(pattern []byte) (off int64) {
+
+	if off = b.IndexFrom(b.off, pattern); off >= 0 {
+
+		b.SeekByte(off+int64(len(pattern)), false)
+
+	}
+	return
+
+}
+
+// LastIndex returns the offset of the last occurrence of pattern in
+// the buffer, or -1 if there is none
+func (b *Buffer) LastIndex(pattern []byte) int64 {
+
+	return int64(bytes.LastIndex(b.buf, pattern))
+
+}
+
+// Count returns the amount of non-overlapping occurrences of pattern
+// in the buffer
+func (b *Buffer) Count(pattern []byte) int64 {
+
+	if len(pattern) == 0 {
+
+		return b.cap + 1
+
+	}
+	return int64(bytes.Count(b.buf, pattern))
+
+}
+
+// IndexPattern returns the offset of the first match of p in the
+// buffer, or -1 if there is none
+func (b *Buffer) IndexPattern(p *Pattern) int64 {
+
+	return int64(p.Index(b.buf))
+
+}
+
+// IndexPatternFrom returns the offset of the first match of p in the
+// buffer at or after the specified offset, or -1 if there is none
+func (b *Buffer) IndexPatternFrom(off int64, p *Pattern) int64 {
+
+	b.checkSearch(off)
+
+	i := int64(p.Index(b.buf[off:]))
+	if i < 0 {
+
+		return -1
+
+	}
+	return off + i
+
+}
+
+// IndexPatternNext returns the offset of the first match of p at or
+// after the current offset and moves the offset past it, so that
+// calling it again finds the next non-overlapping match. if there is
+// none, -1 is returned and the offset is left untouched
+func (b *Buffer) IndexPatternNext(p *Pattern) (off int64) {
+
+	if off = b.IndexPatternFrom(b.off, p); off >= 0 {
+
+		b.SeekByte(off+int64(p.Len()), false)
+
+	}
+	return
+
+}
+
+// LastIndexPattern returns the offset of the last match of p in the
+// buffer, or -1 if there is none
+func (b *Buffer) LastIndexPattern(p *Pattern) int64 {
+
+	return int64(p.LastIndex(b.buf))
+
+}
+
+// CountPattern returns the amount of non-overlapping matches of p in
+// the buffer
+func (b *Buffer) CountPattern(p *Pattern) (n int64) {
+
+	if p.Len() == 0 {
+
+		return b.cap + 1
+
+	}
+
+	for off := 0; ; {
+
+		i := p.Index(b.buf[off:])
+		if i < 0 {
+
+			return
+
+		}
+
+		n++
+		off += i + p.Len()
+
+	}
+
+}
+
+// FindAll returns every match of m in the buffer, including
+// overlapping ones, in the order they end in
+func (b *Buffer) FindAll(m *Matcher) []Match {
+
+	return m.FindAll(b.buf)
+
+}
+
+// FindNext returns the first match of m to end at or after the
+// current offset and moves the offset past it, so that calling it
+// again finds the next non-overlapping match. if there is none, ok is
+// false and the offset is left untouched
+func (b *Buffer) FindNext(m *Matcher) (match Match, ok bool) {
+
+	b.checkSearch(b.off)
+
+	m.scan(b.buf[b.off:], b.off, func(found Match) bool {
+
+		match, ok = found, true
+		return false
+
+	})
+
+	if ok {
+
+		b.SeekByte(match.Offset+int64(m.lens[match.Pattern]), false)
+
+	}
+	return
+
+}
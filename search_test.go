@@ -0,0 +1,433 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+utilities
+
+*/
+
+// naivePatternIndexes returns the offset of every match of p in data
+func naivePatternIndexes(p *Pattern, data []byte) (out []int) {
+
+	for i := 0; i+p.Len() <= len(data); i++ {
+
+		if p.Match(data[i:]) {
+
+			out = append(out, i)
+
+		}
+
+	}
+	return
+
+}
+
+/*
+
+tests
+
+*/
+
+func TestBufferIndex(t *testing.T) {
+
+	buf := NewBuffer([]byte{
+		0x00, 0xDE, 0xAD, 0xBE, 0xEF, 0x00, 0xDE, 0x01,
+		0xBE, 0xEF, 0xCA, 0xFE, 0xBA, 0xBE, 0xDE, 0xAD,
+		0xBE, 0xEF,
+	})
+
+	if i := buf.Index([]byte{0xBE, 0xEF}); i != 0x03 {
+
+		t.Fatalf("expected offset does not match the one gotten (got %d, expected %d)", i, 0x03)
+
+	}
+
+	if i := buf.IndexFrom(0x04, []byte{0xBE, 0xEF}); i != 0x08 {
+
+		t.Fatalf("expected offset does not match the one gotten (got %d, expected %d)", i, 0x08)
+
+	}
+
+	if i := buf.LastIndex([]byte{0xBE, 0xEF}); i != 0x10 {
+
+		t.Fatalf("expected offset does not match the one gotten (got %d, expected %d)", i, 0x10)
+
+	}
+
+	if n := buf.Count([]byte{0xBE, 0xEF}); n != 3 {
+
+		t.Fatalf("expected count does not match the one gotten (got %d, expected %d)", n, 3)
+
+	}
+
+	if i := buf.IndexFrom(0x11, []byte{0xBE, 0xEF}); i != -1 {
+
+		t.Fatalf("expected no match (got %d)", i)
+
+	}
+
+	buf.SeekByte(0x02, false)
+	if i := buf.IndexNext([]byte{0xDE}); i != 0x06 || buf.ByteOffset() != 0x07 {
+
+		t.Fatalf("expected the offset to be moved past the match (got %d at %d)", i, buf.ByteOffset())
+
+	}
+
+	if i := buf.IndexNext([]byte{0xFF}); i != -1 || buf.ByteOffset() != 0x07 {
+
+		t.Fatalf("expected the offset to be left alone (got %d at %d)", i, buf.ByteOffset())
+
+	}
+
+}
+
+func TestBufferIndexPattern(t *testing.T) {
+
+	buf := NewBuffer([]byte{
+		0x00, 0xDE, 0xAD, 0xBE, 0xEF, 0x00, 0xDE, 0x01,
+		0xBE, 0xEF, 0xCA, 0xFE, 0xBA, 0xBE, 0xDE, 0xAD,
+		0xBE, 0xEF,
+	})
+
+	p, err := ParsePattern("DE ?? BE EF")
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if i := buf.IndexPattern(p); i != 0x01 {
+
+		t.Fatalf("expected offset does not match the one gotten (got %d, expected %d)", i, 0x01)
+
+	}
+
+	if i := buf.IndexPatternFrom(0x02, p); i != 0x06 {
+
+		t.Fatalf("expected offset does not match the one gotten (got %d, expected %d)", i, 0x06)
+
+	}
+
+	if i := buf.LastIndexPattern(p); i != 0x0E {
+
+		t.Fatalf("expected offset does not match the one gotten (got %d, expected %d)", i, 0x0E)
+
+	}
+
+	if n := buf.CountPattern(p); n != 3 {
+
+		t.Fatalf("expected count does not match the one gotten (got %d, expected %d)", n, 3)
+
+	}
+
+	// nibble wildcards
+	p, _ = ParsePattern("c? f? b? ?e")
+	if i := buf.IndexPatternNext(p); i != 0x0A || buf.ByteOffset() != 0x0E {
+
+		t.Fatalf("expected the offset to be moved past the match (got %d at %d)", i, buf.ByteOffset())
+
+	}
+
+}
+
+func TestBufferIndexNextLoop(t *testing.T) {
+
+	var (
+		expected = []int64{0x03, 0x08, 0x10}
+		data     = []byte{
+			0x00, 0xDE, 0xAD, 0xBE, 0xEF, 0x00, 0xDE, 0x01,
+			0xBE, 0xEF, 0xCA, 0xFE, 0xBA, 0xBE, 0xDE, 0xAD,
+			0xBE, 0xEF,
+		}
+		m = NewMatcher([]byte{0xBE, 0xEF})
+	)
+
+	p, err := ParsePattern("BE EF")
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	for _, next := range []func(b *Buffer) int64{
+		func(b *Buffer) int64 { return b.IndexNext([]byte{0xBE, 0xEF}) },
+		func(b *Buffer) int64 { return b.IndexPatternNext(p) },
+		func(b *Buffer) int64 {
+
+			if match, ok := b.FindNext(m); ok {
+
+				return match.Offset
+
+			}
+			return -1
+
+		},
+	} {
+
+		var (
+			buf = NewBuffer(data)
+			out []int64
+		)
+
+		// each call resumes after the previous match
+		for off := next(buf); off >= 0; off = next(buf) {
+
+			out = append(out, off)
+
+		}
+
+		if !cmp.Equal(expected, out) {
+
+			t.Fatalf("expected offsets do not match the ones gotten (got %#v, expected %#v)", out, expected)
+
+		}
+
+	}
+
+}
+
+func TestPatternIndexRandom(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+
+	data := make([]byte, 4096)
+	for i := range data {
+
+		data[i] = byte(r.Intn(4))
+
+	}
+
+	for _, s := range []string{"01 ?? 02", "0? 03 03 ?1", "?? ??", "00 01 02 03", "03 ?? ?? ?? 03"} {
+
+		p, err := ParsePattern(s)
+		if err != nil {
+
+			t.Fatal(err)
+
+		}
+
+		expected := naivePatternIndexes(p, data)
+		if i := p.Index(data); i != expected[0] {
+
+			t.Fatalf("expected first offset of %q does not match the one gotten (got %d, expected %d)", s, i, expected[0])
+
+		}
+
+		if i := p.LastIndex(data); i != expected[len(expected)-1] {
+
+			t.Fatalf("expected last offset of %q does not match the one gotten (got %d, expected %d)", s, i, expected[len(expected)-1])
+
+		}
+
+	}
+
+}
+
+func TestParsePatternErrors(t *testing.T) {
+
+	for _, s := range []string{"DE A", "DE GG", "D E ? ?x"} {
+
+		if _, err := ParsePattern(s); err != PatternInvalidError {
+
+			t.Fatalf("expected pattern %q to be rejected (got %v)", s, err)
+
+		}
+
+	}
+
+}
+
+func TestBufferFindAll(t *testing.T) {
+
+	expected := []Match{
+		{0, 0x01},
+		{2, 0x03},
+		{2, 0x08},
+		{1, 0x0A},
+		{0, 0x0E},
+		{2, 0x10},
+	}
+
+	m := NewMatcher([]byte{0xDE, 0xAD, 0xBE, 0xEF}, []byte{0xCA, 0xFE, 0xBA, 0xBE}, []byte{0xBE, 0xEF}, []byte{})
+
+	buf := NewBuffer([]byte{
+		0x00, 0xDE, 0xAD, 0xBE, 0xEF, 0x00, 0xDE, 0x01,
+		0xBE, 0xEF, 0xCA, 0xFE, 0xBA, 0xBE, 0xDE, 0xAD,
+		0xBE, 0xEF,
+	})
+
+	out := buf.FindAll(m)
+	if !cmp.Equal(expected, out) {
+
+		t.Fatalf("expected matches do not match the ones gotten (got %#v, expected %#v)", out, expected)
+
+	}
+
+	buf.SeekByte(0x09, false)
+	if match, ok := buf.FindNext(m); !ok || match != expected[3] || buf.ByteOffset() != 0x0E {
+
+		t.Fatalf("expected the offset to be moved past the match (got %#v at %d)", match, buf.ByteOffset())
+
+	}
+
+}
+
+func TestMatcherRandom(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+
+	data := make([]byte, 4096)
+	for i := range data {
+
+		data[i] = byte(r.Intn(3))
+
+	}
+
+	patterns := [][]byte{{0}, {0, 1}, {1, 0, 1}, {2, 2, 2, 2}, {0, 1, 2, 0}}
+
+	var expected []Match
+	for end := 1; end <= len(data); end++ {
+
+		for i, p := range patterns {
+
+			if end >= len(p) && bytes.Equal(data[end-len(p):end], p) {
+
+				expected = append(expected, Match{i, int64(end - len(p))})
+
+			}
+
+		}
+
+	}
+
+	out := NewMatcher(patterns...).FindAll(data)
+
+	// matches ending at the same offset may be reported in any order
+	less := func(a, b Match) bool {
+
+		return a.Offset+int64(len(patterns[a.Pattern])) < b.Offset+int64(len(patterns[b.Pattern])) ||
+			(a.Offset+int64(len(patterns[a.Pattern])) == b.Offset+int64(len(patterns[b.Pattern])) && a.Pattern < b.Pattern)
+
+	}
+
+	sortMatches := func(m []Match) {
+
+		for i := 1; i < len(m); i++ {
+
+			for j := i; j > 0 && less(m[j], m[j-1]); j-- {
+
+				m[j], m[j-1] = m[j-1], m[j]
+
+			}
+
+		}
+
+	}
+
+	sortMatches(expected)
+	sortMatches(out)
+
+	if !cmp.Equal(expected, out) {
+
+		t.Fatalf("expected %d matches do not match the %d gotten", len(expected), len(out))
+
+	}
+
+}
+
+func TestBufferIndexFromPanic(t *testing.T) {
+
+	defer panicChecker(t, BufferOverreadError)
+
+	buf := NewBuffer(make([]byte, 0x12))
+
+	_ = buf.IndexFrom(0x13, []byte{0x00})
+
+}
+
+/*
+
+benchmarks
+
+*/
+
+// benchmarkSearchData is a mebibyte of random data with a signature
+// at the end
+var benchmarkSearchData = func() []byte {
+
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	copy(data[len(data)-8:], []byte{0x7F, 'E', 'L', 'F', 0x02, 0x01, 0x01, 0x00})
+	return data
+
+}()
+
+func BenchmarkBufferIndex(b *testing.B) {
+
+	buf := NewBuffer(benchmarkSearchData)
+
+	b.SetBytes(int64(len(benchmarkSearchData)))
+	for i := 0; i < b.N; i++ {
+
+		_ = buf.Index([]byte{0x7F, 'E', 'L', 'F'})
+
+	}
+
+}
+
+func BenchmarkBufferIndexPattern(b *testing.B) {
+
+	var (
+		buf  = NewBuffer(benchmarkSearchData)
+		p, _ = ParsePattern("7F 45 4C 46 ?? 01 01")
+	)
+
+	b.SetBytes(int64(len(benchmarkSearchData)))
+	for i := 0; i < b.N; i++ {
+
+		_ = buf.IndexPattern(p)
+
+	}
+
+}
+
+func BenchmarkBufferFindAll(b *testing.B) {
+
+	var (
+		buf = NewBuffer(benchmarkSearchData)
+		m   = NewMatcher(
+			[]byte{0x7F, 'E', 'L', 'F'},
+			[]byte{0x89, 'P', 'N', 'G'},
+			[]byte{'P', 'K', 0x03, 0x04},
+			[]byte{0x1F, 0x8B, 0x08},
+			[]byte{0xCA, 0xFE, 0xBA, 0xBE},
+		)
+	)
+
+	b.SetBytes(int64(len(benchmarkSearchData)))
+	for i := 0; i < b.N; i++ {
+
+		_ = buf.FindAll(m)
+
+	}
+
+}
@@ -0,0 +1,919 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"io"
+	"net"
+	"sort"
+)
+
+// SegmentedBuffer implements a buffer made up of the byte slices it
+// was given, which are never copied until Flatten is called. reads
+// and writes may straddle the boundaries between segments, and writes
+// modify the original slices
+type SegmentedBuffer struct {
+	segs   [][]byte
+	starts []int64
+	off    int64
+	cap    int64
+	boff   int64
+	bcap   int64
+}
+
+// NewSegmentedBuffer initializes a new SegmentedBuffer with the
+// provided byte slice(s) as its segments in the order provided
+func NewSegmentedBuffer(slices ...[]byte) (buf *SegmentedBuffer) {
+
+	buf = &SegmentedBuffer{}
+	buf.Append(slices...)
+	return
+
+}
+
+/* internal use methods */
+
+// segment returns the index of the segment holding the byte located
+// at off
+func (b *SegmentedBuffer) segment(off int64) int {
+
+	return sort.Search(len(b.starts), func(i int) bool {
+
+		return b.starts[i] > off
+
+	}) - 1
+
+}
+
+// span returns the n bytes located at off, sharing memory with the
+// segment holding them if they do not straddle a boundary
+func (b *SegmentedBuffer) span(off, n int64) []byte {
+
+	if n == 0 {
+
+		return []byte{}
+
+	}
+
+	var (
+		i   = b.segment(off)
+		rel = off - b.starts[i]
+	)
+	if rel+n <= int64(len(b.segs[i])) {
+
+		return b.segs[i][rel : rel+n]
+
+	}
+
+	out := make([]byte, 0, n)
+	for int64(len(out)) < n {
+
+		end := rel + n - int64(len(out))
+		if end > int64(len(b.segs[i])) {
+
+			end = int64(len(b.segs[i]))
+
+		}
+
+		out = append(out, b.segs[i][rel:end]...)
+		i++
+		rel = 0
+
+	}
+
+	return out
+
+}
+
+// checkRead panics if n bytes located at off cannot be read
+func (b *SegmentedBuffer) checkRead(off, n int64) {
+
+	if (off + n) > b.cap {
+
+		panic(BufferOverreadError.at(off, n, b.cap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderreadError.at(off, n, b.cap))
+
+	}
+
+	if n < 0 {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+
+}
+
+// view returns a Buffer over the n bytes located at off, used to
+// decode values with the methods of Buffer
+func (b *SegmentedBuffer) view(off, n int64) *Buffer {
+
+	b.checkRead(off, n)
+	return NewBuffer(b.span(off, n))
+
+}
+
+// bitAt returns a pointer to the byte holding the bit located at off
+func (b *SegmentedBuffer) bitAt(off int64) *byte {
+
+	var (
+		byteOff = off / 8
+		i       = b.segment(byteOff)
+	)
+
+	return &b.segs[i][byteOff-b.starts[i]]
+
+}
+
+/* bitfield methods */
+
+// ReadBit returns the bit located at the specified offset without
+// modifying the internal offset value
+func (b *SegmentedBuffer) ReadBit(off int64) byte {
+
+	if off > (b.bcap - 1) {
+
+		panic(BufferOverreadError.atBit(off, 1, b.bcap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderreadError.atBit(off, 1, b.bcap))
+
+	}
+
+	return (*b.bitAt(off) >> (7 - uint64(off%8))) & 1
+
+}
+
+// ReadBitNext returns the next bit from the current offset and moves
+// the offset forward a bit
+func (b *SegmentedBuffer) ReadBitNext() (out byte) {
+
+	out = b.ReadBit(b.boff)
+	b.SeekBit(1, true)
+	return
+
+}
+
+// ReadBits returns the next n bits from the specified offset without
+// modifying the internal offset value. n must be between 0 and 64
+func (b *SegmentedBuffer) ReadBits(off, n int64) (out uint64) {
+
+	if n < 0 || n > 64 {
+
+		panic(BufferInvalidBitCountError)
+
+	}
+
+	if (off + n) > b.bcap {
+
+		panic(BufferOverreadError.atBit(off, n, b.bcap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderreadError.atBit(off, n, b.bcap))
+
+	}
+
+	for i := int64(0); i < n; i++ {
+
+		out = (out << 1) | uint64(b.ReadBit(off+i))
+
+	}
+	return
+
+}
+
+// ReadBitsNext returns the next n bits from the current offset and
+// moves the offset forward the amount of bits read
+func (b *SegmentedBuffer) ReadBitsNext(n int64) (out uint64) {
+
+	out = b.ReadBits(b.boff, n)
+	b.SeekBit(n, true)
+	return
+
+}
+
+// SetBit sets the bit located at the specified offset without
+// modifying the internal offset value
+func (b *SegmentedBuffer) SetBit(off int64) {
+
+	if off > (b.bcap - 1) {
+
+		panic(BufferOverwriteError.atBit(off, 1, b.bcap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderwriteError.atBit(off, 1, b.bcap))
+
+	}
+
+	*b.bitAt(off) |= (1 << uint(7-(off%8)))
+
+}
+
+// SetBitNext sets the next bit from the current offset and moves the
+// offset forward a bit
+func (b *SegmentedBuffer) SetBitNext() {
+
+	b.SetBit(b.boff)
+	b.SeekBit(1, true)
+
+}
+
+// ClearBit clears the bit located at the specified offset without
+// modifying the internal offset value
+func (b *SegmentedBuffer) ClearBit(off int64) {
+
+	if off > (b.bcap - 1) {
+
+		panic(BufferOverwriteError.atBit(off, 1, b.bcap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderwriteError.atBit(off, 1, b.bcap))
+
+	}
+
+	*b.bitAt(off) &= ^(1 << uint(7-(off%8)))
+
+}
+
+// ClearBitNext clears the next bit from the current offset and moves
+// the offset forward a bit
+func (b *SegmentedBuffer) ClearBitNext() {
+
+	b.ClearBit(b.boff)
+	b.SeekBit(1, true)
+
+}
+
+// SetBits sets the next n bits from the specified offset without
+// modifying the internal offset value. n must be between 0 and 64
+func (b *SegmentedBuffer) SetBits(off int64, data uint64, n int64) {
+
+	if n < 0 || n > 64 {
+
+		panic(BufferInvalidBitCountError)
+
+	}
+
+	if (off + n) > b.bcap {
+
+		panic(BufferOverwriteError.atBit(off, n, b.bcap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderwriteError.atBit(off, n, b.bcap))
+
+	}
+
+	for i := int64(0); i < n; i++ {
+
+		if byte((data>>uint64(n-i-1))&1) == 0 {
+
+			b.ClearBit(off + i)
+
+		} else {
+
+			b.SetBit(off + i)
+
+		}
+
+	}
+
+}
+
+// SetBitsNext sets the next n bits from the current offset and moves
+// the offset forward the amount of bits set
+func (b *SegmentedBuffer) SetBitsNext(data uint64, n int64) {
+
+	b.SetBits(b.boff, data, n)
+	b.SeekBit(n, true)
+
+}
+
+// FlipBit flips the bit located at the specified offset without
+// modifying the internal offset value
+func (b *SegmentedBuffer) FlipBit(off int64) {
+
+	if off > (b.bcap - 1) {
+
+		panic(BufferOverwriteError.atBit(off, 1, b.bcap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderwriteError.atBit(off, 1, b.bcap))
+
+	}
+
+	*b.bitAt(off) ^= (1 << uint(7-(off%8)))
+
+}
+
+// FlipBitNext flips the next bit from the current offset and moves
+// the offset forward a bit
+func (b *SegmentedBuffer) FlipBitNext() {
+
+	b.FlipBit(b.boff)
+	b.SeekBit(1, true)
+
+}
+
+// SeekBit seeks to bit position off of the the buffer relative to
+// the current position or exact
+func (b *SegmentedBuffer) SeekBit(off int64, relative bool) {
+
+	if relative {
+
+		b.boff += off
+
+	} else {
+
+		b.boff = off
+
+	}
+
+}
+
+// AfterBit returns the amount of bits located after the current bit
+// position or the specified one
+func (b *SegmentedBuffer) AfterBit(off ...int64) int64 {
+
+	if len(off) == 0 {
+
+		return b.bcap - b.boff - 1
+
+	}
+	return b.bcap - off[0] - 1
+
+}
+
+// AlignBit aligns the bit offset to the byte offset
+func (b *SegmentedBuffer) AlignBit() {
+
+	b.boff = b.off * 8
+
+}
+
+/* byte buffer methods */
+
+// WriteBytes writes bytes to the buffer at the specified offset
+// without modifying the internal offset value
+func (b *SegmentedBuffer) WriteBytes(off int64, data []byte) {
+
+	if (off + int64(len(data))) > b.cap {
+
+		panic(BufferOverwriteError.at(off, int64(len(data)), b.cap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderwriteError.at(off, int64(len(data)), b.cap))
+
+	}
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	var (
+		i   = b.segment(off)
+		rel = off - b.starts[i]
+	)
+	for len(data) > 0 {
+
+		k := copy(b.segs[i][rel:], data)
+		data = data[k:]
+		i++
+		rel = 0
+
+	}
+
+}
+
+// WriteBytesNext writes bytes to the buffer at the current offset
+// and moves the offset forward the amount of bytes written
+func (b *SegmentedBuffer) WriteBytesNext(data []byte) {
+
+	b.WriteBytes(b.off, data)
+	b.SeekByte(int64(len(data)), true)
+
+}
+
+// WriteByteAt writes a byte to the buffer at the specified offset
+// without modifying the internal offset value
+func (b *SegmentedBuffer) WriteByteAt(off int64, data byte) {
+
+	b.WriteBytes(off, []byte{data})
+
+}
+
+// WriteByteNext writes a byte to the buffer at the current
+// offset and moves the offset forward the amount of bytes written
+func (b *SegmentedBuffer) WriteByteNext(data byte) {
+
+	b.WriteBytes(b.off, []byte{data})
+	b.SeekByte(1, true)
+
+}
+
+// WriteU16LE writes a slice of uint16s to the buffer at the
+// specified offset in little-endian without modifying the internal
+// offset value
+func (b *SegmentedBuffer) WriteU16LE(off int64, data []uint16) {
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	tmp := NewBuffer(make([]byte, len(data)*2))
+	tmp.WriteU16LE(0x00, data)
+	b.WriteBytes(off, tmp.buf)
+
+}
+
+// WriteU16LENext writes a slice of uint16s to the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes written
+func (b *SegmentedBuffer) WriteU16LENext(data []uint16) {
+
+	b.WriteU16LE(b.off, data)
+	b.SeekByte(int64(len(data))*2, true)
+
+}
+
+// WriteU16BE writes a slice of uint16s to the buffer at the
+// specified offset in big-endian without modifying the internal
+// offset value
+func (b *SegmentedBuffer) WriteU16BE(off int64, data []uint16) {
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	tmp := NewBuffer(make([]byte, len(data)*2))
+	tmp.WriteU16BE(0x00, data)
+	b.WriteBytes(off, tmp.buf)
+
+}
+
+// WriteU16BENext writes a slice of uint16s to the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes written
+func (b *SegmentedBuffer) WriteU16BENext(data []uint16) {
+
+	b.WriteU16BE(b.off, data)
+	b.SeekByte(int64(len(data))*2, true)
+
+}
+
+// WriteU32LE writes a slice of uint32s to the buffer at the
+// specified offset in little-endian without modifying the internal
+// offset value
+func (b *SegmentedBuffer) WriteU32LE(off int64, data []uint32) {
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	tmp := NewBuffer(make([]byte, len(data)*4))
+	tmp.WriteU32LE(0x00, data)
+	b.WriteBytes(off, tmp.buf)
+
+}
+
+// WriteU32LENext writes a slice of uint32s to the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes written
+func (b *SegmentedBuffer) WriteU32LENext(data []uint32) {
+
+	b.WriteU32LE(b.off, data)
+	b.SeekByte(int64(len(data))*4, true)
+
+}
+
+// WriteU32BE writes a slice of uint32s to the buffer at the
+// specified offset in big-endian without modifying the internal
+// offset value
+func (b *SegmentedBuffer) WriteU32BE(off int64, data []uint32) {
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	tmp := NewBuffer(make([]byte, len(data)*4))
+	tmp.WriteU32BE(0x00, data)
+	b.WriteBytes(off, tmp.buf)
+
+}
+
+// WriteU32BENext writes a slice of uint32s to the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes written
+func (b *SegmentedBuffer) WriteU32BENext(data []uint32) {
+
+	b.WriteU32BE(b.off, data)
+	b.SeekByte(int64(len(data))*4, true)
+
+}
+
+// WriteU64LE writes a slice of uint64s to the buffer at the specfied
+// offset in little-endian without modifying the internal offset value
+func (b *SegmentedBuffer) WriteU64LE(off int64, data []uint64) {
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	tmp := NewBuffer(make([]byte, len(data)*8))
+	tmp.WriteU64LE(0x00, data)
+	b.WriteBytes(off, tmp.buf)
+
+}
+
+// WriteU64LENext writes a slice of uint64s to the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes written
+func (b *SegmentedBuffer) WriteU64LENext(data []uint64) {
+
+	b.WriteU64LE(b.off, data)
+	b.SeekByte(int64(len(data))*8, true)
+
+}
+
+// WriteU64BE writes a slice of uint64s to the buffer at the
+// specified offset in big-endian without modifying the internal
+// offset value
+func (b *SegmentedBuffer) WriteU64BE(off int64, data []uint64) {
+
+	if len(data) == 0 {
+
+		return
+
+	}
+
+	tmp := NewBuffer(make([]byte, len(data)*8))
+	tmp.WriteU64BE(0x00, data)
+	b.WriteBytes(off, tmp.buf)
+
+}
+
+// WriteU64BENext writes a slice of uint64s to the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes written
+func (b *SegmentedBuffer) WriteU64BENext(data []uint64) {
+
+	b.WriteU64BE(b.off, data)
+	b.SeekByte(int64(len(data))*8, true)
+
+}
+
+// ReadBytes returns the next n bytes from the specified offset
+// without modifying the internal offset value. the bytes are only
+// copied if they straddle a segment boundary
+func (b *SegmentedBuffer) ReadBytes(off, n int64) []byte {
+
+	b.checkRead(off, n)
+	return b.span(off, n)
+
+}
+
+// ReadBytesNext returns the next n bytes from the current offset
+// and moves the offset forward the amount of bytes read
+func (b *SegmentedBuffer) ReadBytesNext(n int64) (out []byte) {
+
+	out = b.ReadBytes(b.off, n)
+	b.SeekByte(n, true)
+	return
+
+}
+
+// ReadByteAt returns the next byte from the specified offset without
+// modifying the internal offset value
+func (b *SegmentedBuffer) ReadByteAt(off int64) byte {
+
+	return b.ReadBytes(off, 1)[0]
+
+}
+
+// ReadByteNext returns the next byte from the current offset and
+// moves the offset forward a byte
+func (b *SegmentedBuffer) ReadByteNext() (out byte) {
+
+	out = b.ReadBytes(b.off, 1)[0]
+	b.SeekByte(1, true)
+	return
+
+}
+
+// ReadU16LE reads a slice of uint16s from the buffer at the
+// specified offset in little-endian without modifying the internal
+// offset value
+func (b *SegmentedBuffer) ReadU16LE(off, n int64) []uint16 {
+
+	return b.view(off, n*2).ReadU16LE(0x00, n)
+
+}
+
+// ReadU16LENext reads a slice of uint16s from the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes read
+func (b *SegmentedBuffer) ReadU16LENext(n int64) (out []uint16) {
+
+	out = b.ReadU16LE(b.off, n)
+	b.SeekByte(n*2, true)
+	return
+
+}
+
+// ReadU16BE reads a slice of uint16s from the buffer at the
+// specified offset in big-endian without modifying the internal
+// offset value
+func (b *SegmentedBuffer) ReadU16BE(off, n int64) []uint16 {
+
+	return b.view(off, n*2).ReadU16BE(0x00, n)
+
+}
+
+// ReadU16BENext reads a slice of uint16s from the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes read
+func (b *SegmentedBuffer) ReadU16BENext(n int64) (out []uint16) {
+
+	out = b.ReadU16BE(b.off, n)
+	b.SeekByte(n*2, true)
+	return
+
+}
+
+// ReadU32LE reads a slice of uint32s from the buffer at the
+// specified offset in little-endian without modifying the internal
+// offset value
+func (b *SegmentedBuffer) ReadU32LE(off, n int64) []uint32 {
+
+	return b.view(off, n*4).ReadU32LE(0x00, n)
+
+}
+
+// ReadU32LENext reads a slice of uint32s from the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes read
+func (b *SegmentedBuffer) ReadU32LENext(n int64) (out []uint32) {
+
+	out = b.ReadU32LE(b.off, n)
+	b.SeekByte(n*4, true)
+	return
+
+}
+
+// ReadU32BE reads a slice of uint32s from the buffer at the
+// specified offset in big-endian without modifying the internal
+// offset value
+func (b *SegmentedBuffer) ReadU32BE(off, n int64) []uint32 {
+
+	return b.view(off, n*4).ReadU32BE(0x00, n)
+
+}
+
+// ReadU32BENext reads a slice of uint32s from the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes read
+func (b *SegmentedBuffer) ReadU32BENext(n int64) (out []uint32) {
+
+	out = b.ReadU32BE(b.off, n)
+	b.SeekByte(n*4, true)
+	return
+
+}
+
+// ReadU64LE reads a slice of uint64s from the buffer at the
+// specified offset in little-endian without modifying the internal
+// offset value
+func (b *SegmentedBuffer) ReadU64LE(off, n int64) []uint64 {
+
+	return b.view(off, n*8).ReadU64LE(0x00, n)
+
+}
+
+// ReadU64LENext reads a slice of uint64s from the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes read
+func (b *SegmentedBuffer) ReadU64LENext(n int64) (out []uint64) {
+
+	out = b.ReadU64LE(b.off, n)
+	b.SeekByte(n*8, true)
+	return
+
+}
+
+// ReadU64BE reads a slice of uint64s from the buffer at the
+// specified offset in big-endian without modifying the internal
+// offset value
+func (b *SegmentedBuffer) ReadU64BE(off, n int64) []uint64 {
+
+	return b.view(off, n*8).ReadU64BE(0x00, n)
+
+}
+
+// ReadU64BENext reads a slice of uint64s from the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes read
+func (b *SegmentedBuffer) ReadU64BENext(n int64) (out []uint64) {
+
+	out = b.ReadU64BE(b.off, n)
+	b.SeekByte(n*8, true)
+	return
+
+}
+
+// SeekByte seeks to position off of the buffer relative to the
+// current position or exact
+func (b *SegmentedBuffer) SeekByte(off int64, relative bool) {
+
+	if relative {
+
+		b.off += off
+
+	} else {
+
+		b.off = off
+
+	}
+
+}
+
+// AfterByte returns the amount of bytes located after the current
+// position or the specified one
+func (b *SegmentedBuffer) AfterByte(off ...int64) int64 {
+
+	if len(off) == 0 {
+
+		return b.cap - b.off - 1
+
+	}
+	return b.cap - off[0] - 1
+
+}
+
+// AlignByte aligns the byte offset to the bit offset
+func (b *SegmentedBuffer) AlignByte() {
+
+	b.off = b.boff / 8
+
+}
+
+/* generic methods */
+
+// Append adds the provided byte slice(s) to the end of the buffer as
+// new segments without copying them
+func (b *SegmentedBuffer) Append(slices ...[]byte) {
+
+	for _, s := range slices {
+
+		if len(s) == 0 {
+
+			continue
+
+		}
+
+		b.segs = append(b.segs, s)
+		b.starts = append(b.starts, b.cap)
+		b.cap += int64(len(s))
+
+	}
+	b.bcap = b.cap * 8
+
+}
+
+// Flatten copies every segment into a single one and returns it. the
+// buffer no longer shares memory with the original slices afterwards
+func (b *SegmentedBuffer) Flatten() []byte {
+
+	if len(b.segs) <= 1 {
+
+		if len(b.segs) == 0 {
+
+			return []byte{}
+
+		}
+		return b.segs[0]
+
+	}
+
+	out := make([]byte, 0, b.cap)
+	for _, s := range b.segs {
+
+		out = append(out, s...)
+
+	}
+
+	b.segs = [][]byte{out}
+	b.starts = []int64{0}
+	return out
+
+}
+
+// Buffer flattens the buffer and returns a Buffer sharing its memory
+// with the same offsets
+func (b *SegmentedBuffer) Buffer() (out *Buffer) {
+
+	out = NewBuffer(b.Flatten())
+	out.off = b.off
+	out.boff = b.boff
+	return
+
+}
+
+// Buffers returns the segments as net.Buffers, which writes them with
+// a single writev call where supported. the segments are not copied
+func (b *SegmentedBuffer) Buffers() net.Buffers {
+
+	out := make(net.Buffers, len(b.segs))
+	copy(out, b.segs)
+	return out
+
+}
+
+// WriteTo writes every segment to w, using writev where supported
+func (b *SegmentedBuffer) WriteTo(w io.Writer) (int64, error) {
+
+	bufs := b.Buffers()
+	return bufs.WriteTo(w)
+
+}
+
+/* value retrieval */
+
+// Segments returns the amount of segments the buffer is made up of
+func (b *SegmentedBuffer) Segments() int {
+
+	return len(b.segs)
+
+}
+
+// ByteCapacity returns the capacity of the buffer
+func (b *SegmentedBuffer) ByteCapacity() int64 {
+
+	return b.cap
+
+}
+
+// BitCapacity returns the bit capacity of the buffer
+func (b *SegmentedBuffer) BitCapacity() int64 {
+
+	return b.bcap
+
+}
+
+// ByteOffset returns the current offset of the buffer
+func (b *SegmentedBuffer) ByteOffset() int64 {
+
+	return b.off
+
+}
+
+// BitOffset returns the current bit offset of the buffer
+func (b *SegmentedBuffer) BitOffset() int64 {
+
+	return b.boff
+
+}
@@ -0,0 +1,188 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+tests
+
+*/
+
+func TestSegmentedBufferRead(t *testing.T) {
+
+	buf := NewSegmentedBuffer(
+		[]byte{0x01, 0x02, 0x03},
+		[]byte{},
+		[]byte{0x04},
+		[]byte{0x05, 0x06, 0x07, 0x08, 0x09},
+	)
+
+	if buf.ByteCapacity() != 9 || buf.BitCapacity() != 72 || buf.Segments() != 3 {
+
+		t.Fatalf("unexpected buffer statistics (got %d bytes, %d bits, %d segments)", buf.ByteCapacity(), buf.BitCapacity(), buf.Segments())
+
+	}
+
+	if out := buf.ReadU16BENext(1); out[0] != 0x0102 {
+
+		t.Fatalf("expected uint16 does not match the one gotten (got %#x, expected %#x)", out[0], 0x0102)
+
+	}
+
+	// straddles all three segments
+	if out := buf.ReadU32LENext(1); out[0] != 0x06050403 {
+
+		t.Fatalf("expected uint32 does not match the one gotten (got %#x, expected %#x)", out[0], 0x06050403)
+
+	}
+
+	if out := buf.ReadBytesNext(3); !cmp.Equal([]byte{0x07, 0x08, 0x09}, out) {
+
+		t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", out, []byte{0x07, 0x08, 0x09})
+
+	}
+
+	if out := buf.ReadU64BE(0x01, 1); out[0] != 0x0203040506070809 {
+
+		t.Fatalf("expected uint64 does not match the one gotten (got %#x, expected %#x)", out[0], uint64(0x0203040506070809))
+
+	}
+
+	if out := buf.ReadBits(20, 8); out != 0x30 {
+
+		t.Fatalf("expected uint64 does not match the one gotten (got %#x, expected %#x)", out, 0x30)
+
+	}
+
+	if out := buf.ReadByteAt(0x03); out != 0x04 {
+
+		t.Fatalf("expected byte does not match the one gotten (got %#x, expected %#x)", out, 0x04)
+
+	}
+
+}
+
+func TestSegmentedBufferWrite(t *testing.T) {
+
+	segs := [][]byte{
+		{0x01, 0x02, 0x03},
+		{},
+		{0x04},
+		{0x05, 0x06, 0x07, 0x08, 0x09},
+	}
+	buf := NewSegmentedBuffer(segs...)
+
+	buf.WriteU32BE(0x02, []uint32{0xDEADBEEF})
+	buf.SeekBit(8*8, false)
+	buf.SetBitNext()
+	buf.ClearBitNext()
+	buf.FlipBit(71)
+	buf.WriteByteAt(0x00, 0x11)
+
+	expected := [][]byte{
+		{0x11, 0x02, 0xDE},
+		{},
+		{0xAD},
+		{0xBE, 0xEF, 0x07, 0x08, 0x88},
+	}
+
+	// writes go through to the original slices
+	if !cmp.Equal(expected, segs) {
+
+		t.Fatalf("expected segments do not match the ones gotten (got %#v, expected %#v)", segs, expected)
+
+	}
+
+}
+
+func TestSegmentedBufferFlatten(t *testing.T) {
+
+	expected := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09}
+
+	buf := NewSegmentedBuffer(
+		[]byte{0x01, 0x02, 0x03},
+		[]byte{},
+		[]byte{0x04},
+		[]byte{0x05, 0x06, 0x07, 0x08, 0x09},
+	)
+	buf.SeekByte(0x04, false)
+
+	out := buf.Buffer()
+	if !cmp.Equal(expected, out.Bytes()) || out.ByteOffset() != 0x04 {
+
+		t.Fatalf("expected flattened buffer does not match the one gotten (got %#v at %d)", out.Bytes(), out.ByteOffset())
+
+	}
+
+	if buf.Segments() != 1 {
+
+		t.Fatalf("expected buffer to be made up of a single segment (got %d)", buf.Segments())
+
+	}
+
+}
+
+func TestSegmentedBufferWriteTo(t *testing.T) {
+
+	var (
+		expected = []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09}
+		out      = &bytes.Buffer{}
+	)
+
+	buf := NewSegmentedBuffer(
+		[]byte{0x01, 0x02, 0x03},
+		[]byte{},
+		[]byte{0x04},
+		[]byte{0x05, 0x06, 0x07, 0x08, 0x09},
+	)
+
+	n, err := buf.WriteTo(out)
+	if err != nil || n != 9 {
+
+		t.Fatalf("unexpected result from WriteTo (wrote %d bytes, err %v)", n, err)
+
+	}
+
+	if !cmp.Equal(expected, out.Bytes()) {
+
+		t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", out.Bytes(), expected)
+
+	}
+
+}
+
+func TestSegmentedBufferReadBytesPanic(t *testing.T) {
+
+	defer panicChecker(t, BufferOverreadError)
+
+	buf := NewSegmentedBuffer([]byte{0x00, 0x00}, []byte{0x00})
+
+	_ = buf.ReadBytes(0x02, 2)
+
+}
+
+func TestSegmentedBufferWriteBytesPanic(t *testing.T) {
+
+	defer panicChecker(t, BufferUnderwriteError)
+
+	buf := NewSegmentedBuffer([]byte{0x00, 0x00}, []byte{0x00})
+
+	buf.WriteBytes(-0x01, []byte{0x00})
+
+}
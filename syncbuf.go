@@ -0,0 +1,718 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"io"
+	"sync"
+)
+
+// SyncBuffer implements a wrapper around Buffer that is safe for
+// concurrent use. methods that write to the buffer, use or move the
+// offsets or resize the buffer are serialized, while methods that read
+// bytes or bits at a specified offset only take a shared lock and may
+// run in parallel
+type SyncBuffer struct {
+	mu  sync.RWMutex
+	buf *Buffer
+}
+
+// NewSyncBuffer initializes a new SyncBuffer with the provided byte
+// slice(s) stored inside in the order provided
+func NewSyncBuffer(slices ...[]byte) *SyncBuffer {
+
+	return &SyncBuffer{
+		buf: NewBuffer(slices...),
+	}
+
+}
+
+/* locking methods */
+
+// Lock acquires exclusive access to the buffer, for sequences of
+// operations that must not be interleaved with others. the methods of
+// SyncBuffer must not be called until Unlock is; use the Buffer
+// returned by Buffer instead
+func (s *SyncBuffer) Lock() {
+
+	s.mu.Lock()
+
+}
+
+// Unlock releases the exclusive access acquired by Lock
+func (s *SyncBuffer) Unlock() {
+
+	s.mu.Unlock()
+
+}
+
+// Buffer returns the underlying Buffer. it must only be used while
+// the lock is held
+func (s *SyncBuffer) Buffer() *Buffer {
+
+	return s.buf
+
+}
+
+// Do calls f with exclusive access to the underlying Buffer
+func (s *SyncBuffer) Do(f func(b *Buffer)) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s.buf)
+
+}
+
+/* io methods */
+
+// ReadAt implements io.ReaderAt. it copies bytes from the specified
+// offset into p without touching the offsets
+func (s *SyncBuffer) ReadAt(p []byte, off int64) (n int, err error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if off < 0x00 {
+
+		return 0, BufferUnderreadError.at(off, int64(len(p)), s.buf.cap)
+
+	}
+
+	if off >= s.buf.cap {
+
+		return 0, io.EOF
+
+	}
+
+	n = copy(p, s.buf.buf[off:s.buf.cap])
+	if n < len(p) {
+
+		err = io.EOF
+
+	}
+	return
+
+}
+
+// WriteAt implements io.WriterAt. it copies p to the specified offset
+// without touching the offsets or growing the buffer
+func (s *SyncBuffer) WriteAt(p []byte, off int64) (n int, err error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if off < 0x00 {
+
+		return 0, BufferUnderwriteError.at(off, int64(len(p)), s.buf.cap)
+
+	}
+
+	if off+int64(len(p)) > s.buf.cap {
+
+		return 0, BufferOverwriteError.at(off, int64(len(p)), s.buf.cap)
+
+	}
+
+	return copy(s.buf.buf[off:], p), nil
+
+}
+
+/* bitfield methods */
+
+// ReadBit returns the bit located at the specified offset without
+// modifying the internal offset value
+func (s *SyncBuffer) ReadBit(off int64) byte {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.buf.ReadBit(off)
+
+}
+
+// ReadBitNext returns the next bit from the current offset and moves
+// the offset forward a bit
+func (s *SyncBuffer) ReadBitNext() byte {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.ReadBitNext()
+
+}
+
+// ReadBits returns the next n bits from the specified offset without
+// modifying the internal offset value
+func (s *SyncBuffer) ReadBits(off, n int64) uint64 {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.buf.ReadBits(off, n)
+
+}
+
+// ReadBitsNext returns the next n bits from the current offset and
+// moves the offset forward the amount of bits read
+func (s *SyncBuffer) ReadBitsNext(n int64) uint64 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.ReadBitsNext(n)
+
+}
+
+// SetBit sets the bit located at the specified offset without
+// modifying the internal offset value
+func (s *SyncBuffer) SetBit(off int64) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.SetBit(off)
+
+}
+
+// SetBitNext sets the next bit from the current offset and moves the
+// offset forward a bit
+func (s *SyncBuffer) SetBitNext() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.SetBitNext()
+
+}
+
+// ClearBit clears the bit located at the specified offset without
+// modifying the internal offset value
+func (s *SyncBuffer) ClearBit(off int64) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.ClearBit(off)
+
+}
+
+// ClearBitNext clears the next bit from the current offset and moves the
+// offset forward a bit
+func (s *SyncBuffer) ClearBitNext() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.ClearBitNext()
+
+}
+
+// FlipBit flips the bit located at the specified offset without
+// modifying the internal offset value
+func (s *SyncBuffer) FlipBit(off int64) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.FlipBit(off)
+
+}
+
+// FlipBitNext flips the next bit from the current offset and moves the
+// offset forward a bit
+func (s *SyncBuffer) FlipBitNext() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.FlipBitNext()
+
+}
+
+// SetBits sets the next n bits from the specified offset without
+// modifying the internal offset value
+func (s *SyncBuffer) SetBits(off int64, data uint64, n int64) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.SetBits(off, data, n)
+
+}
+
+// SetBitsNext sets the next n bits from the current offset and moves
+// the offset forward the amount of bits set
+func (s *SyncBuffer) SetBitsNext(data uint64, n int64) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.SetBitsNext(data, n)
+
+}
+
+// SeekBit seeks to bit position off of the the buffer relative to
+// the current position or exact
+func (s *SyncBuffer) SeekBit(off int64, relative bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.SeekBit(off, relative)
+
+}
+
+// AlignBit aligns the bit offset to the byte offset
+func (s *SyncBuffer) AlignBit() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.AlignBit()
+
+}
+
+/* byte buffer methods */
+
+// WriteBytes writes bytes to the buffer at the specified offset
+// without modifying the internal offset value
+func (s *SyncBuffer) WriteBytes(off int64, data []byte) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteBytes(off, data)
+
+}
+
+// WriteBytesNext writes bytes to the buffer at the current offset
+// and moves the offset forward the amount of bytes written
+func (s *SyncBuffer) WriteBytesNext(data []byte) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteBytesNext(data)
+
+}
+
+// WriteByteAt writes a byte to the buffer at the specified offset
+// without modifying the internal offset value
+func (s *SyncBuffer) WriteByteAt(off int64, data byte) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteByte(off, data)
+
+}
+
+// WriteByteNext writes a byte to the buffer at the current
+// offset and moves the offset forward the amount of bytes written
+func (s *SyncBuffer) WriteByteNext(data byte) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteByteNext(data)
+
+}
+
+// WriteU16LE writes a slice of uint16s to the buffer at the
+// specified offset in little-endian without modifying the internal
+// offset value
+func (s *SyncBuffer) WriteU16LE(off int64, data []uint16) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteU16LE(off, data)
+
+}
+
+// WriteU16LENext writes a slice of uint16s to the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes written
+func (s *SyncBuffer) WriteU16LENext(data []uint16) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteU16LENext(data)
+
+}
+
+// WriteU16BE writes a slice of uint16s to the buffer at the
+// specified offset in big-endian without modifying the internal
+// offset value
+func (s *SyncBuffer) WriteU16BE(off int64, data []uint16) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteU16BE(off, data)
+
+}
+
+// WriteU16BENext writes a slice of uint16s to the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes written
+func (s *SyncBuffer) WriteU16BENext(data []uint16) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteU16BENext(data)
+
+}
+
+// WriteU32LE writes a slice of uint32s to the buffer at the
+// specified offset in little-endian without modifying the internal
+// offset value
+func (s *SyncBuffer) WriteU32LE(off int64, data []uint32) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteU32LE(off, data)
+
+}
+
+// WriteU32LENext writes a slice of uint32s to the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes written
+func (s *SyncBuffer) WriteU32LENext(data []uint32) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteU32LENext(data)
+
+}
+
+// WriteU32BE writes a slice of uint32s to the buffer at the
+// specified offset in big-endian without modifying the internal
+// offset value
+func (s *SyncBuffer) WriteU32BE(off int64, data []uint32) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteU32BE(off, data)
+
+}
+
+// WriteU32BENext writes a slice of uint32s to the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes written
+func (s *SyncBuffer) WriteU32BENext(data []uint32) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteU32BENext(data)
+
+}
+
+// WriteU64LE writes a slice of uint64s to the buffer at the
+// specified offset in little-endian without modifying the internal
+// offset value
+func (s *SyncBuffer) WriteU64LE(off int64, data []uint64) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteU64LE(off, data)
+
+}
+
+// WriteU64LENext writes a slice of uint64s to the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes written
+func (s *SyncBuffer) WriteU64LENext(data []uint64) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteU64LENext(data)
+
+}
+
+// WriteU64BE writes a slice of uint64s to the buffer at the
+// specified offset in big-endian without modifying the internal
+// offset value
+func (s *SyncBuffer) WriteU64BE(off int64, data []uint64) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteU64BE(off, data)
+
+}
+
+// WriteU64BENext writes a slice of uint64s to the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes written
+func (s *SyncBuffer) WriteU64BENext(data []uint64) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteU64BENext(data)
+
+}
+
+// ReadBytes returns a copy of the next n bytes from the specified
+// offset without modifying the internal offset value
+func (s *SyncBuffer) ReadBytes(off, n int64) []byte {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]byte{}, s.buf.ReadBytes(off, n)...)
+
+}
+
+// ReadBytesNext returns a copy of the next n bytes from the current
+// offset and moves the offset forward the amount of bytes read
+func (s *SyncBuffer) ReadBytesNext(n int64) []byte {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]byte{}, s.buf.ReadBytesNext(n)...)
+
+}
+
+// ReadByteAt returns the next byte from the specified offset without
+// modifying the internal offset value
+func (s *SyncBuffer) ReadByteAt(off int64) byte {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.buf.ReadByte(off)
+
+}
+
+// ReadByteNext returns the next byte from the current offset and
+// moves the offset forward a byte
+func (s *SyncBuffer) ReadByteNext() byte {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.ReadByteNext()
+
+}
+
+// ReadU16LE reads a slice of uint16s from the buffer at the
+// specified offset in little-endian without modifying the internal
+// offset value
+func (s *SyncBuffer) ReadU16LE(off, n int64) []uint16 {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.buf.ReadU16LE(off, n)
+
+}
+
+// ReadU16LENext reads a slice of uint16s from the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes read
+func (s *SyncBuffer) ReadU16LENext(n int64) []uint16 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.ReadU16LENext(n)
+
+}
+
+// ReadU16BE reads a slice of uint16s from the buffer at the
+// specified offset in big-endian without modifying the internal
+// offset value
+func (s *SyncBuffer) ReadU16BE(off, n int64) []uint16 {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.buf.ReadU16BE(off, n)
+
+}
+
+// ReadU16BENext reads a slice of uint16s from the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes read
+func (s *SyncBuffer) ReadU16BENext(n int64) []uint16 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.ReadU16BENext(n)
+
+}
+
+// ReadU32LE reads a slice of uint32s from the buffer at the
+// specified offset in little-endian without modifying the internal
+// offset value
+func (s *SyncBuffer) ReadU32LE(off, n int64) []uint32 {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.buf.ReadU32LE(off, n)
+
+}
+
+// ReadU32LENext reads a slice of uint32s from the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes read
+func (s *SyncBuffer) ReadU32LENext(n int64) []uint32 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.ReadU32LENext(n)
+
+}
+
+// ReadU32BE reads a slice of uint32s from the buffer at the
+// specified offset in big-endian without modifying the internal
+// offset value
+func (s *SyncBuffer) ReadU32BE(off, n int64) []uint32 {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.buf.ReadU32BE(off, n)
+
+}
+
+// ReadU32BENext reads a slice of uint32s from the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes read
+func (s *SyncBuffer) ReadU32BENext(n int64) []uint32 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.ReadU32BENext(n)
+
+}
+
+// ReadU64LE reads a slice of uint64s from the buffer at the
+// specified offset in little-endian without modifying the internal
+// offset value
+func (s *SyncBuffer) ReadU64LE(off, n int64) []uint64 {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.buf.ReadU64LE(off, n)
+
+}
+
+// ReadU64LENext reads a slice of uint64s from the buffer at the
+// current offset in little-endian and moves the offset forward the
+// amount of bytes read
+func (s *SyncBuffer) ReadU64LENext(n int64) []uint64 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.ReadU64LENext(n)
+
+}
+
+// ReadU64BE reads a slice of uint64s from the buffer at the
+// specified offset in big-endian without modifying the internal
+// offset value
+func (s *SyncBuffer) ReadU64BE(off, n int64) []uint64 {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.buf.ReadU64BE(off, n)
+
+}
+
+// ReadU64BENext reads a slice of uint64s from the buffer at the
+// current offset in big-endian and moves the offset forward the
+// amount of bytes read
+func (s *SyncBuffer) ReadU64BENext(n int64) []uint64 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.ReadU64BENext(n)
+
+}
+
+// SeekByte seeks to position off of the buffer relative to the
+// current position or exact
+func (s *SyncBuffer) SeekByte(off int64, relative bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.SeekByte(off, relative)
+
+}
+
+// AlignByte aligns the byte offset to the bit offset
+func (s *SyncBuffer) AlignByte() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.AlignByte()
+
+}
+
+/* generic methods */
+
+// TruncateLeft truncates the buffer on the left side
+func (s *SyncBuffer) TruncateLeft(n int64) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.TruncateLeft(n)
+
+}
+
+// TruncateRight truncates the buffer on the right side
+func (s *SyncBuffer) TruncateRight(n int64) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.TruncateRight(n)
+
+}
+
+// Grow makes the buffer's capacity bigger by n bytes
+func (s *SyncBuffer) Grow(n int64) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.Grow(n)
+
+}
+
+// Reset resets the entire buffer
+func (s *SyncBuffer) Reset() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.Reset()
+
+}
+
+/* value retrieval */
+
+// Bytes returns a copy of the internal byte slice of the buffer
+func (s *SyncBuffer) Bytes() []byte {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]byte{}, s.buf.Bytes()...)
+
+}
+
+// ByteCapacity returns the capacity of the buffer
+func (s *SyncBuffer) ByteCapacity() int64 {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.buf.ByteCapacity()
+
+}
+
+// BitCapacity returns the bit capacity of the buffer
+func (s *SyncBuffer) BitCapacity() int64 {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.buf.BitCapacity()
+
+}
+
+// ByteOffset returns the current offset of the buffer
+func (s *SyncBuffer) ByteOffset() int64 {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.buf.ByteOffset()
+
+}
+
+// BitOffset returns the current bit offset of the buffer
+func (s *SyncBuffer) BitOffset() int64 {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.buf.BitOffset()
+
+}
@@ -0,0 +1,314 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+tests
+
+these are meant to be run with the race detector enabled
+
+*/
+
+func TestSyncBufferConcurrentNext(t *testing.T) {
+
+	const (
+		writers = 8
+		writes  = 256
+	)
+
+	var (
+		buf = NewSyncBuffer(make([]byte, writers*writes*4))
+		wg  sync.WaitGroup
+	)
+
+	for i := 0; i < writers; i++ {
+
+		wg.Add(1)
+		go func(i int) {
+
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+
+				buf.WriteU32BENext([]uint32{uint32(i)<<16 | uint32(j)})
+
+			}
+
+		}(i)
+
+	}
+	wg.Wait()
+
+	if buf.ByteOffset() != writers*writes*4 {
+
+		t.Fatalf("expected offset does not match the one gotten (got %d, expected %d)", buf.ByteOffset(), writers*writes*4)
+
+	}
+
+	// every value must have been written whole and exactly once
+	var (
+		seen = make(map[uint32]bool)
+		mu   sync.Mutex
+	)
+
+	buf.SeekByte(0x00, false)
+	for i := 0; i < writers; i++ {
+
+		wg.Add(1)
+		go func() {
+
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+
+				v := buf.ReadU32BENext(1)[0]
+
+				mu.Lock()
+				seen[v] = true
+				mu.Unlock()
+
+			}
+
+		}()
+
+	}
+	wg.Wait()
+
+	if len(seen) != writers*writes {
+
+		t.Fatalf("expected %d distinct values (got %d)", writers*writes, len(seen))
+
+	}
+
+}
+
+func TestSyncBufferConcurrentAt(t *testing.T) {
+
+	var (
+		buf = NewSyncBuffer(make([]byte, 64*16))
+		wg  sync.WaitGroup
+	)
+
+	for i := 0; i < 64; i++ {
+
+		wg.Add(2)
+		go func(i int) {
+
+			defer wg.Done()
+
+			chunk := make([]byte, 16)
+			for j := range chunk {
+
+				chunk[j] = byte(i)
+
+			}
+
+			if _, err := buf.WriteAt(chunk, int64(i*16)); err != nil {
+
+				t.Error(err)
+
+			}
+
+		}(i)
+
+		// readers move the shared cursor while the writers run
+		go func() {
+
+			defer wg.Done()
+			buf.SeekByte(1, true)
+			_ = buf.ByteCapacity()
+
+		}()
+
+	}
+	wg.Wait()
+
+	out := make([]byte, 16)
+	for i := 0; i < 64; i++ {
+
+		if _, err := buf.ReadAt(out, int64(i*16)); err != nil {
+
+			t.Fatal(err)
+
+		}
+
+		for _, c := range out {
+
+			if c != byte(i) {
+
+				t.Fatalf("expected chunk %d to be filled with its index (got %#v)", i, out)
+
+			}
+
+		}
+
+	}
+
+	if buf.ByteOffset() != 64 {
+
+		t.Fatalf("expected offset does not match the one gotten (got %d, expected %d)", buf.ByteOffset(), 64)
+
+	}
+
+	buf.WriteByteAt(0x00, 0xFF)
+	if out := buf.ReadByteAt(0x00); out != 0xFF {
+
+		t.Fatalf("expected byte does not match the one gotten (got %#x, expected %#x)", out, 0xFF)
+
+	}
+
+}
+
+func TestSyncBufferConcurrentOverlap(t *testing.T) {
+
+	var (
+		buf = NewSyncBuffer(make([]byte, 64))
+		wg  sync.WaitGroup
+	)
+
+	for i := 0; i < 32; i++ {
+
+		wg.Add(2)
+		go func(i int) {
+
+			defer wg.Done()
+			buf.WriteBytes(0x00, bytes.Repeat([]byte{byte(i)}, 64))
+
+		}(i)
+
+		// writes to the same range must never be seen half done
+		go func() {
+
+			defer wg.Done()
+
+			out := buf.ReadBytes(0x00, 64)
+			if !bytes.Equal(out, bytes.Repeat(out[:1], 64)) {
+
+				t.Errorf("expected a whole write to be read (got %#v)", out)
+
+			}
+
+		}()
+
+	}
+	wg.Wait()
+
+}
+
+func TestSyncBufferConcurrentBits(t *testing.T) {
+
+	var (
+		buf = NewSyncBuffer([]byte{0x00, 0x00})
+		wg  sync.WaitGroup
+	)
+
+	// every goroutine touches a different bit of the same bytes
+	for i := int64(0); i < 16; i++ {
+
+		wg.Add(1)
+		go func(i int64) {
+
+			defer wg.Done()
+			buf.SetBit(i)
+
+		}(i)
+
+	}
+	wg.Wait()
+
+	if !cmp.Equal([]byte{0xFF, 0xFF}, buf.Bytes()) {
+
+		t.Fatalf("expected byte array does not match the one gotten (got %#v, expected %#v)", buf.Bytes(), []byte{0xFF, 0xFF})
+
+	}
+
+}
+
+func TestSyncBufferLock(t *testing.T) {
+
+	var (
+		buf = NewSyncBuffer()
+		wg  sync.WaitGroup
+	)
+
+	for i := 0; i < 16; i++ {
+
+		wg.Add(1)
+		go func(i int) {
+
+			defer wg.Done()
+
+			buf.Lock()
+			b := buf.Buffer()
+			b.Grow(1)
+			b.WriteByte(b.ByteCapacity()-1, byte(i))
+			buf.Unlock()
+
+		}(i)
+
+	}
+	wg.Wait()
+
+	var sum int
+	buf.Do(func(b *Buffer) {
+
+		for _, c := range b.Bytes() {
+
+			sum += int(c)
+
+		}
+
+	})
+
+	if sum != 120 {
+
+		t.Fatalf("expected every goroutine to append its byte (sum %d, expected %d)", sum, 120)
+
+	}
+
+}
+
+func TestSyncBufferAtErrors(t *testing.T) {
+
+	buf := NewSyncBuffer([]byte{0x01, 0x02})
+
+	out := make([]byte, 4)
+	n, err := buf.ReadAt(out, 0x01)
+	if n != 1 || err != io.EOF || out[0] != 0x02 {
+
+		t.Fatalf("unexpected result from ReadAt (read %d bytes, err %v)", n, err)
+
+	}
+
+	_, err = buf.WriteAt([]byte{0x00, 0x00}, 0x01)
+	if details, ok := err.(Error); !ok || !details.Is(BufferOverwriteError) || details.Offset != 0x01 || details.Size != 2 || details.Capacity != 2 {
+
+		t.Fatalf("expected error does not match the one gotten (got %v, expected %v)", err, BufferOverwriteError)
+
+	}
+
+	_, err = buf.ReadAt(out, -0x01)
+	if details, ok := err.(Error); !ok || !details.Is(BufferUnderreadError) || details.Offset != -0x01 || details.Size != 4 {
+
+		t.Fatalf("expected error does not match the one gotten (got %v, expected %v)", err, BufferUnderreadError)
+
+	}
+
+}
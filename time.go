@@ -0,0 +1,477 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"math"
+	"time"
+)
+
+// TimeKind represents an encoding of timestamps used by binary formats
+type TimeKind int
+
+const (
+	// TimeUnix32 is a signed 32-bit count of seconds since the unix
+	// epoch, which covers 1901 to 2038
+	TimeUnix32 TimeKind = iota
+
+	// TimeUnix64 is a signed 64-bit count of seconds since the unix
+	// epoch
+	TimeUnix64
+
+	// TimeUnixMilli32 is a signed 32-bit count of milliseconds since
+	// the unix epoch, which covers about 24 days either way
+	TimeUnixMilli32
+
+	// TimeUnixMilli64 is a signed 64-bit count of milliseconds since
+	// the unix epoch
+	TimeUnixMilli64
+
+	// TimeNTP64 is the 64-bit ntp timestamp, an unsigned 32-bit count
+	// of seconds since 1900 followed by a 32-bit binary fraction of a
+	// second. only the era ending in 2036 is supported
+	TimeNTP64
+
+	// TimeFiletime is the windows FILETIME, a 64-bit count of 100
+	// nanosecond intervals since 1601
+	TimeFiletime
+
+	// TimeDOS is the ms-dos time followed by the ms-dos date, as
+	// stored by fat directory entries and zip headers. it has a
+	// resolution of two seconds, covers 1980 to 2107 and carries no
+	// time zone, so it is read as utc and written from the wall clock
+	// of the time in its own location
+	TimeDOS
+
+	// TimeHFSPlus is an unsigned 32-bit count of seconds since 1904, as
+	// used by hfs+ and the mp4 family of formats in its 32-bit form
+	TimeHFSPlus
+
+	// TimeGPS is an unsigned 16-bit gps week number, counted without
+	// rollover, followed by an unsigned 32-bit count of seconds into
+	// the week. gps time does not observe leap seconds, so it is
+	// converted to utc using the leap seconds announced up to 2017
+	TimeGPS
+)
+
+var (
+	// timeFields holds the sizes in bytes of the integer fields each
+	// kind of timestamp is made of, in the order they are stored in
+	timeFields = map[TimeKind][]int64{
+		TimeUnix32:      {4},
+		TimeUnix64:      {8},
+		TimeUnixMilli32: {4},
+		TimeUnixMilli64: {8},
+		TimeNTP64:       {4, 4},
+		TimeFiletime:    {8},
+		TimeDOS:         {2, 2},
+		TimeHFSPlus:     {4},
+		TimeGPS:         {2, 4},
+	}
+
+	// timeLeapSeconds holds the unix times at which the leap seconds
+	// since the gps epoch took effect
+	timeLeapSeconds = []int64{
+		362793600, 394329600, 425865600, 489024000, 567993600, 631152000,
+		662688000, 709948800, 741484800, 773020800, 820454400, 867715200,
+		915148800, 1136073600, 1230768000, 1341100800, 1435708800, 1483228800,
+	}
+)
+
+const (
+	// the offsets of the epochs of the timestamp encodings from the
+	// unix epoch, in seconds
+	timeNTPEpoch      = -2208988800
+	timeFiletimeEpoch = -11644473600
+	timeHFSPlusEpoch  = -2082844800
+	timeGPSEpoch      = 315964800
+
+	// timeGPSWeek is the amount of seconds in a gps week
+	timeGPSWeek = 7 * 24 * 60 * 60
+)
+
+// Size returns the amount of bytes a timestamp of the kind takes up,
+// or 0 if the kind is unknown
+func (k TimeKind) Size() (n int64) {
+
+	for _, size := range timeFields[k] {
+
+		n += size
+
+	}
+	return
+
+}
+
+/* internal use methods */
+
+// decodeTime converts the fields of a timestamp of the specified kind
+// to a time
+func decodeTime(kind TimeKind, f []uint64) (time.Time, error) {
+
+	switch kind {
+
+	case TimeUnix32:
+		return time.Unix(int64(int32(f[0])), 0).UTC(), nil
+
+	case TimeUnix64:
+		return time.Unix(int64(f[0]), 0).UTC(), nil
+
+	case TimeUnixMilli32:
+		ms := int64(int32(f[0]))
+		return time.Unix(0, ms*int64(time.Millisecond)).UTC(), nil
+
+	case TimeUnixMilli64:
+		ms := int64(f[0])
+		return time.Unix(ms/1000, ms%1000*int64(time.Millisecond)).UTC(), nil
+
+	case TimeNTP64:
+		ns := (f[1]*uint64(time.Second) + 1<<31) >> 32
+		return time.Unix(int64(f[0])+timeNTPEpoch, int64(ns)).UTC(), nil
+
+	case TimeFiletime:
+		if f[0] > math.MaxInt64 {
+
+			return time.Time{}, TimeOutOfRangeError
+
+		}
+		return time.Unix(int64(f[0]/1e7)+timeFiletimeEpoch, int64(f[0]%1e7)*100).UTC(), nil
+
+	case TimeDOS:
+		var (
+			year   = int(f[1]>>9) + 1980
+			month  = time.Month(f[1] >> 5 & 0x0F)
+			day    = int(f[1] & 0x1F)
+			hour   = int(f[0] >> 11)
+			minute = int(f[0] >> 5 & 0x3F)
+			second = int(f[0]&0x1F) * 2
+		)
+
+		t := time.Date(year, month, day, hour, minute, second, 0, time.UTC)
+		if t.Month() != month || t.Day() != day || t.Hour() != hour || t.Minute() != minute || t.Second() != second {
+
+			return time.Time{}, TimeInvalidError
+
+		}
+		return t, nil
+
+	case TimeHFSPlus:
+		return time.Unix(int64(f[0])+timeHFSPlusEpoch, 0).UTC(), nil
+
+	case TimeGPS:
+		if f[1] >= timeGPSWeek {
+
+			return time.Time{}, TimeInvalidError
+
+		}
+
+		// a leap second that took effect at utc time leap is reached
+		// by the gps clock once it is ahead by the leap seconds so far
+		var (
+			gps = timeGPSEpoch + int64(f[0])*timeGPSWeek + int64(f[1])
+			sec = gps
+		)
+
+		for i, leap := range timeLeapSeconds {
+
+			if gps < leap+int64(i)+1 {
+
+				break
+
+			}
+			sec--
+
+		}
+		return time.Unix(sec, 0).UTC(), nil
+
+	}
+	return time.Time{}, TimeInvalidError
+
+}
+
+// encodeTime converts t to the fields of a timestamp of the specified
+// kind. times that cannot be represented return TimeOutOfRangeError
+// and precision finer than the encoding supports is truncated
+func encodeTime(kind TimeKind, t time.Time) ([]uint64, error) {
+
+	var (
+		sec = t.Unix()
+		ns  = int64(t.Nanosecond())
+	)
+
+	switch kind {
+
+	case TimeUnix32:
+		if sec < math.MinInt32 || sec > math.MaxInt32 {
+
+			return nil, TimeOutOfRangeError
+
+		}
+		return []uint64{uint64(sec)}, nil
+
+	case TimeUnix64:
+		return []uint64{uint64(sec)}, nil
+
+	case TimeUnixMilli32, TimeUnixMilli64:
+		if sec < math.MinInt64/1000 || sec > (math.MaxInt64-999)/1000 {
+
+			return nil, TimeOutOfRangeError
+
+		}
+
+		ms := sec*1000 + ns/int64(time.Millisecond)
+		if kind == TimeUnixMilli32 && (ms < math.MinInt32 || ms > math.MaxInt32) {
+
+			return nil, TimeOutOfRangeError
+
+		}
+		return []uint64{uint64(ms)}, nil
+
+	case TimeNTP64:
+		sec -= timeNTPEpoch
+		if sec < 0 || sec > math.MaxUint32 {
+
+			return nil, TimeOutOfRangeError
+
+		}
+		return []uint64{uint64(sec), (uint64(ns)<<32 + uint64(time.Second)/2) / uint64(time.Second)}, nil
+
+	case TimeFiletime:
+		sec -= timeFiletimeEpoch
+		if sec < 0 || sec > (math.MaxInt64-9999999)/10000000 {
+
+			return nil, TimeOutOfRangeError
+
+		}
+		return []uint64{uint64(sec)*1e7 + uint64(ns)/100}, nil
+
+	case TimeDOS:
+		var (
+			year, month, day     = t.Date()
+			hour, minute, second = t.Clock()
+		)
+
+		if year < 1980 || year > 2107 {
+
+			return nil, TimeOutOfRangeError
+
+		}
+		return []uint64{
+			uint64(hour<<11 | minute<<5 | second/2),
+			uint64((year-1980)<<9 | int(month)<<5 | day),
+		}, nil
+
+	case TimeHFSPlus:
+		sec -= timeHFSPlusEpoch
+		if sec < 0 || sec > math.MaxUint32 {
+
+			return nil, TimeOutOfRangeError
+
+		}
+		return []uint64{uint64(sec)}, nil
+
+	case TimeGPS:
+		gps := sec - timeGPSEpoch
+		for _, leap := range timeLeapSeconds {
+
+			if sec < leap {
+
+				break
+
+			}
+			gps++
+
+		}
+
+		sec = gps
+		if sec < 0 || sec/timeGPSWeek > math.MaxUint16 {
+
+			return nil, TimeOutOfRangeError
+
+		}
+		return []uint64{uint64(sec / timeGPSWeek), uint64(sec % timeGPSWeek)}, nil
+
+	}
+	return nil, TimeInvalidError
+
+}
+
+// readTime reads a timestamp of the specified kind from the buffer at
+// the specified offset, in big endian if big is set
+func (b *Buffer) readTime(off int64, kind TimeKind, big bool) (time.Time, error) {
+
+	sizes, ok := timeFields[kind]
+	if !ok {
+
+		return time.Time{}, TimeInvalidError
+
+	}
+
+	var (
+		data = b.ReadBytes(off, kind.Size())
+		f    = make([]uint64, len(sizes))
+	)
+
+	for i, size := range sizes {
+
+		for j := int64(0); j < size; j++ {
+
+			if big {
+
+				f[i] = (f[i] << 8) | uint64(data[j])
+
+			} else {
+
+				f[i] |= uint64(data[j]) << uint64(j*8)
+
+			}
+
+		}
+		data = data[size:]
+
+	}
+	return decodeTime(kind, f)
+
+}
+
+// writeTime writes t as a timestamp of the specified kind to the
+// buffer at the specified offset, in big endian if big is set
+func (b *Buffer) writeTime(off int64, kind TimeKind, t time.Time, big bool) error {
+
+	f, err := encodeTime(kind, t)
+	if err != nil {
+
+		return err
+
+	}
+
+	data := make([]byte, 0, kind.Size())
+	for i, size := range timeFields[kind] {
+
+		for j := int64(0); j < size; j++ {
+
+			shift := uint64(j * 8)
+			if big {
+
+				shift = uint64((size - 1 - j) * 8)
+
+			}
+			data = append(data, byte(f[i]>>shift))
+
+		}
+
+	}
+
+	b.WriteBytes(off, data)
+	return nil
+
+}
+
+/* timestamp methods */
+
+// ReadTimeLE reads a timestamp of the specified kind from the buffer
+// at the specified offset in little-endian without modifying the
+// internal offset value. the time is returned in utc
+func (b *Buffer) ReadTimeLE(off int64, kind TimeKind) (time.Time, error) {
+
+	return b.readTime(off, kind, false)
+
+}
+
+// ReadTimeLENext reads a timestamp of the specified kind from the
+// buffer at the current offset in little-endian and moves the offset
+// forward the amount of bytes read if no error occurs
+func (b *Buffer) ReadTimeLENext(kind TimeKind) (out time.Time, err error) {
+
+	if out, err = b.ReadTimeLE(b.off, kind); err == nil {
+
+		b.traced(b.off*8, kind.Size()*8)
+		b.SeekByte(kind.Size(), true)
+
+	}
+	return
+
+}
+
+// ReadTimeBE reads a timestamp of the specified kind from the buffer
+// at the specified offset in big-endian without modifying the internal
+// offset value. the time is returned in utc
+func (b *Buffer) ReadTimeBE(off int64, kind TimeKind) (time.Time, error) {
+
+	return b.readTime(off, kind, true)
+
+}
+
+// ReadTimeBENext reads a timestamp of the specified kind from the
+// buffer at the current offset in big-endian and moves the offset
+// forward the amount of bytes read if no error occurs
+func (b *Buffer) ReadTimeBENext(kind TimeKind) (out time.Time, err error) {
+
+	if out, err = b.ReadTimeBE(b.off, kind); err == nil {
+
+		b.traced(b.off*8, kind.Size()*8)
+		b.SeekByte(kind.Size(), true)
+
+	}
+	return
+
+}
+
+// WriteTimeLE writes t as a timestamp of the specified kind to the
+// buffer at the specified offset in little-endian without modifying
+// the internal offset value. if t cannot be represented, nothing is
+// written and TimeOutOfRangeError is returned
+func (b *Buffer) WriteTimeLE(off int64, kind TimeKind, t time.Time) error {
+
+	return b.writeTime(off, kind, t, false)
+
+}
+
+// WriteTimeLENext writes t as a timestamp of the specified kind to
+// the buffer at the current offset in little-endian and moves the
+// offset forward the amount of bytes written if no error occurs
+func (b *Buffer) WriteTimeLENext(kind TimeKind, t time.Time) (err error) {
+
+	if err = b.WriteTimeLE(b.off, kind, t); err == nil {
+
+		b.traced(b.off*8, kind.Size()*8)
+		b.SeekByte(kind.Size(), true)
+
+	}
+	return
+
+}
+
+// WriteTimeBE writes t as a timestamp of the specified kind to the
+// buffer at the specified offset in big-endian without modifying the
+// internal offset value. if t cannot be represented, nothing is
+// written and TimeOutOfRangeError is returned
+func (b *Buffer) WriteTimeBE(off int64, kind TimeKind, t time.Time) error {
+
+	return b.writeTime(off, kind, t, true)
+
+}
+
+// WriteTimeBENext writes t as a timestamp of the specified kind to
+// the buffer at the current offset in big-endian and moves the offset
+// forward the amount of bytes written if no error occurs
+func (b *Buffer) WriteTimeBENext(kind TimeKind, t time.Time) (err error) {
+
+	if err = b.WriteTimeBE(b.off, kind, t); err == nil {
+
+		b.traced(b.off*8, kind.Size()*8)
+		b.SeekByte(kind.Size(), true)
+
+	}
+	return
+
+}
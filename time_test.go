@@ -0,0 +1,194 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"bytes"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+tests
+
+*/
+
+func TestBufferTime(t *testing.T) {
+
+	for _, c := range []struct {
+		kind TimeKind
+		big  bool
+		time time.Time
+		data []byte
+	}{
+		{TimeUnix32, false, time.Date(2019, 6, 15, 13, 45, 30, 0, time.UTC), []byte{0x7A, 0xF6, 0x04, 0x5D}},
+		{TimeUnix32, true, time.Date(1901, 12, 13, 20, 45, 52, 0, time.UTC), []byte{0x80, 0x00, 0x00, 0x00}},
+		{TimeUnix64, true, time.Date(1969, 12, 31, 23, 59, 59, 0, time.UTC), []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{TimeUnixMilli32, false, time.Date(1970, 1, 1, 0, 0, 1, 500e6, time.UTC), []byte{0xDC, 0x05, 0x00, 0x00}},
+		{TimeUnixMilli64, false, time.Date(1969, 12, 31, 23, 59, 59, 999e6, time.UTC), []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{TimeNTP64, true, time.Date(2000, 1, 1, 0, 0, 0, 500e6, time.UTC), []byte{0xBC, 0x17, 0xC2, 0x00, 0x80, 0x00, 0x00, 0x00}},
+		{TimeFiletime, false, time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), []byte{0x00, 0x80, 0x3E, 0xD5, 0xDE, 0xB1, 0x9D, 0x01}},
+		{TimeDOS, false, time.Date(2019, 6, 15, 13, 45, 30, 0, time.UTC), []byte{0xAF, 0x6D, 0xCF, 0x4E}},
+		{TimeHFSPlus, true, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), []byte{0xB4, 0x92, 0xF4, 0x00}},
+		{TimeGPS, false, time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), []byte{0x8A, 0x07, 0x12, 0x00, 0x00, 0x00}},
+		{TimeGPS, false, time.Date(2016, 12, 31, 23, 59, 59, 0, time.UTC), []byte{0x8A, 0x07, 0x10, 0x00, 0x00, 0x00}},
+		{TimeGPS, true, time.Date(1980, 1, 6, 0, 0, 0, 0, time.UTC), []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+	} {
+
+		buf := NewBuffer(make([]byte, c.kind.Size()))
+
+		write, read := buf.WriteTimeLENext, buf.ReadTimeLE
+		if c.big {
+
+			write, read = buf.WriteTimeBENext, buf.ReadTimeBE
+
+		}
+
+		if err := write(c.kind, c.time); err != nil || buf.ByteOffset() != c.kind.Size() {
+
+			t.Fatalf("unexpected error writing %v as kind %d (%v)", c.time, c.kind, err)
+
+		}
+
+		if !cmp.Equal(c.data, buf.Bytes()) {
+
+			t.Fatalf("expected byte slice does not match the one gotten for kind %d (got %#v, expected %#v)", c.kind, buf.Bytes(), c.data)
+
+		}
+
+		if out, err := read(0x00, c.kind); err != nil || !out.Equal(c.time) {
+
+			t.Fatalf("expected time does not match the one gotten for kind %d (got %v, %v, expected %v)", c.kind, out, err, c.time)
+
+		}
+
+	}
+
+}
+
+func TestCursorTime(t *testing.T) {
+
+	var (
+		expected = []byte{0x7A, 0xF6, 0x04, 0x5D, 0x6D, 0xAF, 0x4E, 0xCF}
+		tm       = time.Date(2019, 6, 15, 13, 45, 30, 0, time.UTC)
+	)
+
+	buf := NewBuffer(make([]byte, 8))
+
+	c := buf.Cursor(0x00)
+	if err := c.WriteTimeLENext(TimeUnix32, tm); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if err := c.WriteTimeBENext(TimeDOS, tm); err != nil || c.ByteOffset() != 0x08 {
+
+		t.Fatalf("unexpected error writing a timestamp (%v at %d)", err, c.ByteOffset())
+
+	}
+
+	if !cmp.Equal(expected, buf.Bytes()) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v, expected %#v)", buf.Bytes(), expected)
+
+	}
+
+	c.SeekByte(0x00, false)
+	if out, err := c.ReadTimeLENext(TimeUnix32); err != nil || !out.Equal(tm) {
+
+		t.Fatalf("expected time does not match the one gotten (got %v, %v, expected %v)", out, err, tm)
+
+	}
+
+	if out, err := c.ReadTimeBENext(TimeDOS); err != nil || !out.Equal(tm) || c.ByteOffset() != 0x08 {
+
+		t.Fatalf("expected time does not match the one gotten (got %v, %v, expected %v)", out, err, tm)
+
+	}
+
+}
+
+func TestReaderBufferTime(t *testing.T) {
+
+	tm := time.Date(2019, 6, 15, 13, 45, 30, 0, time.UTC)
+
+	buf := NewReaderBuffer(iotest.OneByteReader(bytes.NewReader([]byte{0x7A, 0xF6, 0x04, 0x5D, 0x6D, 0xAF, 0x4E, 0xCF})))
+
+	if out, err := buf.ReadTimeLENext(TimeUnix32); err != nil || !out.Equal(tm) {
+
+		t.Fatalf("expected time does not match the one gotten (got %v, %v, expected %v)", out, err, tm)
+
+	}
+
+	if out, err := buf.ReadTimeBENext(TimeDOS); err != nil || !out.Equal(tm) {
+
+		t.Fatalf("expected time does not match the one gotten (got %v, %v, expected %v)", out, err, tm)
+
+	}
+
+}
+
+func TestBufferTimeRange(t *testing.T) {
+
+	buf := NewBuffer(make([]byte, 8))
+
+	for kind, tm := range map[TimeKind]time.Time{
+		TimeUnix32:      time.Date(2038, 1, 19, 3, 14, 8, 0, time.UTC),
+		TimeUnixMilli32: time.Date(1970, 1, 26, 0, 0, 0, 0, time.UTC),
+		TimeNTP64:       time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC),
+		TimeFiletime:    time.Date(1600, 12, 31, 0, 0, 0, 0, time.UTC),
+		TimeDOS:         time.Date(2108, 1, 1, 0, 0, 0, 0, time.UTC),
+		TimeHFSPlus:     time.Date(2040, 2, 7, 0, 0, 0, 0, time.UTC),
+		TimeGPS:         time.Date(1980, 1, 5, 0, 0, 0, 0, time.UTC),
+	} {
+
+		if err := buf.WriteTimeLE(0x00, kind, tm); err != TimeOutOfRangeError {
+
+			t.Fatalf("expected %v to be out of range for kind %d (got %v)", tm, kind, err)
+
+		}
+
+	}
+
+	if !cmp.Equal(make([]byte, 8), buf.Bytes()) {
+
+		t.Fatalf("expected nothing to be written (got %#v)", buf.Bytes())
+
+	}
+
+	// february 30th
+	buf.WriteBytes(0x00, []byte{0x00, 0x00, 0x5E, 0x4E})
+	if _, err := buf.ReadTimeLE(0x00, TimeDOS); err != TimeInvalidError {
+
+		t.Fatalf("expected an invalid timestamp error (got %v)", err)
+
+	}
+
+	buf.WriteBytes(0x00, []byte{0x00, 0x00, 0x80, 0x3A, 0x09, 0x00})
+	if _, err := buf.ReadTimeBE(0x00, TimeGPS); err != TimeInvalidError {
+
+		t.Fatalf("expected an invalid timestamp error (got %v)", err)
+
+	}
+
+	if _, err := buf.ReadTimeLENext(TimeKind(-1)); err != TimeInvalidError || buf.ByteOffset() != 0 {
+
+		t.Fatalf("expected an invalid timestamp error (got %v)", err)
+
+	}
+
+}
@@ -0,0 +1,392 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TraceField represents a named range of bits that was read from or
+// written to a traced buffer
+type TraceField struct {
+	// Path is the dot-separated name given to the field
+	Path string
+
+	// BitOffset and BitSize describe the range of bits the field
+	// occupied
+	BitOffset int64
+	BitSize   int64
+}
+
+// ByteOffset returns the offset of the first byte the field occupied
+func (f TraceField) ByteOffset() int64 {
+
+	return f.BitOffset / 8
+
+}
+
+// ByteSize returns the amount of bytes the field occupied, including
+// bytes it only partially covers
+func (f TraceField) ByteSize() int64 {
+
+	return (f.BitOffset+f.BitSize+7)/8 - f.ByteOffset()
+
+}
+
+// TraceNode represents a node of the tree built from the paths of the
+// traced fields. the range of a node that has children covers the
+// ranges of all of its children
+type TraceNode struct {
+	Name       string       `json:"name"`
+	ByteOffset int64        `json:"byteOffset"`
+	ByteSize   int64        `json:"byteSize"`
+	BitOffset  int64        `json:"bitOffset"`
+	BitSize    int64        `json:"bitSize"`
+	Children   []*TraceNode `json:"children,omitempty"`
+}
+
+// String formats the node and its children as an indented tree
+func (n *TraceNode) String() string {
+
+	var sb strings.Builder
+	n.format(&sb, 0)
+	return sb.String()
+
+}
+
+// format writes the node to sb at the specified depth
+func (n *TraceNode) format(sb *strings.Builder, depth int) {
+
+	name := n.Name
+	if depth == 0 && name == "" {
+
+		name = "."
+
+	}
+
+	fmt.Fprintf(sb, "%s%s [%#x, %#x) bits [%d, %d)\n",
+		strings.Repeat("  ", depth), name,
+		n.ByteOffset, n.ByteOffset+n.ByteSize,
+		n.BitOffset, n.BitOffset+n.BitSize,
+	)
+
+	for _, c := range n.Children {
+
+		c.format(sb, depth+1)
+
+	}
+
+}
+
+// child returns the child of the node that the remaining path rest
+// should be added to, creating it if needed. the last child with the
+// same name is reused unless it already holds rest, which means that
+// the field is being repeated
+func (n *TraceNode) child(name string, rest []string) *TraceNode {
+
+	if len(rest) > 0 && len(n.Children) > 0 {
+
+		c := n.Children[len(n.Children)-1]
+		if c.Name == name && len(c.Children) > 0 && !c.holds(rest) {
+
+			return c
+
+		}
+
+	}
+
+	c := &TraceNode{Name: name}
+	n.Children = append(n.Children, c)
+	return c
+
+}
+
+// holds reports whether the node already has a descendant at path
+func (n *TraceNode) holds(path []string) bool {
+
+	for _, c := range n.Children {
+
+		if c.Name == path[0] && (len(path) == 1 || c.holds(path[1:])) {
+
+			return true
+
+		}
+
+	}
+	return false
+
+}
+
+// span computes the ranges of the node and its children from the
+// ranges of the leaves
+func (n *TraceNode) span() {
+
+	if len(n.Children) == 0 {
+
+		return
+
+	}
+
+	start, end := int64(-1), int64(0)
+	for _, c := range n.Children {
+
+		c.span()
+		if start < 0 || c.BitOffset < start {
+
+			start = c.BitOffset
+
+		}
+
+		if c.BitOffset+c.BitSize > end {
+
+			end = c.BitOffset + c.BitSize
+
+		}
+
+	}
+
+	n.BitOffset = start
+	n.BitSize = end - start
+	n.ByteOffset = start / 8
+	n.ByteSize = (end+7)/8 - n.ByteOffset
+
+}
+
+// Tracer records the range of every named field that is read from or
+// written to a Buffer through its *Next methods
+type Tracer struct {
+	fields  []TraceField
+	pending string
+}
+
+// NewTracer initializes a new Tracer
+func NewTracer() *Tracer {
+
+	return &Tracer{}
+
+}
+
+/* internal use methods */
+
+// record adds a field spanning n bits at off if a name is pending
+func (t *Tracer) record(off, n int64) {
+
+	if t.pending == "" {
+
+		return
+
+	}
+
+	t.fields = append(t.fields, TraceField{
+		Path:      t.pending,
+		BitOffset: off,
+		BitSize:   n,
+	})
+	t.pending = ""
+
+}
+
+/* generic methods */
+
+// Fields returns the recorded fields in the order they were recorded
+func (t *Tracer) Fields() []TraceField {
+
+	return append([]TraceField{}, t.fields...)
+
+}
+
+// Reset discards the recorded fields and any pending name
+func (t *Tracer) Reset() {
+
+	t.fields = t.fields[:0]
+	t.pending = ""
+
+}
+
+// Tree builds a tree out of the paths of the recorded fields. the
+// returned node is an unnamed root spanning every recorded field
+func (t *Tracer) Tree() (root *TraceNode) {
+
+	root = &TraceNode{}
+	for _, f := range t.fields {
+
+		var (
+			path = strings.Split(f.Path, ".")
+			n    = root
+		)
+
+		for i, name := range path {
+
+			n = n.child(name, path[i+1:])
+
+		}
+
+		n.BitOffset = f.BitOffset
+		n.BitSize = f.BitSize
+		n.ByteOffset = f.ByteOffset()
+		n.ByteSize = f.ByteSize()
+
+	}
+
+	root.span()
+	return
+
+}
+
+// MarshalJSON encodes the tree built by Tree as json
+func (t *Tracer) MarshalJSON() ([]byte, error) {
+
+	return json.Marshal(t.Tree())
+
+}
+
+// traceColors are the ansi colors cycled through when coloring fields
+// in a hexdump
+var traceColors = []string{"31", "32", "33", "34", "35", "36"}
+
+// Hexdump writes an annotated hexdump of data to w. each line is
+// followed by the paths of the fields that start on it and, if color
+// is set, the bytes of each field are colored with ansi escapes.
+// bytes shared by several fields take the color of the first one
+func (t *Tracer) Hexdump(w io.Writer, data []byte, color bool) (err error) {
+
+	owner := make([]int, len(data))
+	for i := range owner {
+
+		owner[i] = -1
+
+	}
+
+	for i, f := range t.fields {
+
+		for j := f.ByteOffset(); j < f.ByteOffset()+f.ByteSize() && j < int64(len(data)); j++ {
+
+			if j >= 0 && owner[j] < 0 {
+
+				owner[j] = i
+
+			}
+
+		}
+
+	}
+
+	for line := 0; line < len(data); line += 16 {
+
+		var (
+			sb     strings.Builder
+			labels []string
+		)
+
+		fmt.Fprintf(&sb, "%08x ", line)
+		for i := line; i < line+16; i++ {
+
+			if i%8 == 0 {
+
+				sb.WriteByte(' ')
+
+			}
+
+			if i >= len(data) {
+
+				sb.WriteString("   ")
+				continue
+
+			}
+
+			if color && owner[i] >= 0 {
+
+				fmt.Fprintf(&sb, "\x1b[%sm%02x\x1b[0m ", traceColors[owner[i]%len(traceColors)], data[i])
+
+			} else {
+
+				fmt.Fprintf(&sb, "%02x ", data[i])
+
+			}
+
+		}
+
+		for i, f := range t.fields {
+
+			if off := f.ByteOffset(); off >= int64(line) && off < int64(line+16) {
+
+				label := f.Path
+				if color {
+
+					label = fmt.Sprintf("\x1b[%sm%s\x1b[0m", traceColors[i%len(traceColors)], label)
+
+				}
+				labels = append(labels, label)
+
+			}
+
+		}
+
+		if len(labels) > 0 {
+
+			fmt.Fprintf(&sb, " %s", strings.Join(labels, ", "))
+
+		}
+
+		if _, err = io.WriteString(w, strings.TrimRight(sb.String(), " ")+"\n"); err != nil {
+
+			return
+
+		}
+
+	}
+	return
+
+}
+
+/* buffer methods */
+
+// traced records a field spanning n bits at off if tracing is enabled
+func (b *Buffer) traced(off, n int64) {
+
+	if b.tracer != nil {
+
+		b.tracer.record(off, n)
+
+	}
+
+}
+
+// SetTracer enables tracing on the buffer using the specified Tracer.
+// a nil Tracer disables tracing
+func (b *Buffer) SetTracer(t *Tracer) {
+
+	b.tracer = t
+
+}
+
+// Tracer returns the Tracer in use by the buffer, if any
+func (b *Buffer) Tracer() *Tracer {
+
+	return b.tracer
+
+}
+
+// Trace names the field accessed by the next call to one of the
+// *Next methods. it does nothing if tracing is disabled
+func (b *Buffer) Trace(path string) {
+
+	if b.tracer != nil {
+
+		b.tracer.pending = path
+
+	}
+
+}
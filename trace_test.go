@@ -0,0 +1,180 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+tests
+
+*/
+
+func TestTracer(t *testing.T) {
+
+	var (
+		expectedFields = []TraceField{
+			{"header.magic", 0, 16},
+			{"header.flags.version", 16, 3},
+			{"header.flags.mode", 19, 5},
+			{"entry.length", 32, 8},
+			{"entry.data", 40, 8},
+			{"entry.length", 48, 8},
+			{"entry.data", 56, 16},
+		}
+		expectedTree = `. [0x0, 0x9) bits [0, 72)
+  header [0x0, 0x3) bits [0, 24)
+    magic [0x0, 0x2) bits [0, 16)
+    flags [0x2, 0x3) bits [16, 24)
+      version [0x2, 0x3) bits [16, 19)
+      mode [0x2, 0x3) bits [19, 24)
+  entry [0x4, 0x6) bits [32, 48)
+    length [0x4, 0x5) bits [32, 40)
+    data [0x5, 0x6) bits [40, 48)
+  entry [0x6, 0x9) bits [48, 72)
+    length [0x6, 0x7) bits [48, 56)
+    data [0x7, 0x9) bits [56, 72)
+`
+		expectedHexdump = "00000000  ca fe a5 02 01 aa 02 bb  cc                       header.magic, header.flags.version, header.flags.mode, entry.length, entry.data, entry.length, entry.data\n"
+	)
+
+	// a small header followed by two entries
+	buf := NewBuffer([]byte{
+		0xCA, 0xFE, 0xA5, 0x02,
+		0x01, 0xAA,
+		0x02, 0xBB, 0xCC,
+	})
+	buf.SetTracer(NewTracer())
+
+	buf.Trace("header.magic")
+	_ = buf.ReadU16BENext(1)
+	buf.SeekBit(16, false)
+	buf.Trace("header.flags.version")
+	_ = buf.ReadBitsNext(3)
+	buf.Trace("header.flags.mode")
+	_ = buf.ReadBitsNext(5)
+
+	buf.SeekByte(0x03, false)
+
+	// untraced accesses are not recorded
+	count := buf.ReadByteNext()
+
+	for i := byte(0); i < count; i++ {
+
+		buf.Trace("entry.length")
+		n := buf.ReadByteNext()
+		buf.Trace("entry.data")
+		_ = buf.ReadBytesNext(int64(n))
+
+	}
+
+	fields := buf.Tracer().Fields()
+	if !cmp.Equal(expectedFields, fields) {
+
+		t.Fatalf("expected fields do not match the ones gotten (got %#v, expected %#v)", fields, expectedFields)
+
+	}
+
+	tree := buf.Tracer().Tree()
+	if tree.String() != expectedTree {
+
+		t.Fatalf("expected tree does not match the one gotten (got\n%s\nexpected\n%s)", tree.String(), expectedTree)
+
+	}
+
+	encoded, err := json.Marshal(buf.Tracer())
+	if err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	var root TraceNode
+	if err = json.Unmarshal(encoded, &root); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if !cmp.Equal(tree, &root) {
+
+		t.Fatalf("expected tree does not match the one gotten (got %s)", encoded)
+
+	}
+
+	out := &bytes.Buffer{}
+	if err := buf.Tracer().Hexdump(out, buf.Bytes(), false); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if out.String() != expectedHexdump {
+
+		t.Fatalf("expected hexdump does not match the one gotten (got %q, expected %q)", out.String(), expectedHexdump)
+
+	}
+
+	out.Reset()
+	if err := buf.Tracer().Hexdump(out, buf.Bytes(), true); err != nil {
+
+		t.Fatal(err)
+
+	}
+
+	if !bytes.Contains(out.Bytes(), []byte("\x1b[31mca\x1b[0m \x1b[31mfe\x1b[0m")) {
+
+		t.Fatalf("expected hexdump to color the bytes of the first field (got %q)", out.String())
+
+	}
+
+}
+
+func TestTracerDisabled(t *testing.T) {
+
+	buf := NewBuffer([]byte{0x00})
+	buf.Trace("ignored")
+	_ = buf.ReadByteNext()
+
+	if buf.Tracer() != nil {
+
+		t.Fatalf("expected tracing to be disabled by default")
+
+	}
+
+}
+
+/*
+
+benchmarks
+
+*/
+
+func BenchmarkBufferReadU32LENextUntraced(b *testing.B) {
+
+	buf := NewBuffer(make([]byte, 4))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+
+		buf.SeekByte(0x00, false)
+		_ = buf.ReadU32LENext(1)
+
+	}
+
+}
@@ -0,0 +1,312 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// hostLittleEndian is set if the host stores integers in little
+// endian byte order
+var hostLittleEndian = func() bool {
+
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+
+}()
+
+/* internal use methods */
+
+// view returns a pointer to the byte located at off if n elements of
+// the specified size stored there in the specified byte order can be
+// used in place, or nil if they have to be copied. the bounds of the
+// access are checked as if it were a read
+func (b *Buffer) view(off, n, size int64, little bool) unsafe.Pointer {
+
+	if (off + n*size) > b.cap {
+
+		panic(BufferOverreadError.at(off, n*size, b.cap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderreadError.at(off, n*size, b.cap))
+
+	}
+
+	if n < 0 {
+
+		panic(BufferInvalidByteCountError)
+
+	}
+
+	if n == 0 || little != hostLittleEndian {
+
+		return nil
+
+	}
+
+	p := unsafe.Pointer(&b.buf[off])
+	if uintptr(p)%uintptr(size) != 0 {
+
+		return nil
+
+	}
+	return p
+
+}
+
+/* view methods */
+
+// AsU16LE returns n little endian uint16s located at the specified
+// offset. on little-endian hosts the returned slice shares memory with
+// the buffer whenever the offset is suitably aligned, so writes to it
+// are visible in the buffer and it must not be used after the buffer
+// is grown. otherwise, the values are copied like ReadU16LE does
+func (b *Buffer) AsU16LE(off, n int64) (out []uint16) {
+
+	p := b.view(off, n, 2, true)
+	if p == nil {
+
+		if n == 0 {
+
+			return []uint16{}
+
+		}
+		return b.ReadU16LE(off, n)
+
+	}
+
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&out))
+	h.Data = uintptr(p)
+	h.Len = int(n)
+	h.Cap = int(n)
+	return
+
+}
+
+// AsU16BE returns n big endian uint16s located at the specified
+// offset. on big-endian hosts the returned slice shares memory with
+// the buffer whenever the offset is suitably aligned, so writes to it
+// are visible in the buffer and it must not be used after the buffer
+// is grown. otherwise, the values are copied like ReadU16BE does
+func (b *Buffer) AsU16BE(off, n int64) (out []uint16) {
+
+	p := b.view(off, n, 2, false)
+	if p == nil {
+
+		if n == 0 {
+
+			return []uint16{}
+
+		}
+		return b.ReadU16BE(off, n)
+
+	}
+
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&out))
+	h.Data = uintptr(p)
+	h.Len = int(n)
+	h.Cap = int(n)
+	return
+
+}
+
+// AsU32LE returns n little endian uint32s located at the specified
+// offset. on little-endian hosts the returned slice shares memory with
+// the buffer whenever the offset is suitably aligned, so writes to it
+// are visible in the buffer and it must not be used after the buffer
+// is grown. otherwise, the values are copied like ReadU32LE does
+func (b *Buffer) AsU32LE(off, n int64) (out []uint32) {
+
+	p := b.view(off, n, 4, true)
+	if p == nil {
+
+		if n == 0 {
+
+			return []uint32{}
+
+		}
+		return b.ReadU32LE(off, n)
+
+	}
+
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&out))
+	h.Data = uintptr(p)
+	h.Len = int(n)
+	h.Cap = int(n)
+	return
+
+}
+
+// AsU32BE returns n big endian uint32s located at the specified
+// offset. on big-endian hosts the returned slice shares memory with
+// the buffer whenever the offset is suitably aligned, so writes to it
+// are visible in the buffer and it must not be used after the buffer
+// is grown. otherwise, the values are copied like ReadU32BE does
+func (b *Buffer) AsU32BE(off, n int64) (out []uint32) {
+
+	p := b.view(off, n, 4, false)
+	if p == nil {
+
+		if n == 0 {
+
+			return []uint32{}
+
+		}
+		return b.ReadU32BE(off, n)
+
+	}
+
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&out))
+	h.Data = uintptr(p)
+	h.Len = int(n)
+	h.Cap = int(n)
+	return
+
+}
+
+// AsU64LE returns n little endian uint64s located at the specified
+// offset. on little-endian hosts the returned slice shares memory with
+// the buffer whenever the offset is suitably aligned, so writes to it
+// are visible in the buffer and it must not be used after the buffer
+// is grown. otherwise, the values are copied like ReadU64LE does
+func (b *Buffer) AsU64LE(off, n int64) (out []uint64) {
+
+	p := b.view(off, n, 8, true)
+	if p == nil {
+
+		if n == 0 {
+
+			return []uint64{}
+
+		}
+		return b.ReadU64LE(off, n)
+
+	}
+
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&out))
+	h.Data = uintptr(p)
+	h.Len = int(n)
+	h.Cap = int(n)
+	return
+
+}
+
+// AsU64BE returns n big endian uint64s located at the specified
+// offset. on big-endian hosts the returned slice shares memory with
+// the buffer whenever the offset is suitably aligned, so writes to it
+// are visible in the buffer and it must not be used after the buffer
+// is grown. otherwise, the values are copied like ReadU64BE does
+func (b *Buffer) AsU64BE(off, n int64) (out []uint64) {
+
+	p := b.view(off, n, 8, false)
+	if p == nil {
+
+		if n == 0 {
+
+			return []uint64{}
+
+		}
+		return b.ReadU64BE(off, n)
+
+	}
+
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&out))
+	h.Data = uintptr(p)
+	h.Len = int(n)
+	h.Cap = int(n)
+	return
+
+}
+
+/* byte swapping methods */
+
+// ByteSwapU16 reverses the byte order of n uint16s located at the
+// specified offset in place without modifying the internal offset
+// value
+func (b *Buffer) ByteSwapU16(off, n int64) {
+
+	if (off + n*2) > b.cap {
+
+		panic(BufferOverwriteError.at(off, n*2, b.cap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderwriteError.at(off, n*2, b.cap))
+
+	}
+
+	for i := int64(0); i < n; i++ {
+
+		p := b.buf[off+i*2 : off+i*2+2]
+		p[0], p[1] = p[1], p[0]
+
+	}
+
+}
+
+// ByteSwapU32 reverses the byte order of n uint32s located at the
+// specified offset in place without modifying the internal offset
+// value
+func (b *Buffer) ByteSwapU32(off, n int64) {
+
+	if (off + n*4) > b.cap {
+
+		panic(BufferOverwriteError.at(off, n*4, b.cap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderwriteError.at(off, n*4, b.cap))
+
+	}
+
+	for i := int64(0); i < n; i++ {
+
+		p := b.buf[off+i*4 : off+i*4+4]
+		p[0], p[1], p[2], p[3] = p[3], p[2], p[1], p[0]
+
+	}
+
+}
+
+// ByteSwapU64 reverses the byte order of n uint64s located at the
+// specified offset in place without modifying the internal offset
+// value
+func (b *Buffer) ByteSwapU64(off, n int64) {
+
+	if (off + n*8) > b.cap {
+
+		panic(BufferOverwriteError.at(off, n*8, b.cap))
+
+	}
+
+	if off < 0x00 {
+
+		panic(BufferUnderwriteError.at(off, n*8, b.cap))
+
+	}
+
+	for i := int64(0); i < n; i++ {
+
+		p := b.buf[off+i*8 : off+i*8+8]
+		p[0], p[1], p[2], p[3], p[4], p[5], p[6], p[7] = p[7], p[6], p[5], p[4], p[3], p[2], p[1], p[0]
+
+	}
+
+}
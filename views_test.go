@@ -0,0 +1,160 @@
+/*
+
+crunch - utilities for taking bytes out of things
+copyright (c) 2019 superwhiskers <whiskerdev@protonmail.com>
+
+this source code form is subject to the terms of the mozilla public
+license, v. 2.0. if a copy of the mpl was not distributed with this
+file, you can obtain one at http://mozilla.org/MPL/2.0/.
+
+*/
+
+package crunch
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+/*
+
+tests
+
+*/
+
+func TestBufferAsU32(t *testing.T) {
+
+	data := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+	buf := NewBuffer(data)
+
+	if out := buf.AsU32LE(0x00, 2); !cmp.Equal([]uint32{0x03020100, 0x07060504}, out) {
+
+		t.Fatalf("expected uint32 slice does not match the one gotten (got %#v, expected %#v)", out, []uint32{0x03020100, 0x07060504})
+
+	}
+
+	if out := buf.AsU32BE(0x01, 2); !cmp.Equal([]uint32{0x01020304, 0x05060708}, out) {
+
+		t.Fatalf("expected uint32 slice does not match the one gotten (got %#v, expected %#v)", out, []uint32{0x01020304, 0x05060708})
+
+	}
+
+	if out := buf.AsU32LE(0x04, 0); !cmp.Equal([]uint32{}, out) {
+
+		t.Fatalf("expected an empty slice (got %#v)", out)
+
+	}
+
+}
+
+func TestBufferAsU32Aliasing(t *testing.T) {
+
+	if !hostLittleEndian {
+
+		t.Skip("host is not little endian")
+
+	}
+
+	data := make([]byte, 16)
+	buf := NewBuffer(data)
+
+	// the backing array of a fresh buffer is at least word aligned
+	view := buf.AsU32LE(0x04, 2)
+	view[1] = 0xDEADBEEF
+	if out := buf.ReadU32LE(0x08, 1)[0]; out != 0xDEADBEEF {
+
+		t.Fatalf("expected the view to alias the buffer (got %#x)", out)
+
+	}
+
+	// misaligned offsets fall back to a copy
+	view = buf.AsU32LE(0x01, 2)
+	view[0] = 0xFFFFFFFF
+	if out := buf.ReadU32LE(0x01, 1)[0]; out == 0xFFFFFFFF {
+
+		t.Fatalf("expected a misaligned view to be a copy")
+
+	}
+
+	if uintptr(unsafe.Pointer(&buf.AsU64LE(0x00, 1)[0])) != uintptr(unsafe.Pointer(&buf.buf[0])) {
+
+		t.Fatalf("expected an aligned uint64 view to alias the buffer")
+
+	}
+
+}
+
+func TestBufferByteSwap(t *testing.T) {
+
+	buf := NewBuffer([]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08})
+
+	buf.ByteSwapU16(0x00, 2)
+	if !cmp.Equal([]byte{0x02, 0x01, 0x04, 0x03, 0x05, 0x06, 0x07, 0x08}, buf.Bytes()) {
+
+		t.Fatalf("expected byte slice does not match the one gotten (got %#v)", buf.Bytes())
+
+	}
+
+	buf.ByteSwapU32(0x04, 1)
+	if out := buf.ReadU32LE(0x04, 1)[0]; out != 0x05060708 {
+
+		t.Fatalf("expected uint32 does not match the one gotten (got %#x, expected %#x)", out, 0x05060708)
+
+	}
+
+	buf.ByteSwapU64(0x00, 1)
+	if out := buf.ReadU64BE(0x00, 1)[0]; out != 0x0506070803040102 {
+
+		t.Fatalf("expected uint64 does not match the one gotten (got %#x, expected %#x)", out, uint64(0x0506070803040102))
+
+	}
+
+}
+
+func TestBufferViewOutOfBounds(t *testing.T) {
+
+	buf := NewBuffer(make([]byte, 8))
+
+	func() {
+
+		defer panicChecker(t, BufferOverreadError)
+		buf.AsU32LE(0x06, 1)
+
+	}()
+
+	func() {
+
+		defer panicChecker(t, BufferUnderreadError)
+		buf.AsU16BE(-1, 1)
+
+	}()
+
+	func() {
+
+		defer panicChecker(t, BufferOverwriteError)
+		buf.ByteSwapU64(0x01, 1)
+
+	}()
+
+}
+
+/*
+
+benchmarks
+
+*/
+
+func BenchmarkBufferAsU32LE(b *testing.B) {
+
+	buf := NewBuffer(make([]byte, 1<<16))
+
+	b.SetBytes(1 << 16)
+	for i := 0; i < b.N; i++ {
+
+		_ = buf.AsU32LE(0x00, 1<<14)
+
+	}
+
+}